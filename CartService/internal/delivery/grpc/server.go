@@ -0,0 +1,105 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"sort"
+
+	"github.com/egannguyen/go-kafka-ecommerce/cart-service/internal/delivery/grpc/pb"
+	"github.com/egannguyen/go-kafka-ecommerce/cart-service/internal/domain"
+	"github.com/egannguyen/go-kafka-ecommerce/cart-service/internal/usecase"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type Server struct {
+	pb.UnimplementedCartServiceServer
+	useCase usecase.CartUseCase
+}
+
+func NewServer(useCase usecase.CartUseCase) *Server {
+	return &Server{useCase: useCase}
+}
+
+func (s *Server) GetCart(ctx context.Context, req *pb.GetCartRequest) (*pb.Cart, error) {
+	if req.CartId == "" {
+		return nil, status.Error(codes.InvalidArgument, "missing cart id")
+	}
+
+	return s.cart(ctx, req.CartId)
+}
+
+func (s *Server) AddItem(ctx context.Context, req *pb.AddItemRequest) (*pb.Cart, error) {
+	if req.CartId == "" || req.ProductId == "" {
+		return nil, status.Error(codes.InvalidArgument, "missing cart or product id")
+	}
+
+	err := s.useCase.AddItemToCart(ctx, req.CustomerId, req.CartId, req.ProductId, int(req.Quantity), float64(req.Price))
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return s.cart(ctx, req.CartId)
+}
+
+func (s *Server) RemoveItem(ctx context.Context, req *pb.RemoveItemRequest) (*pb.Cart, error) {
+	if req.CartId == "" || req.ProductId == "" {
+		return nil, status.Error(codes.InvalidArgument, "missing cart or product id")
+	}
+
+	if err := s.useCase.RemoveItemFromCart(ctx, req.CustomerId, req.CartId, req.ProductId); err != nil {
+		return nil, toStatus(err)
+	}
+	return s.cart(ctx, req.CartId)
+}
+
+func (s *Server) EmptyCart(ctx context.Context, req *pb.EmptyCartRequest) (*pb.Cart, error) {
+	if req.CartId == "" {
+		return nil, status.Error(codes.InvalidArgument, "missing cart id")
+	}
+
+	if err := s.useCase.ClearCart(ctx, req.CustomerId, req.CartId); err != nil {
+		return nil, toStatus(err)
+	}
+	return s.cart(ctx, req.CartId)
+}
+
+// cart returns the current state of a cart.
+func (s *Server) cart(ctx context.Context, cartID string) (*pb.Cart, error) {
+	cart, err := s.useCase.GetCart(ctx, cartID)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	resp := &pb.Cart{
+		CartId:     cart.ID,
+		Items:      make([]*pb.CartItem, 0, len(cart.Items)),
+		Version:    int32(cart.GetVersion()),
+		CustomerId: cart.CustomerID,
+	}
+	for _, item := range cart.Items {
+		resp.Items = append(resp.Items, &pb.CartItem{
+			ProductId: item.ProductID,
+			Quantity:  int32(item.Quantity),
+			Price:     float32(item.Price),
+		})
+	}
+	// Map iteration order is random; keep responses stable.
+	sort.Slice(resp.Items, func(i, j int) bool { return resp.Items[i].ProductId < resp.Items[j].ProductId })
+	return resp, nil
+}
+
+// toStatus maps use case errors to gRPC status codes.
+func toStatus(err error) error {
+	switch {
+	case errors.Is(err, domain.ErrForbidden):
+		return status.Error(codes.PermissionDenied, err.Error())
+	case errors.Is(err, domain.ErrInvalidQuantity):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, domain.ErrItemNotInCart):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, domain.ErrConcurrencyConflict):
+		return status.Error(codes.Aborted, err.Error())
+	default:
+		return status.Error(codes.Internal, err.Error())
+	}
+}
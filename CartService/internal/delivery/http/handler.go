@@ -2,9 +2,11 @@ package http
 
 import (
 	"encoding/json"
+	"errors"
 	"log/slog"
 	"net/http"
 
+	"github.com/egannguyen/go-kafka-ecommerce/cart-service/internal/domain"
 	"github.com/egannguyen/go-kafka-ecommerce/cart-service/internal/usecase"
 )
 
@@ -19,24 +21,61 @@ func NewHandler(cartUseCase usecase.CartUseCase) *Handler {
 	}
 }
 
+// customerIDHeader carries the customer authenticated by the API gateway.
+// Requests without it are anonymous.
+const customerIDHeader = "X-Customer-ID"
+
 func (h *Handler) RegisterRoutes(mux *http.ServeMux) {
-	mux.HandleFunc("GET /api/cart/{id}", h.handleGetCart)
-	mux.HandleFunc("POST /api/cart/{id}/items", h.handleAddItemToCart)
+	mux.HandleFunc("GET /api/cart/mine", myCart(h.getCart))
+	mux.HandleFunc("DELETE /api/cart/mine", myCart(h.clearCart))
+	mux.HandleFunc("POST /api/cart/mine/items", myCart(h.addItem))
+	mux.HandleFunc("PUT /api/cart/mine/items/{productId}", myCart(h.setItemQuantity))
+	mux.HandleFunc("DELETE /api/cart/mine/items/{productId}", myCart(h.removeItem))
+	mux.HandleFunc("GET /api/cart/{id}", cartByID(h.getCart))
+	mux.HandleFunc("DELETE /api/cart/{id}", cartByID(h.clearCart))
+	mux.HandleFunc("POST /api/cart/{id}/items", cartByID(h.addItem))
+	mux.HandleFunc("PUT /api/cart/{id}/items/{productId}", cartByID(h.setItemQuantity))
+	mux.HandleFunc("DELETE /api/cart/{id}/items/{productId}", cartByID(h.removeItem))
 }
 
-func (h *Handler) handleGetCart(w http.ResponseWriter, r *http.Request) {
-	cartID := r.PathValue("id")
-	if cartID == "" {
-		http.Error(w, "missing cart id", http.StatusBadRequest)
-		return
+// cartHandler serves a request for the cart with the given ID.
+type cartHandler func(w http.ResponseWriter, r *http.Request, cartID string)
+
+// myCart serves the cart of the signed-in customer.
+func myCart(next cartHandler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		customerID := r.Header.Get(customerIDHeader)
+		if customerID == "" {
+			http.Error(w, "authentication required", http.StatusUnauthorized)
+			return
+		}
+		next(w, r, domain.CustomerCartID(customerID))
 	}
+}
+
+// cartByID serves the cart named by the id path parameter.
+func cartByID(next cartHandler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		cartID := r.PathValue("id")
+		if cartID == "" {
+			http.Error(w, "missing cart id", http.StatusBadRequest)
+			return
+		}
+		next(w, r, cartID)
+	}
+}
 
+func (h *Handler) getCart(w http.ResponseWriter, r *http.Request, cartID string) {
 	cart, err := h.cartUseCase.GetCart(r.Context(), cartID)
 	if err != nil {
 		slog.Error("Failed to get cart", "err", err)
 		http.Error(w, "internal server error", http.StatusInternalServerError)
 		return
 	}
+	if !cart.AccessibleBy(r.Header.Get(customerIDHeader)) {
+		http.Error(w, domain.ErrForbidden.Error(), http.StatusForbidden)
+		return
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(cart.Items)
@@ -48,33 +87,85 @@ type AddCartItemRequest struct {
 	Price     float64 `json:"price"`
 }
 
-func (h *Handler) handleAddItemToCart(w http.ResponseWriter, r *http.Request) {
-	cartID := r.PathValue("id")
-	if cartID == "" {
-		http.Error(w, "missing cart id", http.StatusBadRequest)
+func (h *Handler) addItem(w http.ResponseWriter, r *http.Request, cartID string) {
+	var req AddCartItemRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
 		return
 	}
 
-	var req AddCartItemRequest
+	err := h.cartUseCase.AddItemToCart(r.Context(), r.Header.Get(customerIDHeader), cartID, req.ProductID, req.Quantity, req.Price)
+	if err != nil {
+		writeCartError(w, "add item to cart", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+type SetCartItemQuantityRequest struct {
+	Quantity int `json:"quantity"`
+}
+
+func (h *Handler) setItemQuantity(w http.ResponseWriter, r *http.Request, cartID string) {
+	var req SetCartItemQuantityRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		http.Error(w, "invalid request body", http.StatusBadRequest)
 		return
 	}
 
-	if err := h.cartUseCase.AddItemToCart(r.Context(), cartID, req.ProductID, req.Quantity, req.Price); err != nil {
-		slog.Error("Failed to add item to cart", "err", err)
-		http.Error(w, "failed to add item to cart", http.StatusInternalServerError)
+	err := h.cartUseCase.SetItemQuantity(r.Context(), r.Header.Get(customerIDHeader), cartID, r.PathValue("productId"), req.Quantity)
+	if err != nil {
+		writeCartError(w, "update cart item", err)
 		return
 	}
 
-	w.WriteHeader(http.StatusOK)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) removeItem(w http.ResponseWriter, r *http.Request, cartID string) {
+	err := h.cartUseCase.RemoveItemFromCart(r.Context(), r.Header.Get(customerIDHeader), cartID, r.PathValue("productId"))
+	if err != nil {
+		writeCartError(w, "remove cart item", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) clearCart(w http.ResponseWriter, r *http.Request, cartID string) {
+	err := h.cartUseCase.ClearCart(r.Context(), r.Header.Get(customerIDHeader), cartID)
+	if err != nil {
+		writeCartError(w, "clear cart", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// writeCartError maps a failed cart mutation to a response. A conflict means
+// the cart kept changing concurrently and the client may retry.
+func writeCartError(w http.ResponseWriter, action string, err error) {
+	switch {
+	case errors.Is(err, domain.ErrForbidden):
+		http.Error(w, domain.ErrForbidden.Error(), http.StatusForbidden)
+	case errors.Is(err, domain.ErrInvalidQuantity):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, domain.ErrItemNotInCart):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, domain.ErrConcurrencyConflict):
+		http.Error(w, "cart was modified concurrently, please retry", http.StatusConflict)
+	default:
+		slog.Error("Failed to "+action, "err", err)
+		http.Error(w, "failed to "+action, http.StatusInternalServerError)
+	}
 }
 
 // EnableCORS is a middleware to allow the React frontend to connect.
 func EnableCORS(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
 		w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
 
 		if r.Method == http.MethodOptions {
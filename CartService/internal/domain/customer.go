@@ -0,0 +1,11 @@
+package domain
+
+import "errors"
+
+// ErrForbidden is returned when a customer accesses a cart owned by another.
+var ErrForbidden = errors.New("cart belongs to another customer")
+
+// CustomerCartID returns the ID of the cart of a signed-in customer.
+func CustomerCartID(customerID string) string {
+	return "customer-" + customerID
+}
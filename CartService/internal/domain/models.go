@@ -1,22 +1,28 @@
 package domain
 
 import (
-	"encoding/json"
+	"errors"
 	"fmt"
-	"time"
+
+	"github.com/egannguyen/go-kafka-ecommerce/eventsourcing"
+)
+
+var (
+	ErrInvalidQuantity = errors.New("quantity must be positive")
+	ErrItemNotInCart   = errors.New("item not in cart")
 )
 
 // Event represents a domain event.
-type Event interface {
-	EventType() string
-}
+type Event = eventsourcing.Event
 
-// ItemAddedToCart is emitted when a user drops an item into their cart.
+// ItemAddedToCart is emitted when a user drops an item into their cart. The
+// first item added by a signed-in customer makes them the owner of the cart.
 type ItemAddedToCart struct {
-	CartID    string  `json:"cart_id" bson:"cart_id"`
-	ProductID string  `json:"product_id" bson:"product_id"`
-	Quantity  int     `json:"quantity" bson:"quantity"`
-	Price     float64 `json:"price" bson:"price"`
+	CartID     string  `json:"cart_id" bson:"cart_id"`
+	CustomerID string  `json:"customer_id,omitempty" bson:"customer_id,omitempty"`
+	ProductID  string  `json:"product_id" bson:"product_id"`
+	Quantity   int     `json:"quantity" bson:"quantity"`
+	Price      float64 `json:"price" bson:"price"`
 }
 
 func (e ItemAddedToCart) EventType() string { return "ItemAddedToCart" }
@@ -30,6 +36,42 @@ type ItemRemovedFromCart struct {
 
 func (e ItemRemovedFromCart) EventType() string { return "ItemRemovedFromCart" }
 
+// ItemQuantityChanged is emitted when a user sets the quantity of an item
+// already in their cart. Quantity is the new absolute quantity.
+type ItemQuantityChanged struct {
+	CartID    string `json:"cart_id" bson:"cart_id"`
+	ProductID string `json:"product_id" bson:"product_id"`
+	Quantity  int    `json:"quantity" bson:"quantity"`
+}
+
+func (e ItemQuantityChanged) EventType() string { return "ItemQuantityChanged" }
+
+// CartCleared is emitted when a user empties their cart.
+type CartCleared struct {
+	CartID string `json:"cart_id" bson:"cart_id"`
+}
+
+func (e CartCleared) EventType() string { return "CartCleared" }
+
+// CartCheckedOut is emitted when an order placed from the cart succeeded. The
+// ordered quantities are removed from the cart; items added while the order
+// was being placed stay in it.
+type CartCheckedOut struct {
+	CartID  string     `json:"cart_id" bson:"cart_id"`
+	OrderID string     `json:"order_id" bson:"order_id"`
+	Items   []CartItem `json:"items" bson:"items"`
+}
+
+func (e CartCheckedOut) EventType() string { return "CartCheckedOut" }
+
+// OrderPlaced is the subset of CheckoutService's OrderPlaced event the cart
+// needs to clear itself once an order placed from it succeeded.
+type OrderPlaced struct {
+	OrderID string     `json:"order_id"`
+	CartID  string     `json:"cart_id"`
+	Items   []CartItem `json:"items"`
+}
+
 // CartItem represents an item in the cart.
 type CartItem struct {
 	ProductID string  `json:"product_id" bson:"product_id"`
@@ -38,48 +80,46 @@ type CartItem struct {
 }
 
 // EventRecord represents an event stored in the event store.
-type EventRecord struct {
-	ID            string    `json:"id" bson:"id"`
-	StreamID      string    `json:"stream_id" bson:"stream_id"`
-	StreamType    string    `json:"stream_type" bson:"stream_type"`
-	Version       int       `json:"version" bson:"version"`
-	EventType     string    `json:"event_type" bson:"event_type"`
-	Payload       []byte    `json:"payload" bson:"payload"`
-	CreatedAt     time.Time `json:"created_at" bson:"created_at"`
-}
+type EventRecord = eventsourcing.EventRecord
 
 // AggregateBase provides a basic implementation for an aggregate.
-type AggregateBase struct {
-	ID      string
-	Version int
-}
-
-func (a *AggregateBase) GetAggregateID() string {
-	return a.ID
-}
-
-func (a *AggregateBase) GetVersion() int {
-	return a.Version
-}
+type AggregateBase = eventsourcing.AggregateBase
 
 // CartAggregate manages the state of a shopping cart by replaying events.
 type CartAggregate struct {
 	AggregateBase
-	Items map[string]*CartItem
+	CustomerID       string // owner; empty for anonymous carts
+	Items            map[string]*CartItem
+	CheckedOutOrders map[string]bool // orders placed from the cart
 }
 
 // NewCartAggregate creates a new CartAggregate.
 func NewCartAggregate(cartID string) *CartAggregate {
 	return &CartAggregate{
-		AggregateBase: AggregateBase{ID: cartID, Version: 0},
-		Items:         make(map[string]*CartItem),
+		AggregateBase:    AggregateBase{ID: cartID, Version: 0},
+		Items:            make(map[string]*CartItem),
+		CheckedOutOrders: make(map[string]bool),
 	}
 }
 
+// IsCheckedOut reports whether the cart was already checked out for the order.
+func (a *CartAggregate) IsCheckedOut(orderID string) bool {
+	return a.CheckedOutOrders[orderID]
+}
+
+// AccessibleBy reports whether the customer may read and change the cart.
+// Anonymous carts are accessible to everyone who knows their ID.
+func (a *CartAggregate) AccessibleBy(customerID string) bool {
+	return a.CustomerID == "" || a.CustomerID == customerID
+}
+
 // ApplyEvent mutates the aggregate state based on the event.
 func (a *CartAggregate) ApplyEvent(e Event) error {
 	switch e := e.(type) {
 	case ItemAddedToCart:
+		if a.CustomerID == "" {
+			a.CustomerID = e.CustomerID
+		}
 		if item, exists := a.Items[e.ProductID]; exists {
 			item.Quantity += e.Quantity
 		} else {
@@ -90,12 +130,21 @@ func (a *CartAggregate) ApplyEvent(e Event) error {
 			}
 		}
 	case ItemRemovedFromCart:
+		a.removeItem(e.ProductID, e.Quantity)
+	case ItemQuantityChanged:
 		if item, exists := a.Items[e.ProductID]; exists {
-			item.Quantity -= e.Quantity
-			if item.Quantity <= 0 {
-				delete(a.Items, e.ProductID)
-			}
+			item.Quantity = e.Quantity
+		}
+	case CartCleared:
+		a.Items = make(map[string]*CartItem)
+	case CartCheckedOut:
+		for _, item := range e.Items {
+			a.removeItem(item.ProductID, item.Quantity)
+		}
+		if a.CheckedOutOrders == nil {
+			a.CheckedOutOrders = make(map[string]bool)
 		}
+		a.CheckedOutOrders[e.OrderID] = true
 	default:
 		return fmt.Errorf("unknown event type for CartAggregate: %s", e.EventType())
 	}
@@ -103,28 +152,31 @@ func (a *CartAggregate) ApplyEvent(e Event) error {
 	return nil
 }
 
-// Rehydrate rebuilds the aggregate from a list of records.
-func (a *CartAggregate) Rehydrate(records []EventRecord) error {
-	for _, rec := range records {
-		var err error
-		switch rec.EventType {
-		case "ItemAddedToCart":
-			var e ItemAddedToCart
-			if err = json.Unmarshal(rec.Payload, &e); err == nil {
-				err = a.ApplyEvent(e)
-			}
-		case "ItemRemovedFromCart":
-			var e ItemRemovedFromCart
-			if err = json.Unmarshal(rec.Payload, &e); err == nil {
-				err = a.ApplyEvent(e)
-			}
-		default:
-			return fmt.Errorf("unknown event type in cart stream: %s", rec.EventType)
-		}
-		if err != nil {
-			return fmt.Errorf("failed to apply cart event from stream: %w", err)
+func (a *CartAggregate) removeItem(productID string, quantity int) {
+	if item, exists := a.Items[productID]; exists {
+		item.Quantity -= quantity
+		if item.Quantity <= 0 {
+			delete(a.Items, productID)
 		}
 	}
-	return nil
 }
 
+// cartEvents decodes the events of cart streams, upcasting old payloads.
+var cartEvents = newCartEvents()
+
+func newCartEvents() *eventsourcing.Registry {
+	r := eventsourcing.NewRegistry(
+		ItemAddedToCart{},
+		ItemRemovedFromCart{},
+		ItemQuantityChanged{},
+		CartCleared{},
+		CartCheckedOut{},
+	)
+	r.RegisterUpcaster("ItemAddedToCart", 1, upcastItemAddedToCartV1)
+	return r
+}
+
+// Rehydrate rebuilds the aggregate from a list of records.
+func (a *CartAggregate) Rehydrate(records []EventRecord) error {
+	return eventsourcing.Rehydrate(a, cartEvents, records)
+}
@@ -0,0 +1,86 @@
+package domain
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// loadFixture reads stored records from testdata. Payloads are written as
+// JSON objects rather than the base64 of EventRecord.Payload.
+func loadFixture(t *testing.T, name string) []EventRecord {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatalf("failed to read fixture: %v", err)
+	}
+
+	var fixtures []struct {
+		EventType     string          `json:"event_type"`
+		SchemaVersion int             `json:"schema_version"`
+		Payload       json.RawMessage `json:"payload"`
+	}
+	if err := json.Unmarshal(data, &fixtures); err != nil {
+		t.Fatalf("failed to decode fixture: %v", err)
+	}
+
+	records := make([]EventRecord, len(fixtures))
+	for i, f := range fixtures {
+		records[i] = EventRecord{
+			StreamID:      "cart",
+			StreamType:    "cart",
+			Version:       i + 1,
+			EventType:     f.EventType,
+			SchemaVersion: f.SchemaVersion,
+			Payload:       f.Payload,
+		}
+	}
+	return records
+}
+
+func TestRehydrateStoredEvents(t *testing.T) {
+	tests := []struct {
+		name         string
+		fixture      string
+		wantCustomer string
+		wantItems    map[string]int
+	}{
+		{
+			name:      "v1 events of an anonymous cart",
+			fixture:   "cart_v1.json",
+			wantItems: map[string]int{"OLJCESPC7Z": 3},
+		},
+		{
+			name:         "v1 events carrying the customer",
+			fixture:      "cart_v1_with_customer.json",
+			wantCustomer: "customer-1",
+			wantItems:    map[string]int{"OLJCESPC7Z": 1, "66VCHSJNUP": 3},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			records := loadFixture(t, tt.fixture)
+			cart := NewCartAggregate("cart")
+			if err := cart.Rehydrate(records); err != nil {
+				t.Fatalf("Rehydrate: %v", err)
+			}
+
+			if cart.Version != len(records) {
+				t.Errorf("version = %d, want %d", cart.Version, len(records))
+			}
+			if cart.CustomerID != tt.wantCustomer {
+				t.Errorf("customer = %q, want %q", cart.CustomerID, tt.wantCustomer)
+			}
+			if len(cart.Items) != len(tt.wantItems) {
+				t.Errorf("cart has %d items, want %d", len(cart.Items), len(tt.wantItems))
+			}
+			for productID, quantity := range tt.wantItems {
+				if item := cart.Items[productID]; item == nil || item.Quantity != quantity {
+					t.Errorf("item %s = %+v, want quantity %d", productID, item, quantity)
+				}
+			}
+		})
+	}
+}
@@ -1,6 +1,10 @@
 package domain
 
-import "context"
+import (
+	"context"
+
+	"github.com/egannguyen/go-kafka-ecommerce/eventsourcing"
+)
 
 type CartRepository interface {
 	Save(ctx context.Context, cart *CartAggregate) error
@@ -9,7 +13,14 @@ type CartRepository interface {
 }
 
 // EventStore defines the interface for persisting and loading events.
-type EventStore interface {
-	SaveEvents(ctx context.Context, aggregateID string, aggregateType string, expectedVersion int, events []Event) error
-	LoadEvents(ctx context.Context, aggregateID string) ([]EventRecord, error)
+type EventStore = eventsourcing.EventStore
+
+// ErrConcurrencyConflict is returned by EventStore.SaveEvents when another
+// writer appended to the stream first.
+var ErrConcurrencyConflict = eventsourcing.ErrConcurrencyConflict
+
+// Subscriber consumes messages published by other services.
+type Subscriber interface {
+	Consume(ctx context.Context, topic string, groupID string, handler func(ctx context.Context, payload []byte) error) error
+	Close() error
 }
@@ -0,0 +1,15 @@
+package domain
+
+import "github.com/egannguyen/go-kafka-ecommerce/eventsourcing"
+
+// CartSnapshotSchemaVersion must be bumped whenever CartAggregate changes
+// shape: snapshots with an older schema are ignored, the stream is replayed
+// in full and a fresh snapshot is written.
+const CartSnapshotSchemaVersion = 3
+
+// Snapshot is the serialized state of an aggregate at a given stream version.
+type Snapshot = eventsourcing.Snapshot
+
+// SnapshotPolicy maps a stream type to the number of events that may be
+// replayed on load before a new snapshot is taken.
+type SnapshotPolicy = eventsourcing.SnapshotPolicy
@@ -0,0 +1,23 @@
+package domain
+
+import "encoding/json"
+
+// SchemaVersion 2 of ItemAddedToCart added CustomerID.
+func (e ItemAddedToCart) SchemaVersion() int { return 2 }
+
+// upcastItemAddedToCartV1 marks items added before customers were attached to
+// carts as added anonymously, leaving the cart without an owner. Records
+// stored between that change and schema versioning already carry the customer
+// and are kept as they are.
+func upcastItemAddedToCartV1(payload []byte) ([]byte, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(payload, &fields); err != nil {
+		return nil, err
+	}
+	if _, ok := fields["customer_id"]; ok {
+		return payload, nil
+	}
+
+	fields["customer_id"] = json.RawMessage(`""`)
+	return json.Marshal(fields)
+}
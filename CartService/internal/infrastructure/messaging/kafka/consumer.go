@@ -0,0 +1,258 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/egannguyen/go-kafka-ecommerce/cart-service/internal/domain"
+	kafkaGo "github.com/segmentio/kafka-go"
+)
+
+// Headers attached to messages forwarded to retry and dead-letter topics.
+const (
+	HeaderOriginalTopic = "x-original-topic"
+	HeaderGroupID       = "x-group-id"
+	HeaderAttempt       = "x-attempt"
+	HeaderError         = "x-error"
+	HeaderFailedAt      = "x-failed-at"
+	HeaderRetryAt       = "x-retry-at"
+)
+
+// RetryPolicy controls how Consume handles messages whose handler fails.
+// A failed message is forwarded to "<topic>.<groupID>.retry.<n>" for each of
+// the RetryAttempts levels, waiting an exponentially growing delay before each
+// redelivery, and finally to "<topic>.<groupID>.dlq". The topics are per
+// consumer group, so a failure in one group is never redelivered to another
+// group reading the same source topic.
+type RetryPolicy struct {
+	RetryAttempts  int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+}
+
+// DefaultRetryPolicy retries three times after 1s, 2s and 4s.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		RetryAttempts:  3,
+		InitialBackoff: time.Second,
+		MaxBackoff:     time.Minute,
+	}
+}
+
+// withDefaults replaces the settings of p that would make the retry loops spin
+// or misbehave: a non-positive backoff takes its default, a maximum below the
+// initial backoff is raised to it and negative attempts become zero, sending
+// failed messages straight to the dead-letter topic.
+func (p RetryPolicy) withDefaults() RetryPolicy {
+	def := DefaultRetryPolicy()
+	if p.RetryAttempts < 0 {
+		p.RetryAttempts = 0
+	}
+	if p.InitialBackoff <= 0 {
+		p.InitialBackoff = def.InitialBackoff
+	}
+	if p.MaxBackoff <= 0 {
+		p.MaxBackoff = def.MaxBackoff
+	}
+	if p.MaxBackoff < p.InitialBackoff {
+		p.MaxBackoff = p.InitialBackoff
+	}
+	return p
+}
+
+// backoff returns the delay before the given retry level (1-based).
+func (p RetryPolicy) backoff(level int) time.Duration {
+	d := p.InitialBackoff
+	for i := 1; i < level; i++ {
+		d *= 2
+		if d >= p.MaxBackoff {
+			return p.MaxBackoff
+		}
+	}
+	return d
+}
+
+func RetryTopic(topic, groupID string, level int) string {
+	return fmt.Sprintf("%s.%s.retry.%d", topic, groupID, level)
+}
+
+func DeadLetterTopic(topic, groupID string) string {
+	return fmt.Sprintf("%s.%s.dlq", topic, groupID)
+}
+
+type kafkaSubscriber struct {
+	brokers []string
+	policy  RetryPolicy
+	writer  *kafkaGo.Writer
+}
+
+// NewKafkaSubscriber creates a subscriber that hands failed messages to retry
+// and dead-letter topics through a shared writer. Close must be called on
+// shutdown to flush it. Unusable policy settings fall back to those of
+// DefaultRetryPolicy.
+func NewKafkaSubscriber(brokers []string, policy RetryPolicy) domain.Subscriber {
+	writer := &kafkaGo.Writer{
+		Addr:                   kafkaGo.TCP(brokers...),
+		Balancer:               &kafkaGo.Hash{},
+		BatchTimeout:           10 * time.Millisecond,
+		RequiredAcks:           kafkaGo.RequireOne,
+		AllowAutoTopicCreation: true,
+	}
+	return &kafkaSubscriber{brokers: brokers, policy: policy.withDefaults(), writer: writer}
+}
+
+// Close flushes pending forwards and closes the shared writer.
+func (k *kafkaSubscriber) Close() error {
+	return k.writer.Close()
+}
+
+// Consume reads topic with the given consumer group until ctx is cancelled.
+// Offsets are committed only once a message was handled successfully or
+// handed off to a retry or dead-letter topic. Retry topics are consumed by the
+// same call, under "<groupID>.retry.<n>" consumer groups.
+func (k *kafkaSubscriber) Consume(ctx context.Context, topic string, groupID string, handler func(ctx context.Context, payload []byte) error) error {
+	var wg sync.WaitGroup
+	for level := 1; level <= k.policy.RetryAttempts; level++ {
+		wg.Add(1)
+		go func(level int) {
+			defer wg.Done()
+			k.consumeLevel(ctx, topic, groupID, level, handler)
+		}(level)
+	}
+
+	k.consumeLevel(ctx, topic, groupID, 0, handler)
+	wg.Wait()
+	return nil
+}
+
+// consumeLevel consumes one topic of groupID's retry chain; level 0 is the original topic.
+func (k *kafkaSubscriber) consumeLevel(ctx context.Context, originalTopic, groupID string, level int, handler func(ctx context.Context, payload []byte) error) {
+	topic, levelGroupID := originalTopic, groupID
+	if level > 0 {
+		topic = RetryTopic(originalTopic, groupID, level)
+		levelGroupID = fmt.Sprintf("%s.retry.%d", groupID, level)
+	}
+
+	reader := kafkaGo.NewReader(kafkaGo.ReaderConfig{
+		Brokers: k.brokers,
+		Topic:   topic,
+		GroupID: levelGroupID,
+	})
+	defer reader.Close()
+
+	for {
+		msg, err := reader.FetchMessage(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			slog.Error("Error reading message", "topic", topic, "err", err)
+			continue
+		}
+
+		if level > 0 && !waitUntil(ctx, retryAt(msg)) {
+			return
+		}
+
+		if err := handler(ctx, msg.Value); err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			slog.Error("Error handling message", "topic", topic, "attempt", level+1, "err", err)
+			if !k.forwardUntilDone(ctx, originalTopic, groupID, topic, msg, level+1, err) {
+				return
+			}
+		}
+
+		if err := reader.CommitMessages(ctx, msg); err != nil {
+			slog.Error("Failed to commit offset", "topic", topic, "offset", msg.Offset, "err", err)
+		}
+	}
+}
+
+// forwardUntilDone forwards a failed message, retrying with backoff until the
+// forward succeeds. The reader must not move past the message before then, or
+// it would be lost once a later offset is committed. It reports false if ctx
+// was cancelled first, leaving the offset uncommitted.
+func (k *kafkaSubscriber) forwardUntilDone(ctx context.Context, originalTopic, groupID, topic string, msg kafkaGo.Message, nextLevel int, handlerErr error) bool {
+	delay := k.policy.InitialBackoff
+	for {
+		err := k.forward(ctx, originalTopic, groupID, msg, nextLevel, handlerErr)
+		if err == nil {
+			return true
+		}
+		if ctx.Err() != nil {
+			return false
+		}
+		slog.Error("Failed to forward message for retry", "topic", topic, "offset", msg.Offset, "retry_in", delay, "err", err)
+
+		if !waitUntil(ctx, time.Now().Add(delay)) {
+			return false
+		}
+		if delay *= 2; delay > k.policy.MaxBackoff {
+			delay = k.policy.MaxBackoff
+		}
+	}
+}
+
+// forward sends a failed message to groupID's next retry level, or to its
+// dead-letter topic once all retry levels are exhausted.
+func (k *kafkaSubscriber) forward(ctx context.Context, originalTopic, groupID string, msg kafkaGo.Message, nextLevel int, handlerErr error) error {
+	now := time.Now()
+	target := DeadLetterTopic(originalTopic, groupID)
+	headers := []kafkaGo.Header{
+		{Key: HeaderOriginalTopic, Value: []byte(originalTopic)},
+		{Key: HeaderGroupID, Value: []byte(groupID)},
+		{Key: HeaderAttempt, Value: []byte(strconv.Itoa(nextLevel))},
+		{Key: HeaderError, Value: []byte(handlerErr.Error())},
+		{Key: HeaderFailedAt, Value: []byte(now.Format(time.RFC3339Nano))},
+	}
+
+	if nextLevel <= k.policy.RetryAttempts {
+		target = RetryTopic(originalTopic, groupID, nextLevel)
+		at := now.Add(k.policy.backoff(nextLevel))
+		headers = append(headers, kafkaGo.Header{Key: HeaderRetryAt, Value: []byte(at.Format(time.RFC3339Nano))})
+	} else {
+		slog.Warn("Sending message to dead-letter topic", "topic", target, "attempts", nextLevel)
+	}
+
+	return k.writer.WriteMessages(ctx, kafkaGo.Message{
+		Topic:   target,
+		Key:     msg.Key,
+		Value:   msg.Value,
+		Headers: headers,
+	})
+}
+
+func retryAt(msg kafkaGo.Message) time.Time {
+	for _, h := range msg.Headers {
+		if h.Key == HeaderRetryAt {
+			t, err := time.Parse(time.RFC3339Nano, string(h.Value))
+			if err == nil {
+				return t
+			}
+		}
+	}
+	return time.Time{}
+}
+
+// waitUntil sleeps until t and reports false if ctx was cancelled first.
+func waitUntil(ctx context.Context, t time.Time) bool {
+	d := time.Until(t)
+	if d <= 0 {
+		return true
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
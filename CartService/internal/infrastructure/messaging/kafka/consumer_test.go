@@ -0,0 +1,47 @@
+package kafka
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRetryPolicyWithDefaults(t *testing.T) {
+	def := DefaultRetryPolicy()
+	tests := []struct {
+		name   string
+		policy RetryPolicy
+		want   RetryPolicy
+	}{
+		{"default", def, def},
+		{"zero value", RetryPolicy{}, RetryPolicy{RetryAttempts: 0, InitialBackoff: def.InitialBackoff, MaxBackoff: def.MaxBackoff}},
+		{"negative backoffs", RetryPolicy{RetryAttempts: 2, InitialBackoff: -time.Second, MaxBackoff: -time.Second}, RetryPolicy{RetryAttempts: 2, InitialBackoff: def.InitialBackoff, MaxBackoff: def.MaxBackoff}},
+		{"negative attempts", RetryPolicy{RetryAttempts: -1, InitialBackoff: time.Second, MaxBackoff: time.Minute}, RetryPolicy{RetryAttempts: 0, InitialBackoff: time.Second, MaxBackoff: time.Minute}},
+		{"max below initial", RetryPolicy{RetryAttempts: 3, InitialBackoff: 10 * time.Second, MaxBackoff: time.Second}, RetryPolicy{RetryAttempts: 3, InitialBackoff: 10 * time.Second, MaxBackoff: 10 * time.Second}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.policy.withDefaults(); got != tt.want {
+				t.Errorf("withDefaults() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	policy := RetryPolicy{RetryAttempts: 5, InitialBackoff: time.Second, MaxBackoff: 5 * time.Second}
+	want := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second}
+	for level, w := range want {
+		if got := policy.backoff(level + 1); got != w {
+			t.Errorf("backoff(%d) = %v, want %v", level+1, got, w)
+		}
+	}
+}
+
+func TestRetryAndDeadLetterTopics(t *testing.T) {
+	if got := RetryTopic("orders.placed", "cart", 1); got != "orders.placed.cart.retry.1" {
+		t.Errorf("RetryTopic = %q", got)
+	}
+	if got := DeadLetterTopic("orders.placed", "cart"); got != "orders.placed.cart.dlq" {
+		t.Errorf("DeadLetterTopic = %q", got)
+	}
+}
@@ -0,0 +1,30 @@
+// Package persistence selects the storage backends of the service.
+package persistence
+
+import (
+	"fmt"
+
+	"github.com/egannguyen/go-kafka-ecommerce/cart-service/internal/domain"
+	"github.com/egannguyen/go-kafka-ecommerce/cart-service/internal/infrastructure/persistence/postgres"
+	"github.com/egannguyen/go-kafka-ecommerce/eventsourcing/mongostore"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Event store backends accepted by NewEventStore.
+const (
+	EventStoreMongoDB  = "mongodb"
+	EventStorePostgres = "postgres"
+)
+
+// NewEventStore returns the event store of the given backend. PostgreSQL is
+// connected to with postgresURL.
+func NewEventStore(backend string, mongoDB *mongo.Database, postgresURL string) (domain.EventStore, error) {
+	switch backend {
+	case EventStoreMongoDB:
+		return mongostore.New(mongoDB), nil
+	case EventStorePostgres:
+		return postgres.InitDB(postgresURL)
+	default:
+		return nil, fmt.Errorf("unknown event store backend %q", backend)
+	}
+}
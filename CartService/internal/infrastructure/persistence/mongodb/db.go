@@ -6,6 +6,7 @@ import (
 	"log/slog"
 	"time"
 
+	"github.com/egannguyen/go-kafka-ecommerce/eventsourcing/mongostore"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
@@ -25,6 +26,11 @@ func InitDB(uri string) (*mongo.Database, error) {
 	}
 
 	db := client.Database("ecommerce_cart")
+
+	if err := mongostore.EnsureIndexes(ctx, db); err != nil {
+		return nil, fmt.Errorf("failed to create indexes: %w", err)
+	}
+
 	slog.Info("MongoDB connected for CartService")
 	return db, nil
 }
@@ -0,0 +1,37 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/egannguyen/go-kafka-ecommerce/eventsourcing/pgstore"
+	_ "github.com/lib/pq"
+)
+
+// schema holds the tables of CartService in a database shared with other services.
+const schema = "cart"
+
+// InitDB connects to PostgreSQL and returns the migrated cart event store.
+func InitDB(dsn string) (*pgstore.Store, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+	if err := db.PingContext(ctx); err != nil {
+		return nil, fmt.Errorf("failed to ping database: %w", err)
+	}
+
+	store := pgstore.New(db, schema)
+	if err := store.Migrate(ctx); err != nil {
+		return nil, err
+	}
+
+	slog.Info("PostgreSQL connected for CartService")
+	return store, nil
+}
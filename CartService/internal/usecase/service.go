@@ -2,66 +2,171 @@ package usecase
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 
 	"github.com/egannguyen/go-kafka-ecommerce/cart-service/internal/domain"
+	"github.com/egannguyen/go-kafka-ecommerce/eventsourcing"
 )
 
 // CartUseCase orchestrates shopping cart logic.
 type CartUseCase interface {
-	AddItemToCart(ctx context.Context, cartID, productID string, quantity int, price float64) error
+	// AddItemToCart adds an item on behalf of customerID, empty for anonymous
+	// shoppers. It returns ErrForbidden if the cart belongs to someone else.
+	AddItemToCart(ctx context.Context, customerID, cartID, productID string, quantity int, price float64) error
+	// RemoveItemFromCart removes a product from the cart whatever its quantity.
+	// It returns ErrItemNotInCart if the cart does not hold the product.
+	RemoveItemFromCart(ctx context.Context, customerID, cartID, productID string) error
+	// SetItemQuantity sets the quantity of a product already in the cart. It
+	// returns ErrItemNotInCart if the cart does not hold the product.
+	SetItemQuantity(ctx context.Context, customerID, cartID, productID string, quantity int) error
+	// ClearCart removes every item from the cart.
+	ClearCart(ctx context.Context, customerID, cartID string) error
 	GetCart(ctx context.Context, cartID string) (*domain.CartAggregate, error)
+	// HandleOrderPlaced removes the ordered items from the cart the order was
+	// placed from. Orders not placed from a cart are ignored.
+	HandleOrderPlaced(ctx context.Context, event *domain.OrderPlaced) error
 }
 
+// maxConflictAttempts bounds the appends of a cart change racing other
+// writers of the same cart.
+const maxConflictAttempts = 5
+
 type cartUseCase struct {
-	eventStore domain.EventStore
-	repo       domain.CartRepository
+	eventStore     domain.EventStore
+	repo           domain.CartRepository
+	snapshotPolicy domain.SnapshotPolicy
 }
 
-func NewCartUseCase(eventStore domain.EventStore, repo domain.CartRepository) CartUseCase {
+func NewCartUseCase(eventStore domain.EventStore, repo domain.CartRepository, snapshotPolicy domain.SnapshotPolicy) CartUseCase {
 	return &cartUseCase{
-		eventStore: eventStore,
-		repo:       repo,
+		eventStore:     eventStore,
+		repo:           repo,
+		snapshotPolicy: snapshotPolicy,
 	}
 }
 
-func (u *cartUseCase) AddItemToCart(ctx context.Context, cartID, productID string, quantity int, price float64) error {
+func (u *cartUseCase) AddItemToCart(ctx context.Context, customerID, cartID, productID string, quantity int, price float64) error {
 	slog.Info("UseCase: Adding item to cart", "cart_id", cartID, "product_id", productID)
 
-	// Try to get from cache first
-	agg, err := u.repo.Get(ctx, cartID)
-	if err != nil {
-		slog.Warn("Failed to get cart from cache, rehydrating from event store", "err", err)
+	if quantity <= 0 {
+		return fmt.Errorf("%w: %d", domain.ErrInvalidQuantity, quantity)
+	}
+
+	return u.mutate(ctx, customerID, cartID, func(agg *domain.CartAggregate) (domain.Event, error) {
+		return domain.ItemAddedToCart{
+			CartID:     cartID,
+			CustomerID: customerID,
+			ProductID:  productID,
+			Quantity:   quantity,
+			Price:      price,
+		}, nil
+	})
+}
+
+func (u *cartUseCase) RemoveItemFromCart(ctx context.Context, customerID, cartID, productID string) error {
+	slog.Info("UseCase: Removing item from cart", "cart_id", cartID, "product_id", productID)
+
+	return u.mutate(ctx, customerID, cartID, func(agg *domain.CartAggregate) (domain.Event, error) {
+		item, ok := agg.Items[productID]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", domain.ErrItemNotInCart, productID)
+		}
+		return domain.ItemRemovedFromCart{
+			CartID:    cartID,
+			ProductID: productID,
+			Quantity:  item.Quantity,
+		}, nil
+	})
+}
+
+func (u *cartUseCase) SetItemQuantity(ctx context.Context, customerID, cartID, productID string, quantity int) error {
+	slog.Info("UseCase: Setting cart item quantity", "cart_id", cartID, "product_id", productID, "quantity", quantity)
+
+	if quantity <= 0 {
+		return fmt.Errorf("%w: %d", domain.ErrInvalidQuantity, quantity)
 	}
 
-	if agg == nil {
-		// Rehydrate if not in cache
-		records, err := u.eventStore.LoadEvents(ctx, cartID)
+	return u.mutate(ctx, customerID, cartID, func(agg *domain.CartAggregate) (domain.Event, error) {
+		item, ok := agg.Items[productID]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", domain.ErrItemNotInCart, productID)
+		}
+		if item.Quantity == quantity {
+			return nil, nil
+		}
+		return domain.ItemQuantityChanged{
+			CartID:    cartID,
+			ProductID: productID,
+			Quantity:  quantity,
+		}, nil
+	})
+}
+
+func (u *cartUseCase) ClearCart(ctx context.Context, customerID, cartID string) error {
+	slog.Info("UseCase: Clearing cart", "cart_id", cartID)
+
+	return u.mutate(ctx, customerID, cartID, func(agg *domain.CartAggregate) (domain.Event, error) {
+		if len(agg.Items) == 0 {
+			return nil, nil
+		}
+		return domain.CartCleared{CartID: cartID}, nil
+	})
+}
+
+// mutate loads the cart, checks that customerID may change it and appends the
+// event returned by decide, if any. When another writer changed the cart in
+// the meantime, it is reloaded and decided again.
+func (u *cartUseCase) mutate(ctx context.Context, customerID, cartID string, decide func(agg *domain.CartAggregate) (domain.Event, error)) error {
+	return eventsourcing.RetryOnConflict(ctx, maxConflictAttempts, func(attempt int) error {
+		// The cached cart is stale after a conflict, so retries reload it.
+		agg, err := u.cachedCart(ctx, cartID, attempt > 1)
 		if err != nil {
-			return fmt.Errorf("failed to load cart history: %w", err)
+			return err
 		}
 
-		agg = domain.NewCartAggregate(cartID)
-		if err := agg.Rehydrate(records); err != nil {
-			return fmt.Errorf("failed to rehydrate cart aggregate: %w", err)
+		if !agg.AccessibleBy(customerID) {
+			return fmt.Errorf("%w: %s", domain.ErrForbidden, cartID)
 		}
-	}
 
-	event := domain.ItemAddedToCart{
-		CartID:    cartID,
-		ProductID: productID,
-		Quantity:  quantity,
-		Price:     price,
+		event, err := decide(agg)
+		if err != nil || event == nil {
+			return err
+		}
+		return u.save(ctx, agg, event)
+	})
+}
+
+// cachedCart returns the cart from the cache, falling back to the event store
+// when it is not cached or when fresh is set.
+func (u *cartUseCase) cachedCart(ctx context.Context, cartID string, fresh bool) (*domain.CartAggregate, error) {
+	if !fresh {
+		agg, err := u.repo.Get(ctx, cartID)
+		if err != nil {
+			slog.Warn("Failed to get cart from cache, rehydrating from event store", "err", err)
+		}
+		if agg != nil {
+			return agg, nil
+		}
 	}
+	return u.loadCart(ctx, cartID)
+}
 
-	// Persist event
-	err = u.eventStore.SaveEvents(ctx, cartID, "cart", agg.GetVersion(), []domain.Event{event})
+// save appends event to the cart stream at the version of agg, then applies
+// it to agg and updates the cache. It returns ErrConcurrencyConflict if the
+// cart changed since agg was loaded.
+func (u *cartUseCase) save(ctx context.Context, agg *domain.CartAggregate, event domain.Event) error {
+	err := u.eventStore.SaveEvents(ctx, agg.ID, "cart", agg.GetVersion(), []domain.Event{event})
 	if err != nil {
-		return fmt.Errorf("failed to save ItemAddedToCart event: %w", err)
+		if errors.Is(err, domain.ErrConcurrencyConflict) {
+			// Whatever the cache holds is behind the winning writer.
+			_ = u.repo.Delete(ctx, agg.ID)
+		}
+		return fmt.Errorf("failed to save %s event: %w", event.EventType(), err)
 	}
 
-	// Apply event to aggregate and update cache
 	if err := agg.ApplyEvent(event); err != nil {
 		return fmt.Errorf("failed to apply event to aggregate: %w", err)
 	}
@@ -69,7 +174,6 @@ func (u *cartUseCase) AddItemToCart(ctx context.Context, cartID, productID strin
 	if err := u.repo.Save(ctx, agg); err != nil {
 		slog.Error("Failed to save cart to cache", "err", err)
 	}
-
 	return nil
 }
 
@@ -81,21 +185,88 @@ func (u *cartUseCase) GetCart(ctx context.Context, cartID string) (*domain.CartA
 	}
 
 	// Rehydrate if not in cache or error
-	records, err := u.eventStore.LoadEvents(ctx, cartID)
+	agg, err = u.loadCart(ctx, cartID)
+	if err != nil {
+		return nil, err
+	}
+	if agg.GetVersion() == 0 {
+		return agg, nil
+	}
+
+	// Save back to cache
+	_ = u.repo.Save(ctx, agg)
+
+	return agg, nil
+}
+
+func (u *cartUseCase) HandleOrderPlaced(ctx context.Context, event *domain.OrderPlaced) error {
+	if event.CartID == "" {
+		return nil
+	}
+	slog.Info("UseCase: Checking out cart", "cart_id", event.CartID, "order_id", event.OrderID)
+
+	return eventsourcing.RetryOnConflict(ctx, maxConflictAttempts, func(attempt int) error {
+		// The cache may lag behind the event store, so load the authoritative state.
+		agg, err := u.loadCart(ctx, event.CartID)
+		if err != nil {
+			return err
+		}
+		if agg.IsCheckedOut(event.OrderID) {
+			slog.Info("Cart already checked out (idempotency)", "cart_id", event.CartID, "order_id", event.OrderID)
+			return nil
+		}
+
+		return u.save(ctx, agg, domain.CartCheckedOut{
+			CartID:  event.CartID,
+			OrderID: event.OrderID,
+			Items:   event.Items,
+		})
+	})
+}
+
+// loadCart restores a cart from its latest snapshot and replays the events
+// recorded after it, saving a new snapshot once the replayed tail reaches the
+// configured interval.
+func (u *cartUseCase) loadCart(ctx context.Context, cartID string) (*domain.CartAggregate, error) {
+	snapshot, records, err := u.eventStore.LoadFromSnapshot(ctx, cartID, domain.CartSnapshotSchemaVersion)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load cart history: %w", err)
 	}
-	if len(records) == 0 {
-		return domain.NewCartAggregate(cartID), nil
+
+	agg := domain.NewCartAggregate(cartID)
+	if snapshot != nil {
+		if err := json.Unmarshal(snapshot.State, agg); err != nil {
+			return nil, fmt.Errorf("failed to decode cart snapshot: %w", err)
+		}
 	}
 
-	agg = domain.NewCartAggregate(cartID)
 	if err := agg.Rehydrate(records); err != nil {
 		return nil, fmt.Errorf("failed to rehydrate cart aggregate: %w", err)
 	}
 
-	// Save back to cache
-	_ = u.repo.Save(ctx, agg)
-
+	if u.snapshotPolicy.ShouldSnapshot("cart", len(records)) {
+		u.saveSnapshot(ctx, agg)
+	}
 	return agg, nil
 }
+
+// saveSnapshot stores the cart state. Failures are only logged since the
+// events remain the source of truth.
+func (u *cartUseCase) saveSnapshot(ctx context.Context, agg *domain.CartAggregate) {
+	state, err := json.Marshal(agg)
+	if err != nil {
+		slog.Error("Failed to marshal cart snapshot", "cart_id", agg.ID, "err", err)
+		return
+	}
+
+	err = u.eventStore.SaveSnapshot(ctx, domain.Snapshot{
+		StreamID:      agg.ID,
+		StreamType:    "cart",
+		Version:       agg.GetVersion(),
+		SchemaVersion: domain.CartSnapshotSchemaVersion,
+		State:         state,
+	})
+	if err != nil {
+		slog.Error("Failed to save cart snapshot", "cart_id", agg.ID, "err", err)
+	}
+}
@@ -31,6 +31,8 @@ func (c *currencyServiceClient) Convert(ctx context.Context, in *CurrencyConvers
 // PaymentServiceClient
 type PaymentServiceClient interface {
 	Charge(ctx context.Context, in *ChargeRequest, opts ...grpc.CallOption) (*ChargeResponse, error)
+	Refund(ctx context.Context, in *RefundRequest, opts ...grpc.CallOption) (*RefundResponse, error)
+	Tokenize(ctx context.Context, in *TokenizeRequest, opts ...grpc.CallOption) (*TokenizeResponse, error)
 }
 
 type paymentServiceClient struct {
@@ -50,6 +52,24 @@ func (c *paymentServiceClient) Charge(ctx context.Context, in *ChargeRequest, op
 	return out, nil
 }
 
+func (c *paymentServiceClient) Refund(ctx context.Context, in *RefundRequest, opts ...grpc.CallOption) (*RefundResponse, error) {
+	out := new(RefundResponse)
+	err := c.cc.Invoke(ctx, "/payment.PaymentService/Refund", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *paymentServiceClient) Tokenize(ctx context.Context, in *TokenizeRequest, opts ...grpc.CallOption) (*TokenizeResponse, error) {
+	out := new(TokenizeResponse)
+	err := c.cc.Invoke(ctx, "/payment.PaymentService/Tokenize", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // ProductCatalogServiceClient
 type ProductCatalogServiceClient interface {
 	GetProduct(ctx context.Context, in *GetProductRequest, opts ...grpc.CallOption) (*Product, error)
@@ -82,6 +102,120 @@ func (c *productCatalogServiceClient) ListProducts(ctx context.Context, in *List
 	return out, nil
 }
 
+// InventoryServiceClient
+type InventoryServiceClient interface {
+	Reserve(ctx context.Context, in *ReserveRequest, opts ...grpc.CallOption) (*ReserveResponse, error)
+	Hold(ctx context.Context, in *HoldRequest, opts ...grpc.CallOption) (*HoldResponse, error)
+	Release(ctx context.Context, in *ReleaseRequest, opts ...grpc.CallOption) (*ReleaseResponse, error)
+	Commit(ctx context.Context, in *CommitRequest, opts ...grpc.CallOption) (*CommitResponse, error)
+	Restock(ctx context.Context, in *RestockRequest, opts ...grpc.CallOption) (*RestockResponse, error)
+}
+
+type inventoryServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewInventoryServiceClient(cc grpc.ClientConnInterface) InventoryServiceClient {
+	return &inventoryServiceClient{cc}
+}
+
+func (c *inventoryServiceClient) Reserve(ctx context.Context, in *ReserveRequest, opts ...grpc.CallOption) (*ReserveResponse, error) {
+	out := new(ReserveResponse)
+	err := c.cc.Invoke(ctx, "/inventory.InventoryService/Reserve", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *inventoryServiceClient) Hold(ctx context.Context, in *HoldRequest, opts ...grpc.CallOption) (*HoldResponse, error) {
+	out := new(HoldResponse)
+	err := c.cc.Invoke(ctx, "/inventory.InventoryService/Hold", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *inventoryServiceClient) Release(ctx context.Context, in *ReleaseRequest, opts ...grpc.CallOption) (*ReleaseResponse, error) {
+	out := new(ReleaseResponse)
+	err := c.cc.Invoke(ctx, "/inventory.InventoryService/Release", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *inventoryServiceClient) Commit(ctx context.Context, in *CommitRequest, opts ...grpc.CallOption) (*CommitResponse, error) {
+	out := new(CommitResponse)
+	err := c.cc.Invoke(ctx, "/inventory.InventoryService/Commit", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *inventoryServiceClient) Restock(ctx context.Context, in *RestockRequest, opts ...grpc.CallOption) (*RestockResponse, error) {
+	out := new(RestockResponse)
+	err := c.cc.Invoke(ctx, "/inventory.InventoryService/Restock", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// CartServiceClient
+type CartServiceClient interface {
+	GetCart(ctx context.Context, in *GetCartRequest, opts ...grpc.CallOption) (*Cart, error)
+	AddItem(ctx context.Context, in *AddItemRequest, opts ...grpc.CallOption) (*Cart, error)
+	RemoveItem(ctx context.Context, in *RemoveItemRequest, opts ...grpc.CallOption) (*Cart, error)
+	EmptyCart(ctx context.Context, in *EmptyCartRequest, opts ...grpc.CallOption) (*Cart, error)
+}
+
+type cartServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewCartServiceClient(cc grpc.ClientConnInterface) CartServiceClient {
+	return &cartServiceClient{cc}
+}
+
+func (c *cartServiceClient) GetCart(ctx context.Context, in *GetCartRequest, opts ...grpc.CallOption) (*Cart, error) {
+	out := new(Cart)
+	err := c.cc.Invoke(ctx, "/cart.CartService/GetCart", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cartServiceClient) AddItem(ctx context.Context, in *AddItemRequest, opts ...grpc.CallOption) (*Cart, error) {
+	out := new(Cart)
+	err := c.cc.Invoke(ctx, "/cart.CartService/AddItem", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cartServiceClient) RemoveItem(ctx context.Context, in *RemoveItemRequest, opts ...grpc.CallOption) (*Cart, error) {
+	out := new(Cart)
+	err := c.cc.Invoke(ctx, "/cart.CartService/RemoveItem", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cartServiceClient) EmptyCart(ctx context.Context, in *EmptyCartRequest, opts ...grpc.CallOption) (*Cart, error) {
+	out := new(Cart)
+	err := c.cc.Invoke(ctx, "/cart.CartService/EmptyCart", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // Shared Types
 type Money struct {
 	CurrencyCode string `json:"currency_code,omitempty"`
@@ -102,14 +236,32 @@ type CreditCardInfo struct {
 }
 
 type ChargeRequest struct {
-	Amount     *Money          `json:"amount,omitempty"`
+	Amount       *Money          `json:"amount,omitempty"`
+	CreditCard   *CreditCardInfo `json:"credit_card,omitempty"`
+	PaymentToken string          `json:"payment_token,omitempty"`
+}
+
+type TokenizeRequest struct {
 	CreditCard *CreditCardInfo `json:"credit_card,omitempty"`
 }
 
+type TokenizeResponse struct {
+	PaymentToken string `json:"payment_token,omitempty"`
+}
+
 type ChargeResponse struct {
 	TransactionId string `json:"transaction_id,omitempty"`
 }
 
+type RefundRequest struct {
+	TransactionId string `json:"transaction_id,omitempty"`
+}
+
+type RefundResponse struct {
+	TransactionId string `json:"transaction_id,omitempty"`
+	Status        string `json:"status,omitempty"`
+}
+
 type Product struct {
 	Id          string  `json:"id,omitempty"`
 	Name        string  `json:"name,omitempty"`
@@ -129,3 +281,80 @@ type ListProductsRequest struct{}
 type ListProductsResponse struct {
 	Products []*Product `json:"products,omitempty"`
 }
+
+type ReservationItem struct {
+	ProductId string `json:"product_id,omitempty"`
+	Quantity  int32  `json:"quantity,omitempty"`
+}
+
+type ReserveRequest struct {
+	OrderId string             `json:"order_id,omitempty"`
+	Items   []*ReservationItem `json:"items,omitempty"`
+}
+
+type ReserveResponse struct{}
+
+type HoldRequest struct {
+	OrderId    string   `json:"order_id,omitempty"`
+	ProductIds []string `json:"product_ids,omitempty"`
+}
+
+type HoldResponse struct{}
+
+type ReleaseRequest struct {
+	OrderId    string   `json:"order_id,omitempty"`
+	ProductIds []string `json:"product_ids,omitempty"`
+}
+
+type ReleaseResponse struct{}
+
+type CommitRequest struct {
+	OrderId    string   `json:"order_id,omitempty"`
+	ProductIds []string `json:"product_ids,omitempty"`
+}
+
+type CommitResponse struct{}
+
+type RestockRequest struct {
+	OrderId    string   `json:"order_id,omitempty"`
+	ProductIds []string `json:"product_ids,omitempty"`
+	Reason     string   `json:"reason,omitempty"`
+}
+
+type RestockResponse struct{}
+
+type CartItem struct {
+	ProductId string  `json:"product_id,omitempty"`
+	Quantity  int32   `json:"quantity,omitempty"`
+	Price     float32 `json:"price,omitempty"`
+}
+
+type GetCartRequest struct {
+	CartId string `json:"cart_id,omitempty"`
+}
+
+type AddItemRequest struct {
+	CartId     string  `json:"cart_id,omitempty"`
+	CustomerId string  `json:"customer_id,omitempty"`
+	ProductId  string  `json:"product_id,omitempty"`
+	Quantity   int32   `json:"quantity,omitempty"`
+	Price      float32 `json:"price,omitempty"`
+}
+
+type RemoveItemRequest struct {
+	CartId     string `json:"cart_id,omitempty"`
+	CustomerId string `json:"customer_id,omitempty"`
+	ProductId  string `json:"product_id,omitempty"`
+}
+
+type EmptyCartRequest struct {
+	CartId     string `json:"cart_id,omitempty"`
+	CustomerId string `json:"customer_id,omitempty"`
+}
+
+type Cart struct {
+	CartId     string      `json:"cart_id,omitempty"`
+	Items      []*CartItem `json:"items,omitempty"`
+	Version    int32       `json:"version,omitempty"`
+	CustomerId string      `json:"customer_id,omitempty"`
+}
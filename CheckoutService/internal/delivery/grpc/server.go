@@ -0,0 +1,102 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/egannguyen/go-kafka-ecommerce/checkout-service/internal/delivery/grpc/pb"
+	"github.com/egannguyen/go-kafka-ecommerce/checkout-service/internal/domain"
+	"github.com/egannguyen/go-kafka-ecommerce/checkout-service/internal/usecase"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Server serves the orders read model to internal callers.
+type Server struct {
+	pb.UnimplementedOrderServiceServer
+	useCase usecase.CheckoutUseCase
+}
+
+func NewServer(useCase usecase.CheckoutUseCase) *Server {
+	return &Server{useCase: useCase}
+}
+
+func (s *Server) GetOrder(ctx context.Context, req *pb.GetOrderRequest) (*pb.Order, error) {
+	order, err := s.useCase.GetOrder(ctx, req.Id)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return toPbOrder(order), nil
+}
+
+func (s *Server) ListOrders(ctx context.Context, req *pb.ListOrdersRequest) (*pb.ListOrdersResponse, error) {
+	filter := domain.OrderFilter{
+		CustomerID: req.CustomerId,
+		Status:     req.Status,
+		Limit:      int(req.Limit),
+		Cursor:     req.Cursor,
+	}
+	var err error
+	if req.CreatedFrom != "" {
+		if filter.CreatedFrom, err = time.Parse(time.RFC3339, req.CreatedFrom); err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid created_from: %v", err)
+		}
+	}
+	if req.CreatedTo != "" {
+		if filter.CreatedTo, err = time.Parse(time.RFC3339, req.CreatedTo); err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid created_to: %v", err)
+		}
+	}
+
+	page, err := s.useCase.ListOrders(ctx, filter)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	resp := &pb.ListOrdersResponse{NextCursor: page.NextCursor}
+	for i := range page.Orders {
+		resp.Orders = append(resp.Orders, toPbOrder(&page.Orders[i]))
+	}
+	return resp, nil
+}
+
+func toStatus(err error) error {
+	switch {
+	case errors.Is(err, domain.ErrOrderNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, domain.ErrInvalidCursor):
+		return status.Error(codes.InvalidArgument, err.Error())
+	default:
+		return status.Error(codes.Internal, err.Error())
+	}
+}
+
+func toPbOrder(o *domain.Order) *pb.Order {
+	order := &pb.Order{
+		Id:             o.ID,
+		CustomerId:     o.CustomerID,
+		Status:         o.Status,
+		TotalPrice:     o.TotalPrice,
+		BaseAmount:     toPbMoney(o.BaseAmount),
+		ChargedAmount:  toPbMoney(o.ChargedAmount),
+		TransactionId:  o.TransactionID,
+		FailureReason:  o.FailureReason,
+		Carrier:        o.Carrier,
+		TrackingNumber: o.TrackingNumber,
+		CreatedAt:      o.CreatedAt.Format(time.RFC3339Nano),
+	}
+	for _, item := range o.Items {
+		order.Items = append(order.Items, &pb.OrderItem{
+			ProductId: item.ProductID,
+			Name:      item.Name,
+			Price:     item.Price,
+			Quantity:  int32(item.Quantity),
+		})
+	}
+	return order
+}
+
+func toPbMoney(m domain.Money) *pb.Money {
+	return &pb.Money{CurrencyCode: m.CurrencyCode, Units: m.Units, Nanos: m.Nanos}
+}
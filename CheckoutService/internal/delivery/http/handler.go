@@ -2,31 +2,93 @@ package http
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"log/slog"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/egannguyen/go-kafka-ecommerce/checkout-service/internal/domain"
 	"github.com/egannguyen/go-kafka-ecommerce/checkout-service/internal/usecase"
 	"github.com/google/uuid"
 )
 
+// sseKeepAlive is how often an idle order event stream sends a comment so
+// proxies keep the connection open.
+const sseKeepAlive = 15 * time.Second
+
+// customerIDHeader carries the customer authenticated by the API gateway and
+// roleHeader the role of their token. Requests without a customer are anonymous.
+const (
+	customerIDHeader = "X-Customer-ID"
+	roleHeader       = "X-Customer-Role"
+)
+
+// roleOperator is the role of staff allowed to fulfil and refund any order.
+const roleOperator = "operator"
+
 type Handler struct {
 	checkoutUseCase usecase.CheckoutUseCase
+	statusFeed      *usecase.OrderStatusFeed
+	idempotency     *Idempotency
+	asyncCheckout   bool
 }
 
-func NewHandler(checkoutUseCase usecase.CheckoutUseCase) *Handler {
+// NewHandler creates the HTTP handler. With asyncCheckout, POST /api/orders
+// only queues the order and answers 202 Accepted; clients poll its status.
+// Order creation honours the Idempotency-Key header unless idempotency is nil.
+func NewHandler(checkoutUseCase usecase.CheckoutUseCase, statusFeed *usecase.OrderStatusFeed, idempotency *Idempotency, asyncCheckout bool) *Handler {
 	return &Handler{
 		checkoutUseCase: checkoutUseCase,
+		statusFeed:      statusFeed,
+		idempotency:     idempotency,
+		asyncCheckout:   asyncCheckout,
 	}
 }
 
 func (h *Handler) RegisterRoutes(mux *http.ServeMux) {
-	mux.HandleFunc("POST /api/orders", h.handleCreateOrder)
+	mux.HandleFunc("POST /api/orders", h.idempotency.Wrap(h.handleCreateOrder))
 	mux.HandleFunc("GET /api/orders", h.handleGetOrders)
+	mux.HandleFunc("GET /api/orders/mine", h.handleGetMyOrders)
+	mux.HandleFunc("GET /api/orders/{id}", h.handleGetOrder)
+	mux.HandleFunc("GET /api/orders/{id}/events", h.handleOrderEvents)
+	mux.HandleFunc("POST /api/orders/{id}/cancel", h.handleCancelOrder)
+	mux.HandleFunc("POST /api/orders/{id}/ship", operatorOnly(h.handleShipOrder))
+	mux.HandleFunc("POST /api/orders/{id}/deliver", operatorOnly(h.handleDeliverOrder))
+	mux.HandleFunc("POST /api/orders/{id}/return", h.handleRequestReturn)
+	mux.HandleFunc("POST /api/orders/{id}/refund", operatorOnly(h.handleRefundOrder))
+}
+
+// isOperator reports whether the request was made by an operator.
+func isOperator(r *http.Request) bool {
+	return r.Header.Get(customerIDHeader) != "" && r.Header.Get(roleHeader) == roleOperator
+}
+
+// operatorOnly rejects requests not made by an operator.
+func operatorOnly(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(customerIDHeader) == "" {
+			http.Error(w, "authentication required", http.StatusUnauthorized)
+			return
+		}
+		if !isOperator(r) {
+			http.Error(w, "operator role required", http.StatusForbidden)
+			return
+		}
+		next(w, r)
+	}
 }
 
+// CreateOrderRequest places an order either for the given items or, when
+// CartID is set, for the current content of that cart. The total is charged
+// to CreditCard in Currency (default USD).
 type CreateOrderRequest struct {
-	Items []domain.OrderItem `json:"items"`
+	CartID     string                 `json:"cart_id,omitempty"`
+	Items      []domain.OrderItem     `json:"items"`
+	CreditCard *domain.CreditCardInfo `json:"credit_card"`
+	Currency   string                 `json:"currency,omitempty"`
 }
 
 func (h *Handler) handleCreateOrder(w http.ResponseWriter, r *http.Request) {
@@ -36,42 +98,396 @@ func (h *Handler) handleCreateOrder(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	cmd := &domain.PlaceOrder{
-		OrderID: uuid.New().String(),
-		Items:   req.Items,
+	orderID := uuid.New().String()
+	customerID := r.Header.Get(customerIDHeader)
+
+	if h.asyncCheckout {
+		h.submitOrder(w, r, orderID, req)
+		return
 	}
 
-	if err := h.checkoutUseCase.PlaceOrder(r.Context(), cmd); err != nil {
-		slog.Error("Failed to place order", "err", err)
-		http.Error(w, "failed to place order", http.StatusInternalServerError)
+	var err error
+	if req.CartID != "" {
+		err = h.checkoutUseCase.PlaceOrderFromCart(r.Context(), &domain.PlaceOrderFromCart{
+			OrderID:    orderID,
+			CustomerID: customerID,
+			CartID:     req.CartID,
+			CreditCard: req.CreditCard,
+			Currency:   req.Currency,
+		})
+	} else {
+		err = h.checkoutUseCase.PlaceOrder(r.Context(), &domain.PlaceOrder{
+			OrderID:    orderID,
+			CustomerID: customerID,
+			Items:      req.Items,
+			CreditCard: req.CreditCard,
+			Currency:   req.Currency,
+		})
+	}
+	if err != nil {
+		h.writePlaceOrderError(w, err)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(map[string]string{
-		"order_id": cmd.OrderID,
-		"status":   "placed",
+		"order_id": orderID,
+		"status":   domain.OrderStatusPlaced,
 	})
 }
 
+// submitOrder queues the order and answers with the URL to poll for its status.
+func (h *Handler) submitOrder(w http.ResponseWriter, r *http.Request, orderID string, req CreateOrderRequest) {
+	err := h.checkoutUseCase.SubmitOrder(r.Context(), &domain.PlaceOrder{
+		OrderID:    orderID,
+		CustomerID: r.Header.Get(customerIDHeader),
+		CartID:     req.CartID,
+		Items:      req.Items,
+		CreditCard: req.CreditCard,
+		Currency:   req.Currency,
+	})
+	if err != nil {
+		h.writePlaceOrderError(w, err)
+		return
+	}
+
+	statusURL := "/api/orders/" + orderID
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", statusURL)
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(map[string]string{
+		"order_id":   orderID,
+		"status":     domain.OrderStatusPending,
+		"status_url": statusURL,
+	})
+}
+
+// writePlaceOrderError maps a rejected order to a response. A stale price is
+// answered with the current catalog prices so the client can refresh its cart.
+func (h *Handler) writePlaceOrderError(w http.ResponseWriter, err error) {
+	var priceErr *domain.PriceChangedError
+	switch {
+	case errors.As(err, &priceErr):
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"error": "prices have changed, please review your cart",
+			"items": priceErr.Changes,
+		})
+	case errors.Is(err, domain.ErrInvalidOrder):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, domain.ErrProductUnavailable):
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+	case errors.Is(err, domain.ErrCartNotOwned):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, domain.ErrConcurrencyConflict):
+		http.Error(w, "order was modified concurrently, please retry", http.StatusConflict)
+	default:
+		slog.Error("Failed to place order", "err", err)
+		http.Error(w, "failed to place order", http.StatusInternalServerError)
+	}
+}
+
+// handleGetOrders lists orders newest first. Query parameters: customer_id,
+// status, from and to (RFC 3339), limit and cursor (next_cursor of the
+// previous page). Customers only ever see their own orders; only operators
+// may list the orders of any customer.
 func (h *Handler) handleGetOrders(w http.ResponseWriter, r *http.Request) {
-	orders, err := h.checkoutUseCase.GetRecentOrders(r.Context(), 50)
+	customerID := r.Header.Get(customerIDHeader)
+	if customerID == "" {
+		http.Error(w, "authentication required", http.StatusUnauthorized)
+		return
+	}
+
+	filter, err := parseOrderFilter(r)
 	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if !isOperator(r) {
+		if filter.CustomerID != "" && filter.CustomerID != customerID {
+			http.Error(w, "cannot list orders of another customer", http.StatusForbidden)
+			return
+		}
+		filter.CustomerID = customerID
+	}
+	h.listOrders(w, r, filter)
+}
+
+// handleGetMyOrders lists the orders of the signed-in customer, with the same
+// query parameters as handleGetOrders except customer_id.
+func (h *Handler) handleGetMyOrders(w http.ResponseWriter, r *http.Request) {
+	customerID := r.Header.Get(customerIDHeader)
+	if customerID == "" {
+		http.Error(w, "authentication required", http.StatusUnauthorized)
+		return
+	}
+
+	filter, err := parseOrderFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	filter.CustomerID = customerID
+	h.listOrders(w, r, filter)
+}
+
+func (h *Handler) listOrders(w http.ResponseWriter, r *http.Request, filter domain.OrderFilter) {
+	page, err := h.checkoutUseCase.ListOrders(r.Context(), filter)
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidCursor) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
 		slog.Error("Failed to get orders", "err", err)
 		http.Error(w, "internal server error", http.StatusInternalServerError)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(orders)
+	json.NewEncoder(w).Encode(page)
+}
+
+func parseOrderFilter(r *http.Request) (domain.OrderFilter, error) {
+	q := r.URL.Query()
+	filter := domain.OrderFilter{
+		CustomerID: q.Get("customer_id"),
+		Status:     q.Get("status"),
+		Cursor:     q.Get("cursor"),
+	}
+
+	var err error
+	if v := q.Get("from"); v != "" {
+		if filter.CreatedFrom, err = time.Parse(time.RFC3339, v); err != nil {
+			return filter, fmt.Errorf("invalid from: %w", err)
+		}
+	}
+	if v := q.Get("to"); v != "" {
+		if filter.CreatedTo, err = time.Parse(time.RFC3339, v); err != nil {
+			return filter, fmt.Errorf("invalid to: %w", err)
+		}
+	}
+	if v := q.Get("limit"); v != "" {
+		if filter.Limit, err = strconv.Atoi(v); err != nil || filter.Limit < 0 {
+			return filter, fmt.Errorf("invalid limit: %s", v)
+		}
+	}
+	return filter, nil
+}
+
+func (h *Handler) handleGetOrder(w http.ResponseWriter, r *http.Request) {
+	order, ok := h.accessibleOrder(w, r, r.PathValue("id"))
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(order)
+}
+
+// accessibleOrder loads an order the caller may access. Otherwise it writes
+// the error response and returns false; orders of other customers are
+// reported as not found so their existence is not revealed.
+func (h *Handler) accessibleOrder(w http.ResponseWriter, r *http.Request, orderID string) (*domain.Order, bool) {
+	order, err := h.checkoutUseCase.GetOrder(r.Context(), orderID)
+	if err != nil {
+		if errors.Is(err, domain.ErrOrderNotFound) {
+			http.Error(w, "order not found", http.StatusNotFound)
+			return nil, false
+		}
+		slog.Error("Failed to get order", "order_id", orderID, "err", err)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return nil, false
+	}
+	if !order.AccessibleBy(r.Header.Get(customerIDHeader)) {
+		http.Error(w, "order not found", http.StatusNotFound)
+		return nil, false
+	}
+	return order, true
+}
+
+// handleOrderEvents streams the status transitions of an order as Server-Sent
+// Events. Each event's id is the version of the order stream, so a client
+// reconnecting with Last-Event-ID (or ?last_event_id= for the first request)
+// receives only what it missed. The stream ends once the order is final.
+func (h *Handler) handleOrderEvents(w http.ResponseWriter, r *http.Request) {
+	orderID := r.PathValue("id")
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+	if _, ok := h.accessibleOrder(w, r, orderID); !ok {
+		return
+	}
+
+	lastEventID := r.Header.Get("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = r.URL.Query().Get("last_event_id")
+	}
+	lastVersion := 0
+	if lastEventID != "" {
+		v, err := strconv.Atoi(lastEventID)
+		if err != nil || v < 0 {
+			http.Error(w, "invalid Last-Event-ID", http.StatusBadRequest)
+			return
+		}
+		lastVersion = v
+	}
+
+	// Watch before reading so a change between the read and the wait is not missed.
+	changed, stopWatching := h.statusFeed.Watch(orderID)
+	defer stopWatching()
+
+	ctx := r.Context()
+	changes, final, err := h.checkoutUseCase.OrderStatusChanges(ctx, orderID, lastVersion)
+	if err != nil {
+		if errors.Is(err, domain.ErrOrderNotFound) {
+			http.Error(w, "order not found", http.StatusNotFound)
+			return
+		}
+		slog.Error("Failed to load order status", "order_id", orderID, "err", err)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	keepAlive := time.NewTicker(sseKeepAlive)
+	defer keepAlive.Stop()
+
+	for {
+		for _, change := range changes {
+			if err := writeSSE(w, change); err != nil {
+				return
+			}
+			lastVersion = change.Version
+		}
+		flusher.Flush()
+		if final {
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-changed:
+		case <-keepAlive.C:
+			// Also re-read in case a notification was lost.
+			if _, err := io.WriteString(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+		}
+
+		changes, final, err = h.checkoutUseCase.OrderStatusChanges(ctx, orderID, lastVersion)
+		if err != nil {
+			if ctx.Err() == nil {
+				slog.Error("Failed to load order status", "order_id", orderID, "err", err)
+			}
+			return
+		}
+	}
+}
+
+func writeSSE(w io.Writer, change domain.OrderStatusChange) error {
+	data, err := json.Marshal(change)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", change.Version, change.EventType, data)
+	return err
+}
+
+type ReasonRequest struct {
+	Reason string `json:"reason"`
+}
+
+type ShipOrderRequest struct {
+	Carrier        string `json:"carrier"`
+	TrackingNumber string `json:"tracking_number"`
+}
+
+func (h *Handler) handleCancelOrder(w http.ResponseWriter, r *http.Request) {
+	var req ReasonRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	orderID := r.PathValue("id")
+	if _, ok := h.accessibleOrder(w, r, orderID); !ok {
+		return
+	}
+	h.writeTransitionResult(w, orderID, h.checkoutUseCase.CancelOrder(r.Context(), orderID, req.Reason))
+}
+
+func (h *Handler) handleShipOrder(w http.ResponseWriter, r *http.Request) {
+	var req ShipOrderRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.Carrier == "" || req.TrackingNumber == "" {
+		http.Error(w, "carrier and tracking_number are required", http.StatusBadRequest)
+		return
+	}
+
+	orderID := r.PathValue("id")
+	h.writeTransitionResult(w, orderID, h.checkoutUseCase.ShipOrder(r.Context(), orderID, req.Carrier, req.TrackingNumber))
+}
+
+func (h *Handler) handleDeliverOrder(w http.ResponseWriter, r *http.Request) {
+	orderID := r.PathValue("id")
+	h.writeTransitionResult(w, orderID, h.checkoutUseCase.DeliverOrder(r.Context(), orderID))
+}
+
+func (h *Handler) handleRequestReturn(w http.ResponseWriter, r *http.Request) {
+	var req ReasonRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	orderID := r.PathValue("id")
+	if _, ok := h.accessibleOrder(w, r, orderID); !ok {
+		return
+	}
+	h.writeTransitionResult(w, orderID, h.checkoutUseCase.RequestReturn(r.Context(), orderID, req.Reason))
+}
+
+func (h *Handler) handleRefundOrder(w http.ResponseWriter, r *http.Request) {
+	orderID := r.PathValue("id")
+	h.writeTransitionResult(w, orderID, h.checkoutUseCase.RefundOrder(r.Context(), orderID))
+}
+
+// writeTransitionResult maps the outcome of an order lifecycle transition to a response.
+func (h *Handler) writeTransitionResult(w http.ResponseWriter, orderID string, err error) {
+	switch {
+	case err == nil:
+		w.WriteHeader(http.StatusNoContent)
+	case errors.Is(err, domain.ErrOrderNotFound):
+		http.Error(w, "order not found", http.StatusNotFound)
+	case errors.Is(err, domain.ErrInvalidTransition):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, domain.ErrConcurrencyConflict):
+		http.Error(w, "order was modified concurrently, please retry", http.StatusConflict)
+	default:
+		slog.Error("Failed to transition order", "order_id", orderID, "err", err)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+	}
 }
 
 func EnableCORS(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
 		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Idempotency-Key")
 
 		if r.Method == http.MethodOptions {
 			w.WriteHeader(http.StatusOK)
@@ -0,0 +1,89 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/egannguyen/go-kafka-ecommerce/checkout-service/internal/domain"
+	"github.com/egannguyen/go-kafka-ecommerce/checkout-service/internal/usecase"
+)
+
+// fakeCheckout serves orders from a map and records transitions. Calling a
+// use case method it does not implement panics.
+type fakeCheckout struct {
+	usecase.CheckoutUseCase
+	orders      map[string]*domain.Order
+	transitions []string
+}
+
+func (f *fakeCheckout) GetOrder(ctx context.Context, orderID string) (*domain.Order, error) {
+	order, ok := f.orders[orderID]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", domain.ErrOrderNotFound, orderID)
+	}
+	return order, nil
+}
+
+func (f *fakeCheckout) CancelOrder(ctx context.Context, orderID string, reason string) error {
+	f.transitions = append(f.transitions, "cancel "+orderID)
+	return nil
+}
+
+func (f *fakeCheckout) RequestReturn(ctx context.Context, orderID string, reason string) error {
+	f.transitions = append(f.transitions, "return "+orderID)
+	return nil
+}
+
+func TestOrderAccessIsScopedToCustomer(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		customerID string
+		wantStatus int
+		wantCalled bool
+	}{
+		{"owner gets order", http.MethodGet, "/api/orders/order-1", "customer-1", http.StatusOK, false},
+		{"other customer gets order", http.MethodGet, "/api/orders/order-1", "customer-2", http.StatusNotFound, false},
+		{"anonymous gets customer order", http.MethodGet, "/api/orders/order-1", "", http.StatusNotFound, false},
+		{"anyone gets anonymous order", http.MethodGet, "/api/orders/guest-order", "customer-2", http.StatusOK, false},
+		{"unknown order", http.MethodGet, "/api/orders/missing", "customer-1", http.StatusNotFound, false},
+		{"owner cancels", http.MethodPost, "/api/orders/order-1/cancel", "customer-1", http.StatusNoContent, true},
+		{"other customer cancels", http.MethodPost, "/api/orders/order-1/cancel", "customer-2", http.StatusNotFound, false},
+		{"owner requests return", http.MethodPost, "/api/orders/order-1/return", "customer-1", http.StatusNoContent, true},
+		{"other customer requests return", http.MethodPost, "/api/orders/order-1/return", "customer-2", http.StatusNotFound, false},
+		{"other customer streams events", http.MethodGet, "/api/orders/order-1/events", "customer-2", http.StatusNotFound, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkout := &fakeCheckout{orders: map[string]*domain.Order{
+				"order-1":     {ID: "order-1", CustomerID: "customer-1", Status: domain.OrderStatusConfirmed},
+				"guest-order": {ID: "guest-order", Status: domain.OrderStatusConfirmed},
+			}}
+			mux := http.NewServeMux()
+			NewHandler(checkout, nil, nil, false).RegisterRoutes(mux)
+
+			r := httptest.NewRequest(tt.method, tt.path, strings.NewReader(`{}`))
+			if tt.customerID != "" {
+				r.Header.Set(customerIDHeader, tt.customerID)
+			}
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, r)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if called := len(checkout.transitions) > 0; called != tt.wantCalled {
+				t.Errorf("transitions = %v, want called %v", checkout.transitions, tt.wantCalled)
+			}
+			if tt.wantStatus == http.StatusNotFound && strings.Contains(w.Body.String(), "customer-1") {
+				t.Errorf("404 body reveals the owner: %q", w.Body.String())
+			}
+		})
+	}
+}
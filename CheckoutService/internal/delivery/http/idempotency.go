@@ -0,0 +1,137 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/egannguyen/go-kafka-ecommerce/checkout-service/internal/domain"
+)
+
+const (
+	idempotencyKeyHeader = "Idempotency-Key"
+	maxIdempotencyKeyLen = 255
+)
+
+// Idempotency lets clients safely retry a request by sending the same
+// Idempotency-Key header: the first response for a key is stored for ttl and
+// replayed for every repeat instead of processing the request again. Keys are
+// scoped to the customer, so one customer never sees another's response.
+type Idempotency struct {
+	store domain.IdempotencyStore
+	ttl   time.Duration
+}
+
+func NewIdempotency(store domain.IdempotencyStore, ttl time.Duration) *Idempotency {
+	return &Idempotency{store: store, ttl: ttl}
+}
+
+// Wrap applies the Idempotency-Key handling to next. Requests without the
+// header are passed through unchanged. A key reused with a different body is
+// rejected with 422, and one whose first request is still running with 409.
+// Server errors are not stored so the request can be retried with the same key.
+func (m *Idempotency) Wrap(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(idempotencyKeyHeader)
+		if m == nil || key == "" {
+			next(w, r)
+			return
+		}
+		if len(key) > maxIdempotencyKeyLen {
+			http.Error(w, "Idempotency-Key is too long", http.StatusBadRequest)
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		sum := sha256.Sum256(body)
+		hash := hex.EncodeToString(sum[:])
+
+		key = r.Header.Get(customerIDHeader) + ":" + key
+		now := time.Now()
+		existing, err := m.store.Claim(r.Context(), domain.IdempotencyRecord{
+			Key:         key,
+			RequestHash: hash,
+			CreatedAt:   now,
+			ExpiresAt:   now.Add(m.ttl),
+		})
+		if err != nil {
+			slog.Error("Failed to claim idempotency key", "key", key, "err", err)
+			http.Error(w, "internal server error", http.StatusInternalServerError)
+			return
+		}
+
+		if existing != nil {
+			switch {
+			case existing.RequestHash != hash:
+				http.Error(w, "Idempotency-Key was already used with a different request", http.StatusUnprocessableEntity)
+			case !existing.Completed:
+				http.Error(w, "a request with this Idempotency-Key is still being processed", http.StatusConflict)
+			default:
+				replay(w, existing)
+			}
+			return
+		}
+
+		rec := &responseRecorder{header: make(http.Header), statusCode: http.StatusOK}
+		next(rec, r)
+
+		// Store the outcome even if the client went away, so its retry finds it.
+		ctx := context.WithoutCancel(r.Context())
+		if rec.statusCode >= http.StatusInternalServerError {
+			if err := m.store.Release(ctx, key); err != nil {
+				slog.Error("Failed to release idempotency key", "key", key, "err", err)
+			}
+		} else if err := m.store.Complete(ctx, key, rec.statusCode, rec.header, rec.body.Bytes()); err != nil {
+			slog.Error("Failed to complete idempotency key", "key", key, "err", err)
+		}
+
+		for k, v := range rec.header {
+			w.Header()[k] = v
+		}
+		w.WriteHeader(rec.statusCode)
+		w.Write(rec.body.Bytes())
+	}
+}
+
+// replay writes the stored response of an earlier request.
+func replay(w http.ResponseWriter, rec *domain.IdempotencyRecord) {
+	for k, v := range rec.Header {
+		w.Header()[k] = v
+	}
+	w.Header().Set("Idempotent-Replayed", "true")
+	w.WriteHeader(rec.StatusCode)
+	w.Write(rec.Body)
+}
+
+// responseRecorder buffers a response so it can be stored before it is sent.
+type responseRecorder struct {
+	header     http.Header
+	statusCode int
+	body       bytes.Buffer
+	wroteHead  bool
+}
+
+func (r *responseRecorder) Header() http.Header { return r.header }
+
+func (r *responseRecorder) WriteHeader(statusCode int) {
+	if r.wroteHead {
+		return
+	}
+	r.statusCode = statusCode
+	r.wroteHead = true
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	r.wroteHead = true
+	return r.body.Write(b)
+}
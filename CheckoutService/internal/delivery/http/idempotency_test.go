@@ -0,0 +1,247 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/egannguyen/go-kafka-ecommerce/checkout-service/internal/domain"
+)
+
+// memIdempotencyStore is an in-memory IdempotencyStore.
+type memIdempotencyStore struct {
+	mu      sync.Mutex
+	records map[string]domain.IdempotencyRecord
+}
+
+func newMemIdempotencyStore() *memIdempotencyStore {
+	return &memIdempotencyStore{records: make(map[string]domain.IdempotencyRecord)}
+}
+
+func (s *memIdempotencyStore) Claim(ctx context.Context, rec domain.IdempotencyRecord) (*domain.IdempotencyRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if existing, ok := s.records[rec.Key]; ok && existing.ExpiresAt.After(time.Now()) {
+		return &existing, nil
+	}
+	s.records[rec.Key] = rec
+	return nil, nil
+}
+
+func (s *memIdempotencyStore) Complete(ctx context.Context, key string, statusCode int, header map[string][]string, body []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rec, ok := s.records[key]
+	if !ok {
+		return fmt.Errorf("idempotency key %s not claimed", key)
+	}
+	rec.Completed = true
+	rec.StatusCode = statusCode
+	rec.Header = header
+	rec.Body = append([]byte(nil), body...)
+	s.records[key] = rec
+	return nil
+}
+
+func (s *memIdempotencyStore) Release(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.records, key)
+	return nil
+}
+
+func (s *memIdempotencyStore) has(key string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.records[key]
+	return ok
+}
+
+// countingHandler answers every request with the given status and a body
+// numbering the call.
+func countingHandler(status int) (http.HandlerFunc, *atomic.Int32) {
+	var calls atomic.Int32
+	return func(w http.ResponseWriter, r *http.Request) {
+		n := calls.Add(1)
+		io.Copy(io.Discard, r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Location", "/api/orders/order-1")
+		w.WriteHeader(status)
+		fmt.Fprintf(w, `{"call":%d}`, n)
+	}, &calls
+}
+
+func idempotentRequest(key, customerID, body string) *http.Request {
+	r := httptest.NewRequest(http.MethodPost, "/api/orders", strings.NewReader(body))
+	if key != "" {
+		r.Header.Set(idempotencyKeyHeader, key)
+	}
+	if customerID != "" {
+		r.Header.Set(customerIDHeader, customerID)
+	}
+	return r
+}
+
+func serve(h http.HandlerFunc, r *http.Request) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	h(w, r)
+	return w
+}
+
+func TestIdempotencyStoresAndReplaysFirstResponse(t *testing.T) {
+	store := newMemIdempotencyStore()
+	next, calls := countingHandler(http.StatusAccepted)
+	h := NewIdempotency(store, time.Hour).Wrap(next)
+
+	first := serve(h, idempotentRequest("key-1", "customer-1", `{"order_id":"order-1"}`))
+	if first.Code != http.StatusAccepted || first.Body.String() != `{"call":1}` {
+		t.Fatalf("first response = %d %s", first.Code, first.Body)
+	}
+	if first.Header().Get("Idempotent-Replayed") != "" {
+		t.Error("first response marked as replayed")
+	}
+
+	for i := 0; i < 2; i++ {
+		again := serve(h, idempotentRequest("key-1", "customer-1", `{"order_id":"order-1"}`))
+		if again.Code != http.StatusAccepted || again.Body.String() != `{"call":1}` {
+			t.Errorf("replayed response = %d %s, want the first one", again.Code, again.Body)
+		}
+		if again.Header().Get("Idempotent-Replayed") != "true" {
+			t.Error("replayed response lacks Idempotent-Replayed")
+		}
+		if again.Header().Get("Location") != "/api/orders/order-1" {
+			t.Errorf("replayed Location = %q", again.Header().Get("Location"))
+		}
+	}
+	if calls.Load() != 1 {
+		t.Errorf("handler called %d times, want once", calls.Load())
+	}
+}
+
+func TestIdempotencyStoresClientErrors(t *testing.T) {
+	next, calls := countingHandler(http.StatusBadRequest)
+	h := NewIdempotency(newMemIdempotencyStore(), time.Hour).Wrap(next)
+
+	serve(h, idempotentRequest("key-1", "", `{}`))
+	again := serve(h, idempotentRequest("key-1", "", `{}`))
+	if again.Code != http.StatusBadRequest || again.Header().Get("Idempotent-Replayed") != "true" {
+		t.Errorf("response = %d replayed %q, want the stored 400", again.Code, again.Header().Get("Idempotent-Replayed"))
+	}
+	if calls.Load() != 1 {
+		t.Errorf("handler called %d times, want once", calls.Load())
+	}
+}
+
+func TestIdempotencyRejectsKeyReusedWithDifferentBody(t *testing.T) {
+	next, calls := countingHandler(http.StatusAccepted)
+	h := NewIdempotency(newMemIdempotencyStore(), time.Hour).Wrap(next)
+
+	serve(h, idempotentRequest("key-1", "customer-1", `{"order_id":"order-1"}`))
+	w := serve(h, idempotentRequest("key-1", "customer-1", `{"order_id":"order-2"}`))
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusUnprocessableEntity)
+	}
+	if calls.Load() != 1 {
+		t.Errorf("handler called %d times, want once", calls.Load())
+	}
+}
+
+func TestIdempotencyRejectsRequestInFlight(t *testing.T) {
+	started, finish := make(chan struct{}), make(chan struct{})
+	var calls atomic.Int32
+	h := NewIdempotency(newMemIdempotencyStore(), time.Hour).Wrap(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		close(started)
+		<-finish
+		w.WriteHeader(http.StatusAccepted)
+	})
+
+	done := make(chan *httptest.ResponseRecorder)
+	go func() { done <- serve(h, idempotentRequest("key-1", "", `{}`)) }()
+	<-started
+
+	w := serve(h, idempotentRequest("key-1", "", `{}`))
+	if w.Code != http.StatusConflict {
+		t.Errorf("status while in flight = %d, want %d", w.Code, http.StatusConflict)
+	}
+
+	close(finish)
+	if first := <-done; first.Code != http.StatusAccepted {
+		t.Errorf("first response = %d, want %d", first.Code, http.StatusAccepted)
+	}
+	if calls.Load() != 1 {
+		t.Errorf("handler called %d times, want once", calls.Load())
+	}
+}
+
+func TestIdempotencyReleasesKeyOnServerError(t *testing.T) {
+	store := newMemIdempotencyStore()
+	status := http.StatusServiceUnavailable
+	var calls atomic.Int32
+	h := NewIdempotency(store, time.Hour).Wrap(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(status)
+	})
+
+	if w := serve(h, idempotentRequest("key-1", "customer-1", `{}`)); w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("first response = %d", w.Code)
+	}
+	if store.has("customer-1:key-1") {
+		t.Error("key still held after a server error")
+	}
+
+	status = http.StatusAccepted
+	w := serve(h, idempotentRequest("key-1", "customer-1", `{}`))
+	if w.Code != http.StatusAccepted || w.Header().Get("Idempotent-Replayed") != "" {
+		t.Errorf("retry = %d replayed %q, want it processed again", w.Code, w.Header().Get("Idempotent-Replayed"))
+	}
+	if calls.Load() != 2 {
+		t.Errorf("handler called %d times, want twice", calls.Load())
+	}
+}
+
+func TestIdempotencyScopesKeysToCustomer(t *testing.T) {
+	next, calls := countingHandler(http.StatusAccepted)
+	h := NewIdempotency(newMemIdempotencyStore(), time.Hour).Wrap(next)
+
+	serve(h, idempotentRequest("key-1", "customer-1", `{}`))
+	w := serve(h, idempotentRequest("key-1", "customer-2", `{}`))
+	if w.Header().Get("Idempotent-Replayed") != "" || w.Body.String() != `{"call":2}` {
+		t.Errorf("another customer got the replayed response %s", w.Body)
+	}
+	if calls.Load() != 2 {
+		t.Errorf("handler called %d times, want twice", calls.Load())
+	}
+}
+
+func TestIdempotencyPassesThroughWithoutKey(t *testing.T) {
+	store := newMemIdempotencyStore()
+	next, calls := countingHandler(http.StatusAccepted)
+	h := NewIdempotency(store, time.Hour).Wrap(next)
+
+	serve(h, idempotentRequest("", "", `{}`))
+	serve(h, idempotentRequest("", "", `{}`))
+	if calls.Load() != 2 {
+		t.Errorf("handler called %d times, want twice", calls.Load())
+	}
+	if len(store.records) != 0 {
+		t.Errorf("stored %d records for requests without a key", len(store.records))
+	}
+}
+
+func TestIdempotencyRejectsLongKey(t *testing.T) {
+	next, calls := countingHandler(http.StatusAccepted)
+	h := NewIdempotency(newMemIdempotencyStore(), time.Hour).Wrap(next)
+
+	w := serve(h, idempotentRequest(strings.Repeat("k", maxIdempotencyKeyLen+1), "", `{}`))
+	if w.Code != http.StatusBadRequest || calls.Load() != 0 {
+		t.Errorf("status = %d after %d calls, want 400 without calling the handler", w.Code, calls.Load())
+	}
+}
@@ -0,0 +1,28 @@
+package domain
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrCartNotOwned rejects an order placed from another customer's cart.
+var ErrCartNotOwned = errors.New("cart belongs to another customer")
+
+// Cart is a shopping cart as read from CartService.
+type Cart struct {
+	ID         string
+	CustomerID string // owner; empty for anonymous carts
+	Items      []OrderItem
+}
+
+// CartService defines the interface for reading and changing shopping carts
+// in CartService. Changes act on behalf of customerID, empty for anonymous
+// shoppers, and return the updated cart.
+type CartService interface {
+	// GetCart returns the cart with the prices its items were added at. An
+	// unknown cart has no items.
+	GetCart(ctx context.Context, cartID string) (*Cart, error)
+	AddItem(ctx context.Context, customerID, cartID string, item OrderItem) (*Cart, error)
+	RemoveItem(ctx context.Context, customerID, cartID, productID string) (*Cart, error)
+	EmptyCart(ctx context.Context, customerID, cartID string) (*Cart, error)
+}
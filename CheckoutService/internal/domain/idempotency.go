@@ -0,0 +1,30 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// IdempotencyRecord is the outcome of a request made with an Idempotency-Key.
+// It is claimed before the request is processed and completed with the
+// response once it is known.
+type IdempotencyRecord struct {
+	Key         string              `bson:"_id"`
+	RequestHash string              `bson:"request_hash"`
+	Completed   bool                `bson:"completed"`
+	StatusCode  int                 `bson:"status_code,omitempty"`
+	Header      map[string][]string `bson:"header,omitempty"`
+	Body        []byte              `bson:"body,omitempty"`
+	CreatedAt   time.Time           `bson:"created_at"`
+	ExpiresAt   time.Time           `bson:"expires_at"`
+}
+
+type IdempotencyStore interface {
+	// Claim stores rec unless its key is already held by an unexpired record,
+	// in which case that record is returned instead.
+	Claim(ctx context.Context, rec IdempotencyRecord) (*IdempotencyRecord, error)
+	// Complete stores the response of the request that claimed key.
+	Complete(ctx context.Context, key string, statusCode int, header map[string][]string, body []byte) error
+	// Release forgets key so the request can be retried.
+	Release(ctx context.Context, key string) error
+}
@@ -0,0 +1,21 @@
+package domain
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrInsufficientStock is returned when a reservation is rejected for lack of stock.
+var ErrInsufficientStock = errors.New("insufficient stock")
+
+// InventoryService defines the interface for reserving stock in InventoryService.
+// All calls are idempotent per order.
+type InventoryService interface {
+	Reserve(ctx context.Context, orderID string, items []OrderItem) error
+	// Hold keeps an order's reservations from expiring once the order is placed.
+	Hold(ctx context.Context, orderID string, productIDs []string) error
+	Release(ctx context.Context, orderID string, productIDs []string) error
+	Commit(ctx context.Context, orderID string, productIDs []string) error
+	// Restock puts the stock committed for an order back into the physical stock.
+	Restock(ctx context.Context, orderID string, productIDs []string, reason string) error
+}
@@ -1,15 +1,17 @@
 package domain
 
 import (
-	"encoding/json"
 	"fmt"
 	"time"
+
+	"github.com/egannguyen/go-kafka-ecommerce/eventsourcing"
+	"github.com/egannguyen/go-kafka-ecommerce/eventsourcing/outbox"
 )
 
 type Money struct {
-	CurrencyCode string  `json:"currency_code" bson:"currency_code"`
-	Units        int64   `json:"units" bson:"units"`
-	Nanos        int32   `json:"nanos" bson:"nanos"`
+	CurrencyCode string `json:"currency_code" bson:"currency_code"`
+	Units        int64  `json:"units" bson:"units"`
+	Nanos        int32  `json:"nanos" bson:"nanos"`
 }
 
 // Product represents a product in the store.
@@ -33,32 +35,73 @@ type OrderItem struct {
 
 // Order represents a customer order.
 type Order struct {
-	ID         string      `json:"id" bson:"id"`
-	Items      []OrderItem `json:"items" bson:"items"`
-	TotalPrice float64     `json:"total_price" bson:"total_price"`
-	Status     string      `json:"status" bson:"status"` // "placed", "confirmed", "shipped"
-	CreatedAt  time.Time   `json:"created_at" bson:"created_at"`
+	ID             string      `json:"id" bson:"id"`
+	CustomerID     string      `json:"customer_id,omitempty" bson:"customer_id,omitempty"`
+	Items          []OrderItem `json:"items" bson:"items"`
+	TotalPrice     float64     `json:"total_price" bson:"total_price"`
+	BaseAmount     Money       `json:"base_amount" bson:"base_amount"`
+	ChargedAmount  Money       `json:"charged_amount" bson:"charged_amount"`
+	TransactionID  string      `json:"transaction_id,omitempty" bson:"transaction_id,omitempty"`
+	Status         string      `json:"status" bson:"status"` // see OrderStatus* constants
+	FailureReason  string      `json:"failure_reason,omitempty" bson:"failure_reason,omitempty"`
+	Carrier        string      `json:"carrier,omitempty" bson:"carrier,omitempty"`
+	TrackingNumber string      `json:"tracking_number,omitempty" bson:"tracking_number,omitempty"`
+	CreatedAt      time.Time   `json:"created_at" bson:"created_at"`
+	Version        int         `json:"version" bson:"version"` // of the last order event applied
+}
+
+// AccessibleBy reports whether the customer may see and act on the order.
+// Orders placed anonymously are accessible to everyone who knows their ID.
+func (o *Order) AccessibleBy(customerID string) bool {
+	return o.CustomerID == "" || o.CustomerID == customerID
 }
 
 // --- Commands ---
 
-// PlaceOrder is a command to create a new order.
+// PlaceOrder is a command to create a new order. CartID is set when the items
+// were taken from a cart, which is then cleared once the order is placed. The
+// total is charged in Currency, or BaseCurrency if empty, to CreditCard or,
+// for queued commands, to the card behind PaymentToken. The card is never
+// serialized, so it cannot end up in a Kafka topic.
 type PlaceOrder struct {
-	OrderID string      `json:"order_id"`
-	Items   []OrderItem `json:"items"`
+	OrderID      string          `json:"order_id"`
+	CustomerID   string          `json:"customer_id,omitempty"`
+	CartID       string          `json:"cart_id,omitempty"`
+	Items        []OrderItem     `json:"items"`
+	CreditCard   *CreditCardInfo `json:"-"`
+	PaymentToken string          `json:"payment_token,omitempty"`
+	Currency     string          `json:"currency,omitempty"`
+}
+
+// PlaceOrderFromCart is a command to order the current content of a cart.
+type PlaceOrderFromCart struct {
+	OrderID      string          `json:"order_id"`
+	CustomerID   string          `json:"customer_id,omitempty"`
+	CartID       string          `json:"cart_id"`
+	CreditCard   *CreditCardInfo `json:"-"`
+	PaymentToken string          `json:"payment_token,omitempty"`
+	Currency     string          `json:"currency,omitempty"`
 }
 
 // --- Events ---
 
-type Event interface {
-	EventType() string
-}
+type Event = eventsourcing.Event
 
+// OrderPlaced records the order with the catalog name and price of every
+// item as they were at PricedAt, so later catalog changes do not alter it.
+// BaseAmount is the total in BaseCurrency, ChargedAmount what the payment
+// identified by TransactionID charged in the customer's currency.
 type OrderPlaced struct {
-	OrderID    string      `json:"order_id"`
-	Items      []OrderItem `json:"items"`
-	TotalPrice float64     `json:"total_price"`
-	PlacedAt   time.Time   `json:"placed_at"`
+	OrderID       string      `json:"order_id"`
+	CustomerID    string      `json:"customer_id,omitempty"`
+	CartID        string      `json:"cart_id,omitempty"`
+	Items         []OrderItem `json:"items"`
+	TotalPrice    float64     `json:"total_price"`
+	BaseAmount    Money       `json:"base_amount"`
+	ChargedAmount Money       `json:"charged_amount"`
+	TransactionID string      `json:"transaction_id"`
+	PricedAt      time.Time   `json:"priced_at,omitempty"`
+	PlacedAt      time.Time   `json:"placed_at"`
 }
 
 func (e OrderPlaced) EventType() string { return "OrderPlaced" }
@@ -70,61 +113,24 @@ type OrderConfirmed struct {
 
 func (e OrderConfirmed) EventType() string { return "OrderConfirmed" }
 
-type ProductStockUpdated struct {
-	ProductID string `json:"product_id"`
-	NewStock  int    `json:"new_stock"`
-}
-
-func (e ProductStockUpdated) EventType() string { return "ProductStockUpdated" }
-
-type InventoryReserved struct {
-	OrderID   string `json:"order_id"`
-	ProductID string `json:"product_id"`
-	Quantity  int    `json:"quantity"`
-}
-
-func (e InventoryReserved) EventType() string { return "InventoryReserved" }
-
-type ReservationReleased struct {
-	OrderID   string `json:"order_id"`
-	ProductID string `json:"product_id"`
-	Quantity  int    `json:"quantity"`
-}
-
-func (e ReservationReleased) EventType() string { return "ReservationReleased" }
-
-type ReservationConfirmed struct {
-	OrderID   string `json:"order_id"`
-	ProductID string `json:"product_id"`
-	Quantity  int    `json:"quantity"`
+// OrderFailed is emitted when the checkout saga gives up on an order and has
+// compensated every step that had already completed.
+type OrderFailed struct {
+	OrderID  string    `json:"order_id"`
+	Reason   string    `json:"reason"`
+	FailedAt time.Time `json:"failed_at"`
 }
 
-func (e ReservationConfirmed) EventType() string { return "ReservationConfirmed" }
+func (e OrderFailed) EventType() string { return "OrderFailed" }
 
 // EventRecord represents an event stored in the event store.
-type EventRecord struct {
-	ID            string    `json:"id" bson:"id"`
-	StreamID      string    `json:"stream_id" bson:"stream_id"`
-	StreamType    string    `json:"stream_type" bson:"stream_type"`
-	Version       int       `json:"version" bson:"version"`
-	EventType     string    `json:"event_type" bson:"event_type"`
-	Payload       []byte    `json:"payload" bson:"payload"`
-	CreatedAt     time.Time `json:"created_at" bson:"created_at"`
-}
-
-// AggregateBase provides a basic implementation for an aggregate.
-type AggregateBase struct {
-	ID      string
-	Version int
-}
+type EventRecord = eventsourcing.EventRecord
 
-func (a *AggregateBase) GetAggregateID() string {
-	return a.ID
-}
+// OutboxMessage is an event waiting to be published to Kafka by the outbox relay.
+type OutboxMessage = outbox.Message
 
-func (a *AggregateBase) GetVersion() int {
-	return a.Version
-}
+// AggregateBase provides a basic implementation for an aggregate.
+type AggregateBase = eventsourcing.AggregateBase
 
 // OrderAggregate manages the state of an Order by replaying events.
 type OrderAggregate struct {
@@ -139,7 +145,7 @@ type OrderAggregate struct {
 func NewOrderAggregate(id string) *OrderAggregate {
 	return &OrderAggregate{
 		AggregateBase: AggregateBase{ID: id, Version: 0},
-		Status:        "pending",
+		Status:        OrderStatusPending,
 	}
 }
 
@@ -149,12 +155,27 @@ func (a *OrderAggregate) ApplyEvent(e Event) error {
 	case OrderPlaced:
 		a.Items = e.Items
 		a.TotalPrice = e.TotalPrice
-		a.Status = "placed"
+		a.Status = OrderStatusPlaced
 		if a.CreatedAt.IsZero() {
 			a.CreatedAt = e.PlacedAt
 		}
 	case OrderConfirmed:
-		a.Status = "confirmed"
+		a.Status = OrderStatusConfirmed
+	case OrderFailed:
+		a.Status = OrderStatusFailed
+		if a.CreatedAt.IsZero() {
+			a.CreatedAt = e.FailedAt
+		}
+	case OrderCancelled:
+		a.Status = OrderStatusCancelled
+	case OrderShipped:
+		a.Status = OrderStatusShipped
+	case OrderDelivered:
+		a.Status = OrderStatusDelivered
+	case OrderReturnRequested:
+		a.Status = OrderStatusReturnRequested
+	case OrderRefunded:
+		a.Status = OrderStatusRefunded
 	default:
 		return fmt.Errorf("unknown event type for OrderAggregate: %s", e.EventType())
 	}
@@ -162,100 +183,30 @@ func (a *OrderAggregate) ApplyEvent(e Event) error {
 	return nil
 }
 
-// Rehydrate rebuilds the aggregate from a list of records.
-func (a *OrderAggregate) Rehydrate(records []EventRecord) error {
-	for _, rec := range records {
-		var err error
-		switch rec.EventType {
-		case "OrderPlaced":
-			var e OrderPlaced
-			if err = json.Unmarshal(rec.Payload, &e); err == nil {
-				err = a.ApplyEvent(e)
-			}
-		case "OrderConfirmed":
-			var e OrderConfirmed
-			if err = json.Unmarshal(rec.Payload, &e); err == nil {
-				err = a.ApplyEvent(e)
-			}
-		default:
-			return fmt.Errorf("unknown event type in stream: %s", rec.EventType)
-		}
-		if err != nil {
-			return fmt.Errorf("failed to apply event from stream: %w", err)
-		}
-	}
-	return nil
-}
-
-// InventoryAggregate manages the stock of a product by replaying events.
-type InventoryAggregate struct {
-	AggregateBase
-	HardStock     int // Total physical items
-	ReservedStock int // Items locked for pending orders
-}
-
-// AvailableStock returns the stock available for new reservations.
-func (a *InventoryAggregate) AvailableStock() int {
-	return a.HardStock - a.ReservedStock
-}
+// orderEvents decodes the events of order streams, upcasting old payloads.
+var orderEvents = newOrderEvents()
 
-// NewInventoryAggregate creates a new InventoryAggregate.
-func NewInventoryAggregate(productID string) *InventoryAggregate {
-	return &InventoryAggregate{
-		AggregateBase: AggregateBase{ID: productID, Version: 0},
-	}
+func newOrderEvents() *eventsourcing.Registry {
+	r := eventsourcing.NewRegistry(
+		OrderPlaced{},
+		OrderConfirmed{},
+		OrderFailed{},
+		OrderCancelled{},
+		OrderShipped{},
+		OrderDelivered{},
+		OrderReturnRequested{},
+		OrderRefunded{},
+	)
+	r.RegisterUpcaster("OrderPlaced", 1, upcastOrderPlacedV1)
+	return r
 }
 
-// ApplyEvent mutates the aggregate state based on the event.
-func (a *InventoryAggregate) ApplyEvent(e Event) error {
-	switch e := e.(type) {
-	case ProductStockUpdated:
-		a.HardStock = e.NewStock
-	case InventoryReserved:
-		a.ReservedStock += e.Quantity
-	case ReservationReleased:
-		a.ReservedStock -= e.Quantity
-	case ReservationConfirmed:
-		a.ReservedStock -= e.Quantity
-		a.HardStock -= e.Quantity
-	default:
-		return fmt.Errorf("unknown event type for InventoryAggregate: %s", e.EventType())
-	}
-	a.Version++
-	return nil
+// Rehydrate rebuilds the aggregate from a list of records.
+func (a *OrderAggregate) Rehydrate(records []EventRecord) error {
+	return eventsourcing.Rehydrate(a, orderEvents, records)
 }
 
-// Rehydrate rebuilds the aggregate from a list of records.
-func (a *InventoryAggregate) Rehydrate(records []EventRecord) error {
-	for _, rec := range records {
-		var err error
-		switch rec.EventType {
-		case "ProductStockUpdated":
-			var e ProductStockUpdated
-			if err = json.Unmarshal(rec.Payload, &e); err == nil {
-				err = a.ApplyEvent(e)
-			}
-		case "InventoryReserved":
-			var e InventoryReserved
-			if err = json.Unmarshal(rec.Payload, &e); err == nil {
-				err = a.ApplyEvent(e)
-			}
-		case "ReservationReleased":
-			var e ReservationReleased
-			if err = json.Unmarshal(rec.Payload, &e); err == nil {
-				err = a.ApplyEvent(e)
-			}
-		case "ReservationConfirmed":
-			var e ReservationConfirmed
-			if err = json.Unmarshal(rec.Payload, &e); err == nil {
-				err = a.ApplyEvent(e)
-			}
-		default:
-			return fmt.Errorf("unknown event type in stream: %s", rec.EventType)
-		}
-		if err != nil {
-			return fmt.Errorf("failed to apply event from stream: %w", err)
-		}
-	}
-	return nil
+// DecodeOrderEvent unmarshals a stored order stream record into its event type.
+func DecodeOrderEvent(rec EventRecord) (Event, error) {
+	return orderEvents.Decode(rec)
 }
@@ -0,0 +1,139 @@
+package domain
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// loadFixture reads stored records from testdata. Payloads are written as
+// JSON objects rather than the base64 of EventRecord.Payload.
+func loadFixture(t *testing.T, name string) []EventRecord {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatalf("failed to read fixture: %v", err)
+	}
+
+	var fixtures []struct {
+		EventType     string          `json:"event_type"`
+		SchemaVersion int             `json:"schema_version"`
+		Payload       json.RawMessage `json:"payload"`
+	}
+	if err := json.Unmarshal(data, &fixtures); err != nil {
+		t.Fatalf("failed to decode fixture: %v", err)
+	}
+
+	records := make([]EventRecord, len(fixtures))
+	for i, f := range fixtures {
+		records[i] = EventRecord{
+			StreamID:      "order",
+			StreamType:    "order",
+			Version:       i + 1,
+			EventType:     f.EventType,
+			SchemaVersion: f.SchemaVersion,
+			Payload:       f.Payload,
+		}
+	}
+	return records
+}
+
+func TestRehydrateStoredEvents(t *testing.T) {
+	tests := []struct {
+		name        string
+		fixture     string
+		wantStatus  string
+		wantItems   int
+		wantTotal   float64
+		wantCreated time.Time
+		wantBase    Money
+		wantCharged Money
+	}{
+		{
+			name:        "v1 events",
+			fixture:     "order_v1.json",
+			wantStatus:  OrderStatusConfirmed,
+			wantItems:   2,
+			wantTotal:   58.48,
+			wantCreated: time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC),
+			wantBase:    NewMoney(BaseCurrency, 58.48),
+			wantCharged: NewMoney(BaseCurrency, 58.48),
+		},
+		{
+			name:        "v1 events carrying the amounts",
+			fixture:     "order_v1_with_amounts.json",
+			wantStatus:  OrderStatusPlaced,
+			wantItems:   1,
+			wantTotal:   19.99,
+			wantCreated: time.Date(2024, 6, 1, 10, 0, 0, 0, time.UTC),
+			wantBase:    Money{CurrencyCode: "USD", Units: 19, Nanos: 990000000},
+			wantCharged: Money{CurrencyCode: "EUR", Units: 18, Nanos: 500000000},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			records := loadFixture(t, tt.fixture)
+			order := NewOrderAggregate("order")
+			if err := order.Rehydrate(records); err != nil {
+				t.Fatalf("Rehydrate: %v", err)
+			}
+
+			if order.Version != len(records) {
+				t.Errorf("version = %d, want %d", order.Version, len(records))
+			}
+			if order.Status != tt.wantStatus {
+				t.Errorf("status = %s, want %s", order.Status, tt.wantStatus)
+			}
+			if len(order.Items) != tt.wantItems {
+				t.Errorf("order has %d items, want %d", len(order.Items), tt.wantItems)
+			}
+			if order.TotalPrice != tt.wantTotal {
+				t.Errorf("total = %v, want %v", order.TotalPrice, tt.wantTotal)
+			}
+			if !order.CreatedAt.Equal(tt.wantCreated) {
+				t.Errorf("created at %v, want %v", order.CreatedAt, tt.wantCreated)
+			}
+
+			event, err := DecodeOrderEvent(records[0])
+			if err != nil {
+				t.Fatalf("DecodeOrderEvent: %v", err)
+			}
+			placed, ok := event.(OrderPlaced)
+			if !ok {
+				t.Fatalf("decoded %T, want OrderPlaced", event)
+			}
+			if placed.BaseAmount != tt.wantBase {
+				t.Errorf("base amount = %+v, want %+v", placed.BaseAmount, tt.wantBase)
+			}
+			if placed.ChargedAmount != tt.wantCharged {
+				t.Errorf("charged amount = %+v, want %+v", placed.ChargedAmount, tt.wantCharged)
+			}
+		})
+	}
+}
+
+func TestOrderAccessibleBy(t *testing.T) {
+	tests := []struct {
+		name       string
+		owner      string
+		customerID string
+		want       bool
+	}{
+		{"owner", "customer-1", "customer-1", true},
+		{"other customer", "customer-1", "customer-2", false},
+		{"anonymous caller", "customer-1", "", false},
+		{"anonymous order", "", "customer-2", true},
+		{"anonymous order and caller", "", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			order := &Order{ID: "order-1", CustomerID: tt.owner}
+			if got := order.AccessibleBy(tt.customerID); got != tt.want {
+				t.Errorf("AccessibleBy(%q) = %v, want %v", tt.customerID, got, tt.want)
+			}
+		})
+	}
+}
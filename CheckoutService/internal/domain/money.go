@@ -0,0 +1,21 @@
+package domain
+
+import "math"
+
+// BaseCurrency is the currency of catalog prices and order totals.
+const BaseCurrency = "USD"
+
+// NewMoney converts a decimal amount to Money.
+func NewMoney(currencyCode string, amount float64) Money {
+	units, frac := math.Modf(amount)
+	return Money{
+		CurrencyCode: currencyCode,
+		Units:        int64(units),
+		Nanos:        int32(math.Round(frac * 1e9)),
+	}
+}
+
+// Amount returns the decimal value of m.
+func (m Money) Amount() float64 {
+	return float64(m.Units) + float64(m.Nanos)/1e9
+}
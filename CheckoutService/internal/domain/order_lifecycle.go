@@ -0,0 +1,116 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Order statuses.
+const (
+	OrderStatusPending         = "pending"
+	OrderStatusPlaced          = "placed"
+	OrderStatusConfirmed       = "confirmed"
+	OrderStatusFailed          = "failed"
+	OrderStatusCancelled       = "cancelled"
+	OrderStatusShipped         = "shipped"
+	OrderStatusDelivered       = "delivered"
+	OrderStatusReturnRequested = "return_requested"
+	OrderStatusRefunded        = "refunded"
+)
+
+var (
+	ErrOrderNotFound     = errors.New("order not found")
+	ErrInvalidTransition = errors.New("invalid order status transition")
+)
+
+// orderTransitions lists, for every target status, the statuses an order may
+// move to it from.
+var orderTransitions = map[string][]string{
+	OrderStatusPlaced:          {OrderStatusPending},
+	OrderStatusConfirmed:       {OrderStatusPlaced},
+	OrderStatusFailed:          {OrderStatusPending, OrderStatusPlaced},
+	OrderStatusCancelled:       {OrderStatusPlaced, OrderStatusConfirmed},
+	OrderStatusShipped:         {OrderStatusConfirmed},
+	OrderStatusDelivered:       {OrderStatusShipped},
+	OrderStatusReturnRequested: {OrderStatusDelivered},
+	OrderStatusRefunded:        {OrderStatusCancelled, OrderStatusReturnRequested},
+}
+
+// IsFinal reports whether the order's status allows no further transition.
+func (a *OrderAggregate) IsFinal() bool {
+	for _, froms := range orderTransitions {
+		for _, from := range froms {
+			if a.Status == from {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// OrderStatusChange describes one event of an order's stream and the status
+// the order had after it.
+type OrderStatusChange struct {
+	OrderID    string    `json:"order_id"`
+	Version    int       `json:"version"`
+	EventType  string    `json:"event_type"`
+	Status     string    `json:"status"`
+	OccurredAt time.Time `json:"occurred_at"`
+}
+
+// CanTransitionTo returns ErrInvalidTransition if the order may not move from
+// its current status to the given one.
+func (a *OrderAggregate) CanTransitionTo(status string) error {
+	for _, from := range orderTransitions[status] {
+		if a.Status == from {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s -> %s", ErrInvalidTransition, a.Status, status)
+}
+
+// OrderCancelled is emitted when an order is cancelled before shipping.
+type OrderCancelled struct {
+	OrderID     string    `json:"order_id"`
+	Reason      string    `json:"reason"`
+	CancelledAt time.Time `json:"cancelled_at"`
+}
+
+func (e OrderCancelled) EventType() string { return "OrderCancelled" }
+
+// OrderShipped is emitted when a confirmed order is handed to a carrier.
+type OrderShipped struct {
+	OrderID        string    `json:"order_id"`
+	Carrier        string    `json:"carrier"`
+	TrackingNumber string    `json:"tracking_number"`
+	ShippedAt      time.Time `json:"shipped_at"`
+}
+
+func (e OrderShipped) EventType() string { return "OrderShipped" }
+
+// OrderDelivered is emitted when the carrier reports the order delivered.
+type OrderDelivered struct {
+	OrderID     string    `json:"order_id"`
+	DeliveredAt time.Time `json:"delivered_at"`
+}
+
+func (e OrderDelivered) EventType() string { return "OrderDelivered" }
+
+// OrderReturnRequested is emitted when the customer asks to return a delivered order.
+type OrderReturnRequested struct {
+	OrderID     string    `json:"order_id"`
+	Reason      string    `json:"reason"`
+	RequestedAt time.Time `json:"requested_at"`
+}
+
+func (e OrderReturnRequested) EventType() string { return "OrderReturnRequested" }
+
+// OrderRefunded is emitted once the payment of a cancelled or returned order is refunded.
+type OrderRefunded struct {
+	OrderID       string    `json:"order_id"`
+	TransactionID string    `json:"transaction_id"`
+	RefundedAt    time.Time `json:"refunded_at"`
+}
+
+func (e OrderRefunded) EventType() string { return "OrderRefunded" }
@@ -0,0 +1,108 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+)
+
+var orderStatuses = []string{
+	OrderStatusPending,
+	OrderStatusPlaced,
+	OrderStatusConfirmed,
+	OrderStatusFailed,
+	OrderStatusCancelled,
+	OrderStatusShipped,
+	OrderStatusDelivered,
+	OrderStatusReturnRequested,
+	OrderStatusRefunded,
+}
+
+func TestCanTransitionTo(t *testing.T) {
+	allowed := map[[2]string]bool{
+		{OrderStatusPending, OrderStatusPlaced}:            true,
+		{OrderStatusPlaced, OrderStatusConfirmed}:          true,
+		{OrderStatusPending, OrderStatusFailed}:            true,
+		{OrderStatusPlaced, OrderStatusFailed}:             true,
+		{OrderStatusPlaced, OrderStatusCancelled}:          true,
+		{OrderStatusConfirmed, OrderStatusCancelled}:       true,
+		{OrderStatusConfirmed, OrderStatusShipped}:         true,
+		{OrderStatusShipped, OrderStatusDelivered}:         true,
+		{OrderStatusDelivered, OrderStatusReturnRequested}: true,
+		{OrderStatusCancelled, OrderStatusRefunded}:        true,
+		{OrderStatusReturnRequested, OrderStatusRefunded}:  true,
+	}
+
+	for _, from := range orderStatuses {
+		for _, to := range orderStatuses {
+			want := allowed[[2]string{from, to}]
+			t.Run(from+" -> "+to, func(t *testing.T) {
+				order := &OrderAggregate{Status: from}
+				err := order.CanTransitionTo(to)
+				if want && err != nil {
+					t.Errorf("CanTransitionTo = %v, want allowed", err)
+				}
+				if !want && !errors.Is(err, ErrInvalidTransition) {
+					t.Errorf("CanTransitionTo = %v, want %v", err, ErrInvalidTransition)
+				}
+			})
+		}
+	}
+}
+
+func TestIsFinal(t *testing.T) {
+	final := map[string]bool{
+		OrderStatusFailed:   true,
+		OrderStatusRefunded: true,
+	}
+
+	for _, status := range orderStatuses {
+		t.Run(status, func(t *testing.T) {
+			order := &OrderAggregate{Status: status}
+			if got := order.IsFinal(); got != final[status] {
+				t.Errorf("IsFinal = %v, want %v", got, final[status])
+			}
+		})
+	}
+}
+
+func TestApplyLifecycleEvents(t *testing.T) {
+	tests := []struct {
+		name   string
+		events []Event
+		want   string
+	}{
+		{name: "placed", events: []Event{OrderPlaced{}}, want: OrderStatusPlaced},
+		{name: "confirmed", events: []Event{OrderPlaced{}, OrderConfirmed{}}, want: OrderStatusConfirmed},
+		{name: "failed", events: []Event{OrderFailed{}}, want: OrderStatusFailed},
+		{name: "cancelled and refunded", events: []Event{OrderPlaced{}, OrderConfirmed{}, OrderCancelled{}, OrderRefunded{}}, want: OrderStatusRefunded},
+		{name: "delivered", events: []Event{OrderPlaced{}, OrderConfirmed{}, OrderShipped{}, OrderDelivered{}}, want: OrderStatusDelivered},
+		{name: "returned", events: []Event{OrderPlaced{}, OrderConfirmed{}, OrderShipped{}, OrderDelivered{}, OrderReturnRequested{}, OrderRefunded{}}, want: OrderStatusRefunded},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			order := NewOrderAggregate("order-1")
+			for _, e := range tt.events {
+				if err := order.CanTransitionTo(statusAfter(e)); err != nil {
+					t.Fatalf("applying %s: %v", e.EventType(), err)
+				}
+				if err := order.ApplyEvent(e); err != nil {
+					t.Fatalf("ApplyEvent(%s): %v", e.EventType(), err)
+				}
+			}
+			if order.Status != tt.want {
+				t.Errorf("status = %s, want %s", order.Status, tt.want)
+			}
+			if order.Version != len(tt.events) {
+				t.Errorf("version = %d, want %d", order.Version, len(tt.events))
+			}
+		})
+	}
+}
+
+// statusAfter returns the status an order has after e.
+func statusAfter(e Event) string {
+	order := NewOrderAggregate("")
+	_ = order.ApplyEvent(e)
+	return order.Status
+}
@@ -4,11 +4,18 @@ import "context"
 
 type PaymentService interface {
 	Charge(ctx context.Context, amount Money, card CreditCardInfo) (string, error)
+	// ChargeToken charges the card behind a token returned by Tokenize. A token
+	// can be charged only once.
+	ChargeToken(ctx context.Context, amount Money, token string) (string, error)
+	// Tokenize hands a card to PaymentService and returns a token standing for
+	// it, so a payment can be queued without the card data.
+	Tokenize(ctx context.Context, card CreditCardInfo) (string, error)
+	Refund(ctx context.Context, transactionID string) error
 }
 
 type CreditCardInfo struct {
-	Number          string
-	CVV             int32
-	ExpirationMonth int32
-	ExpirationYear  int32
+	Number          string `json:"number"`
+	CVV             int32  `json:"cvv"`
+	ExpirationMonth int32  `json:"expiration_month"`
+	ExpirationYear  int32  `json:"expiration_year"`
 }
@@ -0,0 +1,44 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+var (
+	ErrInvalidOrder       = errors.New("invalid order")
+	ErrProductUnavailable = errors.New("product unavailable")
+	ErrPriceChanged       = errors.New("price changed")
+)
+
+// PriceChange describes an item whose client-side price no longer matches the catalog.
+type PriceChange struct {
+	ProductID    string  `json:"product_id"`
+	Name         string  `json:"name"`
+	ClientPrice  float64 `json:"client_price"`
+	CurrentPrice float64 `json:"current_price"`
+}
+
+// PriceChangedError is returned when an order was built from stale prices.
+// It lists every item whose price changed so the client can refresh them.
+type PriceChangedError struct {
+	Changes []PriceChange
+}
+
+func (e *PriceChangedError) Error() string {
+	return fmt.Sprintf("%s for %d item(s)", ErrPriceChanged, len(e.Changes))
+}
+
+func (e *PriceChangedError) Unwrap() error { return ErrPriceChanged }
+
+// RoundPrice rounds a price to whole cents. Catalog prices travel as float32
+// over gRPC and would otherwise carry conversion noise into order totals.
+func RoundPrice(price float64) float64 {
+	return math.Round(price*100) / 100
+}
+
+// PriceMatches reports whether a client-side price equals the catalog price to the cent.
+func PriceMatches(clientPrice, catalogPrice float64) bool {
+	return RoundPrice(clientPrice) == RoundPrice(catalogPrice)
+}
@@ -1,22 +1,86 @@
 package domain
 
-import "context"
+import (
+	"context"
+	"time"
+
+	"github.com/egannguyen/go-kafka-ecommerce/eventsourcing"
+	"github.com/egannguyen/go-kafka-ecommerce/eventsourcing/outbox"
+)
 
 type OrderRepository interface {
-	FindRecent(ctx context.Context, limit int) ([]Order, error)
-	UpdateOrderProjection(ctx context.Context, event interface{}) error
+	List(ctx context.Context, filter OrderFilter) (*OrderPage, error)
+	// FindByID returns the order, or nil if it does not exist.
+	FindByID(ctx context.Context, orderID string) (*Order, error)
+	// UpdateOrderProjection applies an event found at version of its order
+	// stream. Events not newer than the last one applied are ignored.
+	UpdateOrderProjection(ctx context.Context, event interface{}, version int) error
+	// MarkPending records a submitted order that checkout has not processed
+	// yet. It does nothing if the order is already known.
+	MarkPending(ctx context.Context, orderID, customerID string, submittedAt time.Time) error
 }
 
+// SagaRepository persists checkout saga state between steps.
+type SagaRepository interface {
+	Save(ctx context.Context, saga *CheckoutSaga) error
+	Get(ctx context.Context, orderID string) (*CheckoutSaga, error)
+	FindIncomplete(ctx context.Context) ([]CheckoutSaga, error)
+}
 
+// EventStore persists order streams and reads them back in global order.
 type EventStore interface {
-	SaveEvents(ctx context.Context, aggregateID string, aggregateType string, expectedVersion int, events []Event) error
-	LoadEvents(ctx context.Context, aggregateID string) ([]EventRecord, error)
+	eventsourcing.GlobalEventStore
+	// SaveEventsWithOutbox saves events and, in the same transaction, queues each
+	// of them in the outbox for publishing to topic keyed by the aggregate ID.
+	SaveEventsWithOutbox(ctx context.Context, aggregateID string, aggregateType string, expectedVersion int, events []Event, topic string) error
+}
+
+// ErrConcurrencyConflict is returned by EventStore appends when another writer
+// appended to the stream first.
+var ErrConcurrencyConflict = eventsourcing.ErrConcurrencyConflict
+
+// CheckpointStore records the last global position a named subscriber has
+// processed so it can resume after a restart.
+type CheckpointStore interface {
+	Load(ctx context.Context, name string) (int64, error)
+	Save(ctx context.Context, name string, position int64) error
 }
 
+// OrderProjectionStore manages the shadow collection used to rebuild the
+// orders read model.
+type OrderProjectionStore interface {
+	// ResetShadow empties the shadow collection and returns a repository writing to it.
+	ResetShadow(ctx context.Context) (OrderRepository, error)
+	Diff(ctx context.Context) (*ProjectionDiff, error)
+	// CopyPending copies the pending orders of the live collection, which
+	// have no events yet, into the shadow one and returns how many it copied.
+	CopyPending(ctx context.Context) (int, error)
+	// Swap atomically replaces the live collection with the shadow one.
+	Swap(ctx context.Context) error
+	DropShadow(ctx context.Context) error
+}
+
+// ProjectionDiff compares a rebuilt projection against the live one by order ID.
+type ProjectionDiff struct {
+	Added     []string `json:"added"`
+	Removed   []string `json:"removed"`
+	Changed   []string `json:"changed"`
+	Unchanged int      `json:"unchanged"`
+}
+
+// OutboxRepository gives the outbox relay access to queued messages.
+type OutboxRepository = outbox.Repository
+
 type Publisher interface {
 	PublishEvent(ctx context.Context, topic string, key string, event interface{}) error
+	outbox.Publisher
+	Close() error
 }
 
 type Subscriber interface {
 	Consume(ctx context.Context, topic string, groupID string, handler func(context.Context, []byte) error) error
+	// Tail delivers the messages published to topic from now on, outside any
+	// consumer group, so every caller sees every message. Failed messages are
+	// skipped rather than retried.
+	Tail(ctx context.Context, topic string, handler func(context.Context, []byte) error) error
 }
@@ -0,0 +1,86 @@
+package domain
+
+import "time"
+
+// Checkout saga steps, in the order the orchestrator runs them.
+const (
+	SagaStepReserveInventory = "reserve_inventory"
+	SagaStepChargePayment    = "charge_payment"
+	SagaStepPlaceOrder       = "place_order"
+	SagaStepConfirmOrder     = "confirm_order"
+)
+
+// Checkout saga statuses.
+const (
+	SagaStatusRunning      = "running"
+	SagaStatusCompensating = "compensating"
+	SagaStatusCompleted    = "completed"
+	SagaStatusFailed       = "failed"
+)
+
+// SagaReservation records a single inventory reservation made by the saga so
+// that it can be released again during compensation.
+type SagaReservation struct {
+	ProductID string `json:"product_id" bson:"product_id"`
+	Quantity  int    `json:"quantity" bson:"quantity"`
+	Released  bool   `json:"released" bson:"released"`
+}
+
+// CheckoutSaga is the persisted state of the checkout orchestration for one
+// order. It is saved after every step so an interrupted checkout can be
+// resumed or compensated after a restart.
+type CheckoutSaga struct {
+	OrderID           string            `json:"order_id" bson:"order_id"`
+	Step              string            `json:"step" bson:"step"`
+	CustomerID        string            `json:"customer_id,omitempty" bson:"customer_id,omitempty"`
+	CartID            string            `json:"cart_id,omitempty" bson:"cart_id,omitempty"`
+	Status            string            `json:"status" bson:"status"`
+	Items             []OrderItem       `json:"items" bson:"items"` // priced from the catalog
+	PricedAt          time.Time         `json:"priced_at" bson:"priced_at"`
+	Reservations      []SagaReservation `json:"reservations" bson:"reservations"`
+	InventoryReleased bool              `json:"inventory_released" bson:"inventory_released"`
+	TotalPrice        float64           `json:"total_price" bson:"total_price"`
+	Currency          string            `json:"currency" bson:"currency"`
+	ChargedAmount     Money             `json:"charged_amount" bson:"charged_amount"`
+	TransactionID     string            `json:"transaction_id,omitempty" bson:"transaction_id,omitempty"`
+	PaymentRefunded   bool              `json:"payment_refunded" bson:"payment_refunded"`
+	FailureReason     string            `json:"failure_reason,omitempty" bson:"failure_reason,omitempty"`
+	CreatedAt         time.Time         `json:"created_at" bson:"created_at"`
+	UpdatedAt         time.Time         `json:"updated_at" bson:"updated_at"`
+
+	// CreditCard and PaymentToken are only held in memory and never persisted:
+	// a saga resumed after a restart before charging cannot charge and is
+	// compensated.
+	CreditCard   *CreditCardInfo `json:"-" bson:"-"`
+	PaymentToken string          `json:"-" bson:"-"`
+}
+
+// NewCheckoutSaga starts a saga for the given order at the first step. items
+// must already carry their catalog prices, looked up at pricedAt.
+func NewCheckoutSaga(orderID string, items []OrderItem, pricedAt time.Time) *CheckoutSaga {
+	now := time.Now()
+	return &CheckoutSaga{
+		OrderID:   orderID,
+		Step:      SagaStepReserveInventory,
+		Status:    SagaStatusRunning,
+		Items:     items,
+		PricedAt:  pricedAt,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+}
+
+// IsReserved reports whether the saga already holds a reservation for the product.
+func (s *CheckoutSaga) IsReserved(productID string) bool {
+	for _, r := range s.Reservations {
+		if r.ProductID == productID {
+			return true
+		}
+	}
+	return false
+}
+
+// IsTerminal reports whether the saga has finished, successfully or not.
+func (s *CheckoutSaga) IsTerminal() bool {
+	return s.Status == SagaStatusCompleted || s.Status == SagaStatusFailed
+}
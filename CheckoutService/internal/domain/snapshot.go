@@ -0,0 +1,25 @@
+package domain
+
+import "github.com/egannguyen/go-kafka-ecommerce/eventsourcing"
+
+// Snapshot schema versions. Bump the version of an aggregate whenever its
+// shape changes: snapshots with an older schema are ignored, the stream is
+// replayed in full and a fresh snapshot is written.
+const (
+	OrderSnapshotSchemaVersion = 1
+)
+
+// Snapshot is the serialized state of an aggregate at a given stream version.
+type Snapshot = eventsourcing.Snapshot
+
+// SnapshotPolicy maps a stream type to the number of events that may be
+// replayed on load before a new snapshot is taken.
+type SnapshotPolicy = eventsourcing.SnapshotPolicy
+
+// SnapshotAggregate is an aggregate that can be restored from a JSON snapshot
+// and brought up to date by replaying the remaining events.
+type SnapshotAggregate interface {
+	GetAggregateID() string
+	GetVersion() int
+	Rehydrate(records []EventRecord) error
+}
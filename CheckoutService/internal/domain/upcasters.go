@@ -0,0 +1,32 @@
+package domain
+
+import "encoding/json"
+
+// SchemaVersion 2 of OrderPlaced added BaseAmount and ChargedAmount.
+func (e OrderPlaced) SchemaVersion() int { return 2 }
+
+// upcastOrderPlacedV1 fills the amounts of orders placed before payments were
+// charged in the customer's currency: the total was charged in BaseCurrency.
+func upcastOrderPlacedV1(payload []byte) ([]byte, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(payload, &fields); err != nil {
+		return nil, err
+	}
+	if _, ok := fields["base_amount"]; ok {
+		return payload, nil
+	}
+
+	var total float64
+	if raw, ok := fields["total_price"]; ok {
+		if err := json.Unmarshal(raw, &total); err != nil {
+			return nil, err
+		}
+	}
+	amount, err := json.Marshal(NewMoney(BaseCurrency, total))
+	if err != nil {
+		return nil, err
+	}
+	fields["base_amount"] = amount
+	fields["charged_amount"] = amount
+	return json.Marshal(fields)
+}
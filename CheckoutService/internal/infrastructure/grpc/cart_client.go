@@ -0,0 +1,86 @@
+package grpc
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/egannguyen/go-kafka-ecommerce/checkout-service/internal/delivery/grpc/pb"
+	"github.com/egannguyen/go-kafka-ecommerce/checkout-service/internal/domain"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+type cartServiceClient struct {
+	client pb.CartServiceClient
+}
+
+func NewCartServiceClient(addr string) (domain.CartService, error) {
+	conn, err := grpc.Dial(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to cart service: %w", err)
+	}
+
+	client := pb.NewCartServiceClient(conn)
+	return &cartServiceClient{client: client}, nil
+}
+
+func (s *cartServiceClient) GetCart(ctx context.Context, cartID string) (*domain.Cart, error) {
+	resp, err := s.client.GetCart(ctx, &pb.GetCartRequest{CartId: cartID})
+	if err != nil {
+		return nil, err
+	}
+	return toCart(resp), nil
+}
+
+func (s *cartServiceClient) AddItem(ctx context.Context, customerID, cartID string, item domain.OrderItem) (*domain.Cart, error) {
+	resp, err := s.client.AddItem(ctx, &pb.AddItemRequest{
+		CartId:     cartID,
+		CustomerId: customerID,
+		ProductId:  item.ProductID,
+		Quantity:   int32(item.Quantity),
+		Price:      float32(item.Price),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return toCart(resp), nil
+}
+
+func (s *cartServiceClient) RemoveItem(ctx context.Context, customerID, cartID, productID string) (*domain.Cart, error) {
+	resp, err := s.client.RemoveItem(ctx, &pb.RemoveItemRequest{
+		CartId:     cartID,
+		CustomerId: customerID,
+		ProductId:  productID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return toCart(resp), nil
+}
+
+func (s *cartServiceClient) EmptyCart(ctx context.Context, customerID, cartID string) (*domain.Cart, error) {
+	resp, err := s.client.EmptyCart(ctx, &pb.EmptyCartRequest{
+		CartId:     cartID,
+		CustomerId: customerID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return toCart(resp), nil
+}
+
+func toCart(resp *pb.Cart) *domain.Cart {
+	items := make([]domain.OrderItem, 0, len(resp.Items))
+	for _, item := range resp.Items {
+		items = append(items, domain.OrderItem{
+			ProductID: item.ProductId,
+			Price:     float64(item.Price),
+			Quantity:  int(item.Quantity),
+		})
+	}
+	return &domain.Cart{
+		ID:         resp.CartId,
+		CustomerID: resp.CustomerId,
+		Items:      items,
+	}
+}
@@ -0,0 +1,65 @@
+package grpc
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/egannguyen/go-kafka-ecommerce/checkout-service/internal/delivery/grpc/pb"
+	"github.com/egannguyen/go-kafka-ecommerce/checkout-service/internal/domain"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+)
+
+type inventoryServiceClient struct {
+	client pb.InventoryServiceClient
+}
+
+func NewInventoryServiceClient(addr string) (domain.InventoryService, error) {
+	conn, err := grpc.Dial(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to inventory service: %w", err)
+	}
+
+	client := pb.NewInventoryServiceClient(conn)
+	return &inventoryServiceClient{client: client}, nil
+}
+
+func (s *inventoryServiceClient) Reserve(ctx context.Context, orderID string, items []domain.OrderItem) error {
+	req := &pb.ReserveRequest{OrderId: orderID}
+	for _, item := range items {
+		req.Items = append(req.Items, &pb.ReservationItem{
+			ProductId: item.ProductID,
+			Quantity:  int32(item.Quantity),
+		})
+	}
+
+	if _, err := s.client.Reserve(ctx, req); err != nil {
+		if status.Code(err) == codes.FailedPrecondition {
+			return fmt.Errorf("%w: %s", domain.ErrInsufficientStock, status.Convert(err).Message())
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *inventoryServiceClient) Hold(ctx context.Context, orderID string, productIDs []string) error {
+	_, err := s.client.Hold(ctx, &pb.HoldRequest{OrderId: orderID, ProductIds: productIDs})
+	return err
+}
+
+func (s *inventoryServiceClient) Release(ctx context.Context, orderID string, productIDs []string) error {
+	_, err := s.client.Release(ctx, &pb.ReleaseRequest{OrderId: orderID, ProductIds: productIDs})
+	return err
+}
+
+func (s *inventoryServiceClient) Commit(ctx context.Context, orderID string, productIDs []string) error {
+	_, err := s.client.Commit(ctx, &pb.CommitRequest{OrderId: orderID, ProductIds: productIDs})
+	return err
+}
+
+func (s *inventoryServiceClient) Restock(ctx context.Context, orderID string, productIDs []string, reason string) error {
+	_, err := s.client.Restock(ctx, &pb.RestockRequest{OrderId: orderID, ProductIds: productIDs, Reason: reason})
+	return err
+}
@@ -44,3 +44,40 @@ func (s *paymentServiceClient) Charge(ctx context.Context, amount domain.Money,
 
 	return resp.TransactionId, nil
 }
+
+func (s *paymentServiceClient) ChargeToken(ctx context.Context, amount domain.Money, token string) (string, error) {
+	resp, err := s.client.Charge(ctx, &pb.ChargeRequest{
+		Amount: &pb.Money{
+			CurrencyCode: amount.CurrencyCode,
+			Units:        amount.Units,
+			Nanos:        amount.Nanos,
+		},
+		PaymentToken: token,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return resp.TransactionId, nil
+}
+
+func (s *paymentServiceClient) Tokenize(ctx context.Context, card domain.CreditCardInfo) (string, error) {
+	resp, err := s.client.Tokenize(ctx, &pb.TokenizeRequest{
+		CreditCard: &pb.CreditCardInfo{
+			Number:          card.Number,
+			Cvv:             card.CVV,
+			ExpirationMonth: card.ExpirationMonth,
+			ExpirationYear:  card.ExpirationYear,
+		},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return resp.PaymentToken, nil
+}
+
+func (s *paymentServiceClient) Refund(ctx context.Context, transactionID string) error {
+	_, err := s.client.Refund(ctx, &pb.RefundRequest{TransactionId: transactionID})
+	return err
+}
@@ -7,7 +7,9 @@ import (
 	"github.com/egannguyen/go-kafka-ecommerce/checkout-service/internal/delivery/grpc/pb"
 	"github.com/egannguyen/go-kafka-ecommerce/checkout-service/internal/domain"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
 )
 
 type productServiceClient struct {
@@ -27,9 +29,12 @@ func NewProductServiceClient(addr string) (domain.ProductService, error) {
 func (s *productServiceClient) GetProduct(ctx context.Context, id string) (*domain.Product, error) {
 	resp, err := s.client.GetProduct(ctx, &pb.GetProductRequest{Id: id})
 	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return nil, nil
+		}
 		return nil, err
 	}
-	if resp == nil {
+	if resp == nil || resp.Id == "" {
 		return nil, nil
 	}
 
@@ -3,61 +3,440 @@ package kafka
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
+	"strconv"
+	"sync"
+	"time"
 
 	"github.com/egannguyen/go-kafka-ecommerce/checkout-service/internal/domain"
+	"github.com/egannguyen/go-kafka-ecommerce/eventsourcing/outbox"
 	kafkaGo "github.com/segmentio/kafka-go"
 )
 
+// Headers attached to messages forwarded to retry and dead-letter topics.
+const (
+	HeaderOriginalTopic = "x-original-topic"
+	HeaderGroupID       = "x-group-id"
+	HeaderAttempt       = "x-attempt"
+	HeaderError         = "x-error"
+	HeaderFailedAt      = "x-failed-at"
+	HeaderRetryAt       = "x-retry-at"
+)
+
+// RetryPolicy controls how Consume handles messages whose handler fails.
+// A failed message is forwarded to "<topic>.<groupID>.retry.<n>" for each of
+// the RetryAttempts levels, waiting an exponentially growing delay before each
+// redelivery, and finally to "<topic>.<groupID>.dlq". The topics are per
+// consumer group, so a failure in one group is never redelivered to another
+// group reading the same source topic.
+type RetryPolicy struct {
+	RetryAttempts  int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+}
+
+// DefaultRetryPolicy retries three times after 1s, 2s and 4s.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		RetryAttempts:  3,
+		InitialBackoff: time.Second,
+		MaxBackoff:     time.Minute,
+	}
+}
+
+// withDefaults replaces the settings of p that would make the retry loops spin
+// or misbehave: a non-positive backoff takes its default, a maximum below the
+// initial backoff is raised to it and negative attempts become zero, sending
+// failed messages straight to the dead-letter topic.
+func (p RetryPolicy) withDefaults() RetryPolicy {
+	def := DefaultRetryPolicy()
+	if p.RetryAttempts < 0 {
+		p.RetryAttempts = 0
+	}
+	if p.InitialBackoff <= 0 {
+		p.InitialBackoff = def.InitialBackoff
+	}
+	if p.MaxBackoff <= 0 {
+		p.MaxBackoff = def.MaxBackoff
+	}
+	if p.MaxBackoff < p.InitialBackoff {
+		p.MaxBackoff = p.InitialBackoff
+	}
+	return p
+}
+
+// backoff returns the delay before the given retry level (1-based).
+func (p RetryPolicy) backoff(level int) time.Duration {
+	d := p.InitialBackoff
+	for i := 1; i < level; i++ {
+		d *= 2
+		if d >= p.MaxBackoff {
+			return p.MaxBackoff
+		}
+	}
+	return d
+}
+
+func RetryTopic(topic, groupID string, level int) string {
+	return fmt.Sprintf("%s.%s.retry.%d", topic, groupID, level)
+}
+
+func DeadLetterTopic(topic, groupID string) string {
+	return fmt.Sprintf("%s.%s.dlq", topic, groupID)
+}
+
+// WriterConfig tunes the broker's shared producer.
+type WriterConfig struct {
+	BatchSize    int           // Max messages buffered per partition before a flush
+	BatchBytes   int64         // Max bytes per request
+	Linger       time.Duration // Max time to wait for a batch to fill
+	Compression  string        // "none", "gzip", "snappy", "lz4" or "zstd"
+	RequiredAcks int           // -1 (all replicas), 0 (none) or 1 (leader)
+}
+
+// DefaultWriterConfig favours latency: small linger, leader acks, snappy.
+func DefaultWriterConfig() WriterConfig {
+	return WriterConfig{
+		BatchSize:    100,
+		BatchBytes:   1 << 20,
+		Linger:       10 * time.Millisecond,
+		Compression:  "snappy",
+		RequiredAcks: int(kafkaGo.RequireOne),
+	}
+}
+
+func parseCompression(name string) (kafkaGo.Compression, error) {
+	switch name {
+	case "", "none":
+		return 0, nil
+	case "gzip":
+		return kafkaGo.Gzip, nil
+	case "snappy":
+		return kafkaGo.Snappy, nil
+	case "lz4":
+		return kafkaGo.Lz4, nil
+	case "zstd":
+		return kafkaGo.Zstd, nil
+	default:
+		return 0, fmt.Errorf("unsupported kafka compression: %s", name)
+	}
+}
+
 type kafkaBroker struct {
 	brokers []string
+	policy  RetryPolicy
+	writer  *kafkaGo.Writer
 }
 
-func NewKafkaBroker(brokers []string) (domain.Publisher, domain.Subscriber) {
-	kb := &kafkaBroker{brokers: brokers}
-	return kb, kb
-}
+// NewKafkaBroker creates a broker whose single multi-topic writer is shared by
+// every publish; the topic is set per message. Close must be called on
+// shutdown to flush buffered messages. Unusable policy settings fall back to
+// those of DefaultRetryPolicy.
+func NewKafkaBroker(brokers []string, cfg WriterConfig, policy RetryPolicy) (domain.Publisher, domain.Subscriber, error) {
+	compression, err := parseCompression(cfg.Compression)
+	if err != nil {
+		return nil, nil, err
+	}
 
-func (k *kafkaBroker) PublishEvent(ctx context.Context, topic string, key string, event interface{}) error {
-	w := &kafkaGo.Writer{
-		Addr:     kafkaGo.TCP(k.brokers...),
-		Topic:    topic,
-		Balancer: &kafkaGo.LeastBytes{},
+	writer := &kafkaGo.Writer{
+		Addr:                   kafkaGo.TCP(brokers...),
+		Balancer:               &kafkaGo.Hash{},
+		BatchSize:              cfg.BatchSize,
+		BatchBytes:             cfg.BatchBytes,
+		BatchTimeout:           cfg.Linger,
+		Compression:            compression,
+		RequiredAcks:           kafkaGo.RequiredAcks(cfg.RequiredAcks),
+		AllowAutoTopicCreation: true,
 	}
-	defer w.Close()
 
+	kb := &kafkaBroker{brokers: brokers, policy: policy.withDefaults(), writer: writer}
+	return kb, kb, nil
+}
+
+func (k *kafkaBroker) PublishEvent(ctx context.Context, topic string, key string, event interface{}) error {
 	payload, err := json.Marshal(event)
 	if err != nil {
 		return fmt.Errorf("failed to marshal event: %w", err)
 	}
 
-	return w.WriteMessages(ctx, kafkaGo.Message{
+	return k.write(ctx, topic, kafkaGo.Message{
 		Key:   []byte(key),
 		Value: payload,
 	})
 }
 
+// PublishBatch writes every message with one WriteMessages call. kafka-go
+// reports per-message failures as WriteErrors, returned as PublishErrors.
+func (k *kafkaBroker) PublishBatch(ctx context.Context, messages []domain.OutboxMessage) error {
+	msgs := make([]kafkaGo.Message, len(messages))
+	for i, m := range messages {
+		msgs[i] = kafkaGo.Message{Topic: m.Topic, Key: []byte(m.Key), Value: m.Payload}
+	}
+
+	err := k.writer.WriteMessages(ctx, msgs...)
+	var writeErrs kafkaGo.WriteErrors
+	if errors.As(err, &writeErrs) {
+		return outbox.PublishErrors(writeErrs)
+	}
+	return err
+}
+
+func (k *kafkaBroker) write(ctx context.Context, topic string, msg kafkaGo.Message) error {
+	msg.Topic = topic
+	return k.writer.WriteMessages(ctx, msg)
+}
+
+// Close flushes pending messages and closes the shared writer.
+func (k *kafkaBroker) Close() error {
+	return k.writer.Close()
+}
+
+// Consume reads topic with the given consumer group until ctx is cancelled.
+// Offsets are committed only once a message was handled successfully or
+// handed off to a retry or dead-letter topic. Retry topics are consumed by the
+// same call, under "<groupID>.retry.<n>" consumer groups.
 func (k *kafkaBroker) Consume(ctx context.Context, topic string, groupID string, handler func(ctx context.Context, payload []byte) error) error {
+	var wg sync.WaitGroup
+	for level := 1; level <= k.policy.RetryAttempts; level++ {
+		wg.Add(1)
+		go func(level int) {
+			defer wg.Done()
+			k.consumeLevel(ctx, topic, groupID, level, handler)
+		}(level)
+	}
+
+	k.consumeLevel(ctx, topic, groupID, 0, handler)
+	wg.Wait()
+	return nil
+}
+
+// consumeLevel consumes one topic of groupID's retry chain; level 0 is the original topic.
+func (k *kafkaBroker) consumeLevel(ctx context.Context, originalTopic, groupID string, level int, handler func(ctx context.Context, payload []byte) error) {
+	topic, levelGroupID := originalTopic, groupID
+	if level > 0 {
+		topic = RetryTopic(originalTopic, groupID, level)
+		levelGroupID = fmt.Sprintf("%s.retry.%d", groupID, level)
+	}
+
 	reader := kafkaGo.NewReader(kafkaGo.ReaderConfig{
 		Brokers: k.brokers,
 		Topic:   topic,
-		GroupID: groupID,
+		GroupID: levelGroupID,
 	})
 	defer reader.Close()
 
+	k.consume(ctx, reader, originalTopic, groupID, level, handler)
+}
+
+// groupReader is the part of a consumer group reader consume needs.
+type groupReader interface {
+	FetchMessage(ctx context.Context) (kafkaGo.Message, error)
+	CommitMessages(ctx context.Context, msgs ...kafkaGo.Message) error
+}
+
+// consume handles the messages of reader until ctx is cancelled. A message's
+// offset is committed once its handler succeeded or it was forwarded.
+func (k *kafkaBroker) consume(ctx context.Context, reader groupReader, originalTopic, groupID string, level int, handler func(ctx context.Context, payload []byte) error) {
+	topic := originalTopic
+	if level > 0 {
+		topic = RetryTopic(originalTopic, groupID, level)
+	}
+
 	for {
-		msg, err := reader.ReadMessage(ctx)
+		msg, err := reader.FetchMessage(ctx)
 		if err != nil {
 			if ctx.Err() != nil {
-				return nil
+				return
 			}
 			slog.Error("Error reading message", "topic", topic, "err", err)
 			continue
 		}
 
+		if level > 0 && !waitUntil(ctx, retryAt(msg)) {
+			return
+		}
+
 		if err := handler(ctx, msg.Value); err != nil {
-			slog.Error("Error handling message", "topic", topic, "err", err)
+			if ctx.Err() != nil {
+				return
+			}
+			slog.Error("Error handling message", "topic", topic, "attempt", level+1, "err", err)
+			if !k.forwardUntilDone(ctx, originalTopic, groupID, topic, msg, level+1, err) {
+				return
+			}
+		}
+
+		if err := reader.CommitMessages(ctx, msg); err != nil {
+			slog.Error("Failed to commit offset", "topic", topic, "offset", msg.Offset, "err", err)
 		}
 	}
 }
+
+// Tail reads every partition of topic from its latest offset until ctx is
+// cancelled. No offsets are committed and no retry or dead-letter topics are
+// involved: a message whose handler fails is logged and skipped. Partitions
+// added after Tail started are not read.
+func (k *kafkaBroker) Tail(ctx context.Context, topic string, handler func(ctx context.Context, payload []byte) error) error {
+	partitions, err := k.partitions(ctx, topic)
+	if err != nil {
+		return err
+	}
+
+	var wg sync.WaitGroup
+	for _, p := range partitions {
+		wg.Add(1)
+		go func(partition int) {
+			defer wg.Done()
+			k.tailPartition(ctx, topic, partition, handler)
+		}(p.ID)
+	}
+	wg.Wait()
+	return nil
+}
+
+// partitions looks up the partitions of topic, retrying until the topic
+// exists or ctx is cancelled.
+func (k *kafkaBroker) partitions(ctx context.Context, topic string) ([]kafkaGo.Partition, error) {
+	for {
+		partitions, err := k.readPartitions(ctx, topic)
+		if err == nil && len(partitions) > 0 {
+			return partitions, nil
+		}
+		slog.Warn("Waiting for topic partitions", "topic", topic, "err", err)
+		if !waitUntil(ctx, time.Now().Add(5*time.Second)) {
+			return nil, ctx.Err()
+		}
+	}
+}
+
+func (k *kafkaBroker) readPartitions(ctx context.Context, topic string) ([]kafkaGo.Partition, error) {
+	var lastErr error
+	for _, broker := range k.brokers {
+		conn, err := kafkaGo.DialContext(ctx, "tcp", broker)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		partitions, err := conn.ReadPartitions(topic)
+		conn.Close()
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		return partitions, nil
+	}
+	return nil, fmt.Errorf("failed to read partitions of %s: %w", topic, lastErr)
+}
+
+func (k *kafkaBroker) tailPartition(ctx context.Context, topic string, partition int, handler func(ctx context.Context, payload []byte) error) {
+	reader := kafkaGo.NewReader(kafkaGo.ReaderConfig{
+		Brokers:   k.brokers,
+		Topic:     topic,
+		Partition: partition,
+	})
+	defer reader.Close()
+
+	if err := reader.SetOffset(kafkaGo.LastOffset); err != nil {
+		slog.Error("Failed to seek to the latest offset", "topic", topic, "partition", partition, "err", err)
+		return
+	}
+
+	for {
+		msg, err := reader.ReadMessage(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			slog.Error("Error reading message", "topic", topic, "partition", partition, "err", err)
+			continue
+		}
+
+		if err := handler(ctx, msg.Value); err != nil {
+			slog.Warn("Skipping message", "topic", topic, "partition", partition, "offset", msg.Offset, "err", err)
+		}
+	}
+}
+
+// forwardUntilDone forwards a failed message, retrying with backoff until the
+// forward succeeds. The reader must not move past the message before then, or
+// it would be lost once a later offset is committed. It reports false if ctx
+// was cancelled first, leaving the offset uncommitted.
+func (k *kafkaBroker) forwardUntilDone(ctx context.Context, originalTopic, groupID, topic string, msg kafkaGo.Message, nextLevel int, handlerErr error) bool {
+	delay := k.policy.InitialBackoff
+	for {
+		err := k.forward(ctx, originalTopic, groupID, msg, nextLevel, handlerErr)
+		if err == nil {
+			return true
+		}
+		if ctx.Err() != nil {
+			return false
+		}
+		slog.Error("Failed to forward message for retry", "topic", topic, "offset", msg.Offset, "retry_in", delay, "err", err)
+
+		if !waitUntil(ctx, time.Now().Add(delay)) {
+			return false
+		}
+		if delay *= 2; delay > k.policy.MaxBackoff {
+			delay = k.policy.MaxBackoff
+		}
+	}
+}
+
+// forward sends a failed message to groupID's next retry level, or to its
+// dead-letter topic once all retry levels are exhausted.
+func (k *kafkaBroker) forward(ctx context.Context, originalTopic, groupID string, msg kafkaGo.Message, nextLevel int, handlerErr error) error {
+	now := time.Now()
+	target := DeadLetterTopic(originalTopic, groupID)
+	headers := []kafkaGo.Header{
+		{Key: HeaderOriginalTopic, Value: []byte(originalTopic)},
+		{Key: HeaderGroupID, Value: []byte(groupID)},
+		{Key: HeaderAttempt, Value: []byte(strconv.Itoa(nextLevel))},
+		{Key: HeaderError, Value: []byte(handlerErr.Error())},
+		{Key: HeaderFailedAt, Value: []byte(now.Format(time.RFC3339Nano))},
+	}
+
+	if nextLevel <= k.policy.RetryAttempts {
+		target = RetryTopic(originalTopic, groupID, nextLevel)
+		at := now.Add(k.policy.backoff(nextLevel))
+		headers = append(headers, kafkaGo.Header{Key: HeaderRetryAt, Value: []byte(at.Format(time.RFC3339Nano))})
+	} else {
+		slog.Warn("Sending message to dead-letter topic", "topic", target, "attempts", nextLevel)
+	}
+
+	return k.write(ctx, target, kafkaGo.Message{
+		Key:     msg.Key,
+		Value:   msg.Value,
+		Headers: headers,
+	})
+}
+
+func retryAt(msg kafkaGo.Message) time.Time {
+	for _, h := range msg.Headers {
+		if h.Key == HeaderRetryAt {
+			t, err := time.Parse(time.RFC3339Nano, string(h.Value))
+			if err == nil {
+				return t
+			}
+		}
+	}
+	return time.Time{}
+}
+
+// waitUntil sleeps until t and reports false if ctx was cancelled first.
+func waitUntil(ctx context.Context, t time.Time) bool {
+	d := time.Until(t)
+	if d <= 0 {
+		return true
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
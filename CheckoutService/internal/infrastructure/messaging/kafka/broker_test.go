@@ -0,0 +1,518 @@
+package kafka
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"slices"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/egannguyen/go-kafka-ecommerce/checkout-service/internal/domain"
+	"github.com/egannguyen/go-kafka-ecommerce/eventsourcing/outbox"
+	kafkaGo "github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/protocol"
+	"github.com/segmentio/kafka-go/protocol/apiversions"
+	"github.com/segmentio/kafka-go/protocol/metadata"
+	"github.com/segmentio/kafka-go/protocol/produce"
+)
+
+// fakeBroker is a single-node Kafka broker on a loopback port. It answers the
+// API versions, metadata and produce requests of a writer and acknowledges
+// every record batch without storing it, so benchmarks measure the client.
+// Topics are created when metadata is first requested for them. With record
+// set, the topic and headers of every produced record are appended to log.
+type fakeBroker struct {
+	listener   net.Listener
+	partitions int
+	record     bool
+
+	mu       sync.Mutex
+	topics   []string
+	produced int // produce requests received
+	log      []loggedEvent
+}
+
+// loggedEvent is a record produced to the broker, or an event the test logged
+// next to them to check the order of both.
+type loggedEvent struct {
+	name    string // topic of a produced record
+	headers map[string]string
+}
+
+func newFakeBroker(tb testing.TB, partitions int) *fakeBroker {
+	tb.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		tb.Fatalf("failed to listen: %v", err)
+	}
+
+	b := &fakeBroker{listener: listener, partitions: partitions}
+	go b.serve()
+	tb.Cleanup(func() { listener.Close() })
+	return b
+}
+
+func (b *fakeBroker) Addr() string {
+	return b.listener.Addr().String()
+}
+
+func (b *fakeBroker) producedRequests() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.produced
+}
+
+func (b *fakeBroker) logEvent(e loggedEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.log = append(b.log, e)
+}
+
+func (b *fakeBroker) events() []loggedEvent {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]loggedEvent(nil), b.log...)
+}
+
+// logRecords logs the records of a produce request.
+func (b *fakeBroker) logRecords(req *produce.Request) {
+	for _, topic := range req.Topics {
+		for _, partition := range topic.Partitions {
+			for {
+				rec, err := partition.RecordSet.Records.ReadRecord()
+				if err != nil {
+					break
+				}
+				headers := make(map[string]string, len(rec.Headers))
+				for _, h := range rec.Headers {
+					headers[h.Key] = string(h.Value)
+				}
+				b.logEvent(loggedEvent{name: topic.Topic, headers: headers})
+			}
+		}
+	}
+}
+
+func (b *fakeBroker) serve() {
+	for {
+		conn, err := b.listener.Accept()
+		if err != nil {
+			return
+		}
+		go b.handle(conn)
+	}
+}
+
+func (b *fakeBroker) handle(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	w := bufio.NewWriter(conn)
+
+	for {
+		apiVersion, correlationID, _, req, err := protocol.ReadRequest(r)
+		if err != nil {
+			return
+		}
+
+		var res protocol.Message
+		switch req := req.(type) {
+		case *apiversions.Request:
+			res = &apiversions.Response{ApiKeys: []apiversions.ApiKeyResponse{
+				{ApiKey: int16(protocol.Produce), MinVersion: 0, MaxVersion: 8},
+				{ApiKey: int16(protocol.Metadata), MinVersion: 0, MaxVersion: 8},
+				{ApiKey: int16(protocol.ApiVersions), MinVersion: 0, MaxVersion: 2},
+			}}
+		case *metadata.Request:
+			res = b.metadata(req)
+		case *produce.Request:
+			b.mu.Lock()
+			b.produced++
+			b.mu.Unlock()
+			if b.record {
+				b.logRecords(req)
+			}
+			if req.Acks == 0 {
+				continue
+			}
+			res = produced(req)
+		default:
+			return
+		}
+
+		if err := protocol.WriteResponse(w, apiVersion, correlationID, res); err != nil {
+			return
+		}
+		if err := w.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+func (b *fakeBroker) metadata(req *metadata.Request) *metadata.Response {
+	host, portStr, _ := net.SplitHostPort(b.Addr())
+	port, _ := strconv.Atoi(portStr)
+
+	res := &metadata.Response{
+		Brokers: []metadata.ResponseBroker{{NodeID: 0, Host: host, Port: int32(port)}},
+	}
+	for _, topic := range b.createTopics(req.TopicNames) {
+		t := metadata.ResponseTopic{Name: topic}
+		for p := 0; p < b.partitions; p++ {
+			t.Partitions = append(t.Partitions, metadata.ResponsePartition{
+				PartitionIndex: int32(p),
+				ReplicaNodes:   []int32{0},
+				IsrNodes:       []int32{0},
+			})
+		}
+		res.Topics = append(res.Topics, t)
+	}
+	return res
+}
+
+// createTopics creates the missing topics of names and returns them, or every
+// topic if names is nil.
+func (b *fakeBroker) createTopics(names []string) []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if names == nil {
+		return append([]string(nil), b.topics...)
+	}
+	for _, name := range names {
+		if !slices.Contains(b.topics, name) {
+			b.topics = append(b.topics, name)
+		}
+	}
+	return names
+}
+
+func produced(req *produce.Request) *produce.Response {
+	res := &produce.Response{}
+	for _, topic := range req.Topics {
+		t := produce.ResponseTopic{Topic: topic.Topic}
+		for _, partition := range topic.Partitions {
+			t.Partitions = append(t.Partitions, produce.ResponsePartition{Partition: partition.Partition})
+		}
+		res.Topics = append(res.Topics, t)
+	}
+	return res
+}
+
+// publishWithWriterPerEvent is how PublishEvent worked before the broker owned
+// a shared writer: a writer is created and closed around every event.
+func publishWithWriterPerEvent(ctx context.Context, brokers []string, topic, key string, payload []byte) error {
+	w := &kafkaGo.Writer{
+		Addr:                   kafkaGo.TCP(brokers...),
+		Topic:                  topic,
+		Balancer:               &kafkaGo.LeastBytes{},
+		AllowAutoTopicCreation: true,
+	}
+	defer w.Close()
+	return w.WriteMessages(ctx, kafkaGo.Message{Key: []byte(key), Value: payload})
+}
+
+type benchEvent struct {
+	OrderID string  `json:"order_id"`
+	Total   float64 `json:"total_price"`
+}
+
+const benchParallelism = 16 // concurrent publishers per GOMAXPROCS
+
+// benchmarkPublish runs publish from a single publisher and from concurrent
+// publishers, as concurrent checkouts do.
+func benchmarkPublish(b *testing.B, publish func(key string) error) {
+	b.Run("sequential", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if err := publish(strconv.Itoa(i)); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("parallel", func(b *testing.B) {
+		var n atomic.Int64
+		b.SetParallelism(benchParallelism)
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				if err := publish(strconv.FormatInt(n.Add(1), 10)); err != nil {
+					b.Error(err)
+					return
+				}
+			}
+		})
+	})
+}
+
+func BenchmarkPublishWriterPerEvent(b *testing.B) {
+	broker := newFakeBroker(b, 3)
+	brokers := []string{broker.Addr()}
+	ctx := context.Background()
+
+	benchmarkPublish(b, func(key string) error {
+		payload, err := json.Marshal(benchEvent{OrderID: key, Total: 58.48})
+		if err != nil {
+			return err
+		}
+		return publishWithWriterPerEvent(ctx, brokers, "orders.placed", key, payload)
+	})
+}
+
+func BenchmarkPublishSharedWriter(b *testing.B) {
+	broker := newFakeBroker(b, 3)
+	ctx := context.Background()
+
+	publisher, _, err := NewKafkaBroker([]string{broker.Addr()}, DefaultWriterConfig(), DefaultRetryPolicy())
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer publisher.Close()
+
+	benchmarkPublish(b, func(key string) error {
+		return publisher.PublishEvent(ctx, "orders.placed", key, benchEvent{OrderID: key, Total: 58.48})
+	})
+}
+
+func TestPublishEventBatchesConcurrentEvents(t *testing.T) {
+	broker := newFakeBroker(t, 3)
+	ctx := context.Background()
+
+	publisher, _, err := NewKafkaBroker([]string{broker.Addr()}, DefaultWriterConfig(), DefaultRetryPolicy())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const events = 50
+	var wg sync.WaitGroup
+	errs := make(chan error, events)
+	for i := 0; i < events; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			event := benchEvent{OrderID: fmt.Sprintf("order-%d", i)}
+			errs <- publisher.PublishEvent(ctx, "orders.placed", event.OrderID, event)
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("PublishEvent: %v", err)
+		}
+	}
+	if err := publisher.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	if produced := broker.producedRequests(); produced == 0 || produced >= events {
+		t.Errorf("broker answered %d produce requests for %d events, want them batched", produced, events)
+	}
+	if err := publisher.PublishEvent(ctx, "orders.placed", "late", benchEvent{}); !errors.Is(err, io.ErrClosedPipe) {
+		t.Errorf("PublishEvent after Close = %v, want %v", err, io.ErrClosedPipe)
+	}
+}
+
+func TestPublishBatchWritesOnce(t *testing.T) {
+	broker := newFakeBroker(t, 3)
+	ctx := context.Background()
+
+	publisher, _, err := NewKafkaBroker([]string{broker.Addr()}, DefaultWriterConfig(), DefaultRetryPolicy())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	messages := make([]domain.OutboxMessage, 30)
+	for i := range messages {
+		messages[i] = domain.OutboxMessage{
+			ID:      fmt.Sprintf("m%d", i),
+			Topic:   "orders.placed",
+			Key:     fmt.Sprintf("order-%d", i%5),
+			Payload: []byte(`{}`),
+		}
+	}
+	if err := publisher.PublishBatch(ctx, messages); err != nil {
+		t.Fatalf("PublishBatch: %v", err)
+	}
+	// One produce request per partition at most.
+	if produced := broker.producedRequests(); produced == 0 || produced > 3 {
+		t.Errorf("broker answered %d produce requests for one batch, want 1 to 3", produced)
+	}
+
+	if err := publisher.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	var publishErrs outbox.PublishErrors
+	if err := publisher.PublishBatch(ctx, messages); errors.As(err, &publishErrs) || !errors.Is(err, io.ErrClosedPipe) {
+		t.Errorf("PublishBatch after Close = %v, want %v for the whole batch", err, io.ErrClosedPipe)
+	}
+}
+
+func TestRetryPolicyWithDefaults(t *testing.T) {
+	def := DefaultRetryPolicy()
+	tests := []struct {
+		name   string
+		policy RetryPolicy
+		want   RetryPolicy
+	}{
+		{"default", def, def},
+		{"zero value", RetryPolicy{}, RetryPolicy{RetryAttempts: 0, InitialBackoff: def.InitialBackoff, MaxBackoff: def.MaxBackoff}},
+		{"negative backoffs", RetryPolicy{RetryAttempts: 2, InitialBackoff: -time.Second, MaxBackoff: -time.Second}, RetryPolicy{RetryAttempts: 2, InitialBackoff: def.InitialBackoff, MaxBackoff: def.MaxBackoff}},
+		{"negative attempts", RetryPolicy{RetryAttempts: -1, InitialBackoff: time.Second, MaxBackoff: time.Minute}, RetryPolicy{RetryAttempts: 0, InitialBackoff: time.Second, MaxBackoff: time.Minute}},
+		{"max below initial", RetryPolicy{RetryAttempts: 3, InitialBackoff: 10 * time.Second, MaxBackoff: time.Second}, RetryPolicy{RetryAttempts: 3, InitialBackoff: 10 * time.Second, MaxBackoff: 10 * time.Second}},
+		{"zero max", RetryPolicy{RetryAttempts: 3, InitialBackoff: 2 * time.Minute}, RetryPolicy{RetryAttempts: 3, InitialBackoff: 2 * time.Minute, MaxBackoff: 2 * time.Minute}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.policy.withDefaults(); got != tt.want {
+				t.Errorf("withDefaults() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	policy := RetryPolicy{RetryAttempts: 6, InitialBackoff: time.Second, MaxBackoff: 10 * time.Second}
+	want := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second, 10 * time.Second, 10 * time.Second}
+	for level, w := range want {
+		if got := policy.backoff(level + 1); got != w {
+			t.Errorf("backoff(%d) = %v, want %v", level+1, got, w)
+		}
+	}
+}
+
+func TestRetryAndDeadLetterTopics(t *testing.T) {
+	if got := RetryTopic("orders.placed", "checkout", 2); got != "orders.placed.checkout.retry.2" {
+		t.Errorf("RetryTopic = %q", got)
+	}
+	if got := DeadLetterTopic("orders.placed", "checkout"); got != "orders.placed.checkout.dlq" {
+		t.Errorf("DeadLetterTopic = %q", got)
+	}
+}
+
+// fakeGroupReader hands out messages in order and logs commits to the broker,
+// next to the records forwarded to it. It blocks once the messages run out.
+type fakeGroupReader struct {
+	broker   *fakeBroker
+	messages []kafkaGo.Message
+	drained  chan struct{}
+}
+
+func (r *fakeGroupReader) FetchMessage(ctx context.Context) (kafkaGo.Message, error) {
+	if len(r.messages) == 0 {
+		close(r.drained)
+		<-ctx.Done()
+		return kafkaGo.Message{}, ctx.Err()
+	}
+	msg := r.messages[0]
+	r.messages = r.messages[1:]
+	return msg, nil
+}
+
+func (r *fakeGroupReader) CommitMessages(ctx context.Context, msgs ...kafkaGo.Message) error {
+	for _, msg := range msgs {
+		r.broker.logEvent(loggedEvent{name: fmt.Sprintf("commit %s@%d", msg.Topic, msg.Offset)})
+	}
+	return nil
+}
+
+func TestConsumeCommitsAfterForward(t *testing.T) {
+	failing := errors.New("handler failed")
+	tests := []struct {
+		name       string
+		level      int
+		handlerErr error
+		want       []string // forwarded topics and commits, in order
+	}{
+		{"handled", 0, nil, []string{"commit orders.placed@7"}},
+		{"first failure", 0, failing, []string{"orders.placed.checkout.retry.1", "commit orders.placed@7"}},
+		{"retry failure", 1, failing, []string{"orders.placed.checkout.retry.2", "commit orders.placed.checkout.retry.1@7"}},
+		{"last retry failure", 2, failing, []string{"orders.placed.checkout.dlq", "commit orders.placed.checkout.retry.2@7"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			broker := newFakeBroker(t, 1)
+			broker.record = true
+			policy := RetryPolicy{RetryAttempts: 2, InitialBackoff: time.Second, MaxBackoff: time.Minute}
+			_, sub, err := NewKafkaBroker([]string{broker.Addr()}, DefaultWriterConfig(), policy)
+			if err != nil {
+				t.Fatal(err)
+			}
+			k := sub.(*kafkaBroker)
+			defer k.Close()
+
+			topic := "orders.placed"
+			if tt.level > 0 {
+				topic = RetryTopic("orders.placed", "checkout", tt.level)
+			}
+			reader := &fakeGroupReader{
+				broker:   broker,
+				messages: []kafkaGo.Message{{Topic: topic, Offset: 7, Key: []byte("order-1"), Value: []byte(`{}`)}},
+				drained:  make(chan struct{}),
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				k.consume(ctx, reader, "orders.placed", "checkout", tt.level, func(context.Context, []byte) error { return tt.handlerErr })
+			}()
+			<-reader.drained
+			cancel()
+			<-done
+
+			var got []string
+			for _, e := range broker.events() {
+				got = append(got, e.name)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("events = %v, want %v", got, tt.want)
+			}
+
+			if tt.handlerErr == nil {
+				return
+			}
+			forwarded := broker.events()[0].headers
+			if forwarded[HeaderOriginalTopic] != "orders.placed" || forwarded[HeaderGroupID] != "checkout" {
+				t.Errorf("forwarded headers = %v", forwarded)
+			}
+			if forwarded[HeaderAttempt] != strconv.Itoa(tt.level+1) || forwarded[HeaderError] != failing.Error() {
+				t.Errorf("forwarded attempt and error = %q, %q", forwarded[HeaderAttempt], forwarded[HeaderError])
+			}
+			if _, ok := forwarded[HeaderRetryAt]; ok == (tt.level == policy.RetryAttempts) {
+				t.Errorf("forwarded %s = %v at level %d", HeaderRetryAt, ok, tt.level)
+			}
+		})
+	}
+}
+
+func TestConsumeLeavesOffsetWhenCancelledDuringHandler(t *testing.T) {
+	broker := newFakeBroker(t, 1)
+	broker.record = true
+	_, sub, err := NewKafkaBroker([]string{broker.Addr()}, DefaultWriterConfig(), DefaultRetryPolicy())
+	if err != nil {
+		t.Fatal(err)
+	}
+	k := sub.(*kafkaBroker)
+	defer k.Close()
+
+	reader := &fakeGroupReader{
+		broker:   broker,
+		messages: []kafkaGo.Message{{Topic: "orders.placed", Offset: 7}},
+		drained:  make(chan struct{}),
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	k.consume(ctx, reader, "orders.placed", "checkout", 0, func(ctx context.Context, _ []byte) error {
+		cancel()
+		return ctx.Err()
+	})
+
+	if events := broker.events(); len(events) != 0 {
+		t.Errorf("events = %v, want neither a forward nor a commit", events)
+	}
+}
@@ -0,0 +1,36 @@
+// Package persistence selects the storage backends of the service.
+package persistence
+
+import (
+	"fmt"
+
+	"github.com/egannguyen/go-kafka-ecommerce/checkout-service/internal/domain"
+	"github.com/egannguyen/go-kafka-ecommerce/checkout-service/internal/infrastructure/persistence/mongodb"
+	"github.com/egannguyen/go-kafka-ecommerce/checkout-service/internal/infrastructure/persistence/postgres"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Event store backends accepted by NewEventStore.
+const (
+	EventStoreMongoDB  = "mongodb"
+	EventStorePostgres = "postgres"
+)
+
+// NewEventStore returns the event store of the given backend together with
+// the outbox it writes to, which must live in the same database. MongoDB
+// requires a replica set for its transactions; PostgreSQL is connected to
+// with postgresURL.
+func NewEventStore(backend string, mongoDB *mongo.Database, postgresURL string) (domain.EventStore, domain.OutboxRepository, error) {
+	switch backend {
+	case EventStoreMongoDB:
+		return mongodb.NewEventStore(mongoDB), mongodb.NewOutboxRepository(mongoDB), nil
+	case EventStorePostgres:
+		db, err := postgres.InitDB(postgresURL)
+		if err != nil {
+			return nil, nil, err
+		}
+		return postgres.NewEventStore(db), postgres.NewOutboxRepository(db), nil
+	default:
+		return nil, nil, fmt.Errorf("unknown event store backend %q", backend)
+	}
+}
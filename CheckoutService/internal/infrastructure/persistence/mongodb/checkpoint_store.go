@@ -0,0 +1,43 @@
+package mongodb
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/egannguyen/go-kafka-ecommerce/checkout-service/internal/domain"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type checkpointStore struct {
+	db *mongo.Database
+}
+
+func NewCheckpointStore(db *mongo.Database) domain.CheckpointStore {
+	return &checkpointStore{db: db}
+}
+
+func (s *checkpointStore) Load(ctx context.Context, name string) (int64, error) {
+	var checkpoint struct {
+		Position int64 `bson:"position"`
+	}
+	err := s.db.Collection("checkpoints").FindOne(ctx, bson.M{"_id": name}).Decode(&checkpoint)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("failed to load checkpoint: %w", err)
+	}
+	return checkpoint.Position, nil
+}
+
+func (s *checkpointStore) Save(ctx context.Context, name string, position int64) error {
+	update := bson.M{"$set": bson.M{"position": position, "updated_at": time.Now()}}
+	_, err := s.db.Collection("checkpoints").UpdateByID(ctx, name, update, options.Update().SetUpsert(true))
+	if err != nil {
+		return fmt.Errorf("failed to save checkpoint: %w", err)
+	}
+	return nil
+}
@@ -6,6 +6,8 @@ import (
 	"log/slog"
 	"time"
 
+	"github.com/egannguyen/go-kafka-ecommerce/eventsourcing/mongostore"
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
@@ -25,6 +27,52 @@ func InitDB(uri string) (*mongo.Database, error) {
 	}
 
 	db := client.Database("ecommerce_checkout")
+
+	if err := createIndexes(ctx, db); err != nil {
+		return nil, fmt.Errorf("failed to create indexes: %w", err)
+	}
+
 	slog.Info("MongoDB connected for CheckoutService")
 	return db, nil
 }
+
+func createIndexes(ctx context.Context, db *mongo.Database) error {
+	if err := mongostore.EnsureIndexes(ctx, db); err != nil {
+		return err
+	}
+
+	_, err := db.Collection("outbox").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "dispatched_at", Value: 1}, {Key: "created_at", Value: 1}}},
+		{Keys: bson.D{{Key: "id", Value: 1}}, Options: options.Index().SetUnique(true)},
+	})
+	if err != nil {
+		return fmt.Errorf("outbox: %w", err)
+	}
+
+	if err := createOrderIndexes(ctx, db.Collection("orders")); err != nil {
+		return fmt.Errorf("orders: %w", err)
+	}
+
+	_, err = db.Collection("idempotency_keys").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "expires_at", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	})
+	if err != nil {
+		return fmt.Errorf("idempotency_keys: %w", err)
+	}
+
+	return nil
+}
+
+// createOrderIndexes indexes an orders projection collection for lookups by
+// ID and for List, whose filters all sort by (created_at, id) descending.
+func createOrderIndexes(ctx context.Context, coll *mongo.Collection) error {
+	newestFirst := bson.D{{Key: "created_at", Value: -1}, {Key: "id", Value: -1}}
+	_, err := coll.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "id", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: newestFirst},
+		{Keys: append(bson.D{{Key: "customer_id", Value: 1}}, newestFirst...)},
+		{Keys: append(bson.D{{Key: "status", Value: 1}}, newestFirst...)},
+	})
+	return err
+}
@@ -2,104 +2,43 @@ package mongodb
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
-	"time"
 
 	"github.com/egannguyen/go-kafka-ecommerce/checkout-service/internal/domain"
+	"github.com/egannguyen/go-kafka-ecommerce/eventsourcing/mongostore"
 	"github.com/google/uuid"
-	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
-	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+// eventStore is the shared Mongo event store plus the outbox of this service.
 type eventStore struct {
+	*mongostore.Store
 	db *mongo.Database
 }
 
 func NewEventStore(db *mongo.Database) domain.EventStore {
-	return &eventStore{db: db}
+	return &eventStore{Store: mongostore.New(db), db: db}
 }
 
-func (s *eventStore) SaveEvents(ctx context.Context, streamID string, streamType string, expectedVersion int, events []domain.Event) error {
-	if len(events) == 0 {
-		return nil
-	}
-
-	coll := s.db.Collection("events")
-
-	// Start session for transaction
-	session, err := s.db.Client().StartSession()
-	if err != nil {
-		return fmt.Errorf("failed to start session: %w", err)
-	}
-	defer session.EndSession(ctx)
-
-	_, err = session.WithTransaction(ctx, func(sessCtx mongo.SessionContext) (interface{}, error) {
-		// Check concurrency
-		var result struct {
-			Version int `bson:"version"`
-		}
-		opts := options.FindOne().SetSort(bson.M{"version": -1})
-		err := coll.FindOne(sessCtx, bson.M{"stream_id": streamID}, opts).Decode(&result)
-		
-		currentVersion := 0
-		if err == nil {
-			currentVersion = result.Version
-		} else if err != mongo.ErrNoDocuments {
-			return nil, fmt.Errorf("failed to get current stream version: %w", err)
-		}
-
-		if expectedVersion != -1 && currentVersion != expectedVersion {
-			return nil, fmt.Errorf("concurrency exception: expected version %d, got %d", expectedVersion, currentVersion)
-		}
-
-		var docs []interface{}
-		version := currentVersion
-		now := time.Now()
-
-		for _, event := range events {
-			version++
-			payload, err := json.Marshal(event)
-			if err != nil {
-				return nil, fmt.Errorf("failed to marshal event %s: %w", event.EventType(), err)
-			}
-
-			docs = append(docs, bson.M{
-				"id":          uuid.NewString(),
-				"stream_id":   streamID,
-				"stream_type": streamType,
-				"version":     version,
-				"event_type":  event.EventType(),
-				"payload":     payload,
-				"created_at":  now,
+// SaveEventsWithOutbox inserts an outbox message per event in the append transaction.
+func (s *eventStore) SaveEventsWithOutbox(ctx context.Context, streamID string, streamType string, expectedVersion int, events []domain.Event, topic string) error {
+	return s.Append(ctx, streamID, streamType, expectedVersion, events, func(sessCtx mongo.SessionContext, records []domain.EventRecord) error {
+		docs := make([]interface{}, 0, len(records))
+		for _, rec := range records {
+			docs = append(docs, domain.OutboxMessage{
+				ID:        uuid.NewString(),
+				Topic:     topic,
+				Key:       rec.StreamID,
+				Version:   rec.Version,
+				EventType: rec.EventType,
+				Payload:   rec.Payload,
+				CreatedAt: rec.CreatedAt,
 			})
 		}
 
-		_, err = coll.InsertMany(sessCtx, docs)
-		if err != nil {
-			return nil, fmt.Errorf("failed to insert events: %w", err)
+		if _, err := s.db.Collection("outbox").InsertMany(sessCtx, docs); err != nil {
+			return fmt.Errorf("failed to insert outbox messages: %w", err)
 		}
-
-		return nil, nil
+		return nil
 	})
-
-	return err
-}
-
-func (s *eventStore) LoadEvents(ctx context.Context, streamID string) ([]domain.EventRecord, error) {
-	coll := s.db.Collection("events")
-	opts := options.Find().SetSort(bson.M{"version": 1})
-	cursor, err := coll.Find(ctx, bson.M{"stream_id": streamID}, opts)
-	if err != nil {
-		return nil, fmt.Errorf("failed to load events: %w", err)
-	}
-	defer cursor.Close(ctx)
-
-	var events []domain.EventRecord
-	if err := cursor.All(ctx, &events); err != nil {
-		return nil, fmt.Errorf("failed to decode events: %w", err)
-	}
-
-	return events, nil
 }
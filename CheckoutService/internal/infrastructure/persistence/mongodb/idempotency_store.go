@@ -0,0 +1,72 @@
+package mongodb
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/egannguyen/go-kafka-ecommerce/checkout-service/internal/domain"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type idempotencyStore struct {
+	db *mongo.Database
+}
+
+func NewIdempotencyStore(db *mongo.Database) domain.IdempotencyStore {
+	return &idempotencyStore{db: db}
+}
+
+func (s *idempotencyStore) Claim(ctx context.Context, rec domain.IdempotencyRecord) (*domain.IdempotencyRecord, error) {
+	coll := s.db.Collection("idempotency_keys")
+	for {
+		_, err := coll.InsertOne(ctx, rec)
+		if err == nil {
+			return nil, nil
+		}
+		if !mongo.IsDuplicateKeyError(err) {
+			return nil, fmt.Errorf("failed to claim idempotency key: %w", err)
+		}
+
+		var existing domain.IdempotencyRecord
+		err = coll.FindOne(ctx, bson.M{"_id": rec.Key}).Decode(&existing)
+		if err == mongo.ErrNoDocuments {
+			continue // removed in the meantime
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to load idempotency key: %w", err)
+		}
+		if existing.ExpiresAt.After(time.Now()) {
+			return &existing, nil
+		}
+
+		// Expired but not yet removed by the TTL index.
+		_, err = coll.DeleteOne(ctx, bson.M{"_id": rec.Key, "expires_at": existing.ExpiresAt})
+		if err != nil {
+			return nil, fmt.Errorf("failed to remove expired idempotency key: %w", err)
+		}
+	}
+}
+
+func (s *idempotencyStore) Complete(ctx context.Context, key string, statusCode int, header map[string][]string, body []byte) error {
+	update := bson.M{"$set": bson.M{
+		"completed":   true,
+		"status_code": statusCode,
+		"header":      header,
+		"body":        body,
+	}}
+	_, err := s.db.Collection("idempotency_keys").UpdateByID(ctx, key, update)
+	if err != nil {
+		return fmt.Errorf("failed to complete idempotency key: %w", err)
+	}
+	return nil
+}
+
+func (s *idempotencyStore) Release(ctx context.Context, key string) error {
+	_, err := s.db.Collection("idempotency_keys").DeleteOne(ctx, bson.M{"_id": key})
+	if err != nil {
+		return fmt.Errorf("failed to release idempotency key: %w", err)
+	}
+	return nil
+}
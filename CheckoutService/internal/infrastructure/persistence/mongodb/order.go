@@ -2,7 +2,10 @@ package mongodb
 
 import (
 	"context"
+	"encoding/base64"
+	"encoding/json"
 	"fmt"
+	"time"
 
 	"github.com/egannguyen/go-kafka-ecommerce/checkout-service/internal/domain"
 	"go.mongodb.org/mongo-driver/bson"
@@ -11,54 +14,219 @@ import (
 )
 
 type orderRepository struct {
-	db *mongo.Database
+	db         *mongo.Database
+	collection string
 }
 
 func NewOrderRepository(db *mongo.Database) domain.OrderRepository {
-	return &orderRepository{db: db}
+	return &orderRepository{db: db, collection: "orders"}
 }
 
-func (r *orderRepository) UpdateOrderProjection(ctx context.Context, event interface{}) error {
-	coll := r.db.Collection("orders")
-
+// UpdateOrderProjection applies event, found at version of its order stream,
+// to the order. The order keeps the version of the last event applied, and
+// events that are not newer are ignored, so redelivered or out-of-order
+// events never roll the order back.
+func (r *orderRepository) UpdateOrderProjection(ctx context.Context, event interface{}, version int) error {
 	switch e := event.(type) {
 	case domain.OrderPlaced:
 		order := domain.Order{
-			ID:         e.OrderID,
-			TotalPrice: e.TotalPrice,
-			Status:     "placed",
-			CreatedAt:  e.PlacedAt,
-			Items:      e.Items,
-		}
-		opts := options.Update().SetUpsert(true)
-		_, err := coll.UpdateOne(ctx, bson.M{"id": e.OrderID}, bson.M{"$set": order}, opts)
-		if err != nil {
-			return fmt.Errorf("failed to upsert order projection: %w", err)
+			ID:            e.OrderID,
+			CustomerID:    e.CustomerID,
+			TotalPrice:    e.TotalPrice,
+			BaseAmount:    e.BaseAmount,
+			ChargedAmount: e.ChargedAmount,
+			TransactionID: e.TransactionID,
+			Status:        domain.OrderStatusPlaced,
+			CreatedAt:     e.PlacedAt,
+			Items:         e.Items,
+			Version:       version,
 		}
+		return r.applyIfNewer(ctx, e.OrderID, version, bson.M{"$set": order}, true)
 
 	case domain.OrderConfirmed:
-		_, err := coll.UpdateOne(ctx, bson.M{"id": e.OrderID}, bson.M{"$set": bson.M{"status": "confirmed"}})
-		if err != nil {
-			return fmt.Errorf("failed to confirm order projection: %w", err)
+		return r.setStatus(ctx, e.OrderID, version, bson.M{"status": domain.OrderStatusConfirmed})
+
+	case domain.OrderFailed:
+		update := bson.M{
+			"$set":         bson.M{"status": domain.OrderStatusFailed, "failure_reason": e.Reason, "version": version},
+			"$setOnInsert": bson.M{"created_at": e.FailedAt, "items": []domain.OrderItem{}},
 		}
+		return r.applyIfNewer(ctx, e.OrderID, version, update, true)
+
+	case domain.OrderCancelled:
+		return r.setStatus(ctx, e.OrderID, version, bson.M{"status": domain.OrderStatusCancelled})
+
+	case domain.OrderShipped:
+		return r.setStatus(ctx, e.OrderID, version, bson.M{
+			"status":          domain.OrderStatusShipped,
+			"carrier":         e.Carrier,
+			"tracking_number": e.TrackingNumber,
+		})
+
+	case domain.OrderDelivered:
+		return r.setStatus(ctx, e.OrderID, version, bson.M{"status": domain.OrderStatusDelivered})
+
+	case domain.OrderReturnRequested:
+		return r.setStatus(ctx, e.OrderID, version, bson.M{"status": domain.OrderStatusReturnRequested})
+
+	case domain.OrderRefunded:
+		return r.setStatus(ctx, e.OrderID, version, bson.M{"status": domain.OrderStatusRefunded})
 	}
 
 	return nil
 }
 
-func (r *orderRepository) FindRecent(ctx context.Context, limit int) ([]domain.Order, error) {
-	coll := r.db.Collection("orders")
-	opts := options.Find().SetSort(bson.M{"created_at": -1}).SetLimit(int64(limit))
-	cursor, err := coll.Find(ctx, bson.M{}, opts)
+func (r *orderRepository) setStatus(ctx context.Context, orderID string, version int, fields bson.M) error {
+	fields["version"] = version
+	return r.applyIfNewer(ctx, orderID, version, bson.M{"$set": fields}, false)
+}
+
+// applyIfNewer updates the order unless it is already at version or later.
+// With upsert, a missing order is created; the upsert of an order that is
+// newer collides with the unique order ID and is ignored as well.
+func (r *orderRepository) applyIfNewer(ctx context.Context, orderID string, version int, update bson.M, upsert bool) error {
+	coll := r.db.Collection(r.collection)
+	filter := bson.M{
+		"id": orderID,
+		"$or": bson.A{
+			bson.M{"version": bson.M{"$lt": version}},
+			bson.M{"version": bson.M{"$exists": false}},
+		},
+	}
+	_, err := coll.UpdateOne(ctx, filter, update, options.Update().SetUpsert(upsert))
+	if err != nil {
+		if upsert && mongo.IsDuplicateKeyError(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to update order projection: %w", err)
+	}
+	return nil
+}
+
+func (r *orderRepository) FindByID(ctx context.Context, orderID string) (*domain.Order, error) {
+	coll := r.db.Collection(r.collection)
+	var order domain.Order
+	err := coll.FindOne(ctx, bson.M{"id": orderID}).Decode(&order)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get order by id: %w", err)
+	}
+	return &order, nil
+}
+
+func (r *orderRepository) MarkPending(ctx context.Context, orderID, customerID string, submittedAt time.Time) error {
+	coll := r.db.Collection(r.collection)
+	order := bson.M{
+		"id":         orderID,
+		"status":     domain.OrderStatusPending,
+		"items":      []domain.OrderItem{},
+		"created_at": submittedAt,
+		"version":    0,
+	}
+	if customerID != "" {
+		order["customer_id"] = customerID
+	}
+	update := bson.M{"$setOnInsert": order}
+	_, err := coll.UpdateOne(ctx, bson.M{"id": orderID}, update, options.Update().SetUpsert(true))
+	if err != nil {
+		return fmt.Errorf("failed to mark order as pending: %w", err)
+	}
+	return nil
+}
+
+// List pages through orders newest first, breaking ties on created_at by ID
+// so the order is total and a cursor resumes exactly after its last order.
+func (r *orderRepository) List(ctx context.Context, filter domain.OrderFilter) (*domain.OrderPage, error) {
+	query, err := orderListQuery(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	coll := r.db.Collection(r.collection)
+	// One extra order tells whether there is a next page.
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "id", Value: -1}}).
+		SetLimit(int64(filter.Limit + 1))
+	cursor, err := coll.Find(ctx, query, opts)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query orders: %w", err)
 	}
 	defer cursor.Close(ctx)
 
-	var orders []domain.Order
+	orders := []domain.Order{}
 	if err := cursor.All(ctx, &orders); err != nil {
 		return nil, fmt.Errorf("failed to decode orders: %w", err)
 	}
+	return newOrderPage(orders, filter.Limit), nil
+}
+
+// orderListQuery builds the query selecting the orders of filter that come
+// after its cursor.
+func orderListQuery(filter domain.OrderFilter) (bson.M, error) {
+	query := bson.M{}
+	if filter.CustomerID != "" {
+		query["customer_id"] = filter.CustomerID
+	}
+	if filter.Status != "" {
+		query["status"] = filter.Status
+	}
+	createdAt := bson.M{}
+	if !filter.CreatedFrom.IsZero() {
+		createdAt["$gte"] = filter.CreatedFrom
+	}
+	if !filter.CreatedTo.IsZero() {
+		createdAt["$lt"] = filter.CreatedTo
+	}
+	if len(createdAt) > 0 {
+		query["created_at"] = createdAt
+	}
+
+	if filter.Cursor != "" {
+		c, err := decodeOrderCursor(filter.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		query["$or"] = bson.A{
+			bson.M{"created_at": bson.M{"$lt": c.CreatedAt}},
+			bson.M{"created_at": c.CreatedAt, "id": bson.M{"$lt": c.ID}},
+		}
+	}
+	return query, nil
+}
+
+// newOrderPage returns the first limit orders, fetched newest first with one
+// extra, and a cursor to the next page if the extra order was found.
+func newOrderPage(orders []domain.Order, limit int) *domain.OrderPage {
+	page := &domain.OrderPage{Orders: orders}
+	if len(orders) > limit {
+		page.Orders = orders[:limit]
+		last := page.Orders[len(page.Orders)-1]
+		page.NextCursor = encodeOrderCursor(orderCursor{CreatedAt: last.CreatedAt, ID: last.ID})
+	}
+	return page
+}
 
-	return orders, nil
+// orderCursor is the position after which the next page starts.
+type orderCursor struct {
+	CreatedAt time.Time `json:"t"`
+	ID        string    `json:"id"`
+}
+
+func encodeOrderCursor(c orderCursor) string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeOrderCursor(s string) (orderCursor, error) {
+	var c orderCursor
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return c, domain.ErrInvalidCursor
+	}
+	if err := json.Unmarshal(data, &c); err != nil || c.ID == "" {
+		return c, domain.ErrInvalidCursor
+	}
+	return c, nil
 }
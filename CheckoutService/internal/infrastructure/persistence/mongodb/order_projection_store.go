@@ -0,0 +1,143 @@
+package mongodb
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/egannguyen/go-kafka-ecommerce/checkout-service/internal/domain"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type orderProjectionStore struct {
+	db     *mongo.Database
+	live   string
+	shadow string
+}
+
+func NewOrderProjectionStore(db *mongo.Database) domain.OrderProjectionStore {
+	return &orderProjectionStore{db: db, live: "orders", shadow: "orders_rebuild"}
+}
+
+func (s *orderProjectionStore) ResetShadow(ctx context.Context) (domain.OrderRepository, error) {
+	if err := s.DropShadow(ctx); err != nil {
+		return nil, err
+	}
+	// The shadow collection replaces the live one on Swap, indexes included.
+	if err := createOrderIndexes(ctx, s.db.Collection(s.shadow)); err != nil {
+		return nil, fmt.Errorf("failed to index shadow collection: %w", err)
+	}
+	return &orderRepository{db: s.db, collection: s.shadow}, nil
+}
+
+func (s *orderProjectionStore) DropShadow(ctx context.Context) error {
+	if err := s.db.Collection(s.shadow).Drop(ctx); err != nil {
+		return fmt.Errorf("failed to drop shadow collection: %w", err)
+	}
+	return nil
+}
+
+// Diff walks both collections in order ID order and compares them document by document.
+func (s *orderProjectionStore) Diff(ctx context.Context) (*domain.ProjectionDiff, error) {
+	opts := options.Find().SetSort(bson.M{"id": 1}).SetProjection(bson.M{"_id": 0})
+
+	liveCur, err := s.db.Collection(s.live).Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query live projection: %w", err)
+	}
+	defer liveCur.Close(ctx)
+
+	shadowCur, err := s.db.Collection(s.shadow).Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query shadow projection: %w", err)
+	}
+	defer shadowCur.Close(ctx)
+
+	next := func(cur *mongo.Cursor) (*domain.Order, error) {
+		if !cur.Next(ctx) {
+			return nil, cur.Err()
+		}
+		var o domain.Order
+		if err := cur.Decode(&o); err != nil {
+			return nil, fmt.Errorf("failed to decode order: %w", err)
+		}
+		return &o, nil
+	}
+
+	diff := &domain.ProjectionDiff{}
+	live, err := next(liveCur)
+	if err != nil {
+		return nil, err
+	}
+	shadow, err := next(shadowCur)
+	if err != nil {
+		return nil, err
+	}
+
+	for live != nil || shadow != nil {
+		switch {
+		case shadow == nil || (live != nil && live.ID < shadow.ID):
+			diff.Removed = append(diff.Removed, live.ID)
+			live, err = next(liveCur)
+		case live == nil || shadow.ID < live.ID:
+			diff.Added = append(diff.Added, shadow.ID)
+			shadow, err = next(shadowCur)
+		default:
+			if reflect.DeepEqual(live, shadow) {
+				diff.Unchanged++
+			} else {
+				diff.Changed = append(diff.Changed, live.ID)
+			}
+			if live, err = next(liveCur); err == nil {
+				shadow, err = next(shadowCur)
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return diff, nil
+}
+
+func (s *orderProjectionStore) CopyPending(ctx context.Context) (int, error) {
+	cursor, err := s.db.Collection(s.live).Find(ctx, bson.M{"status": domain.OrderStatusPending}, options.Find().SetProjection(bson.M{"_id": 0}))
+	if err != nil {
+		return 0, fmt.Errorf("failed to query pending orders: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	shadow := s.db.Collection(s.shadow)
+	copied := 0
+	for cursor.Next(ctx) {
+		var order bson.M
+		if err := cursor.Decode(&order); err != nil {
+			return copied, fmt.Errorf("failed to decode pending order: %w", err)
+		}
+		// An order the rebuild already projected is newer than its pending row.
+		res, err := shadow.UpdateOne(ctx, bson.M{"id": order["id"]}, bson.M{"$setOnInsert": order}, options.Update().SetUpsert(true))
+		if err != nil {
+			return copied, fmt.Errorf("failed to copy pending order: %w", err)
+		}
+		if res.UpsertedCount > 0 {
+			copied++
+		}
+	}
+	return copied, cursor.Err()
+}
+
+// Swap renames the shadow collection over the live one, which MongoDB performs atomically.
+func (s *orderProjectionStore) Swap(ctx context.Context) error {
+	dbName := s.db.Name()
+	cmd := bson.D{
+		{Key: "renameCollection", Value: dbName + "." + s.shadow},
+		{Key: "to", Value: dbName + "." + s.live},
+		{Key: "dropTarget", Value: true},
+	}
+	if err := s.db.Client().Database("admin").RunCommand(ctx, cmd).Err(); err != nil {
+		return fmt.Errorf("failed to swap projection collections: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,181 @@
+package mongodb
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/egannguyen/go-kafka-ecommerce/checkout-service/internal/domain"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestOrderCursorRoundTrip(t *testing.T) {
+	tests := []orderCursor{
+		{CreatedAt: time.Date(2024, 5, 1, 10, 0, 0, 123456789, time.UTC), ID: "order-1"},
+		{CreatedAt: time.Date(2024, 5, 1, 12, 0, 0, 0, time.FixedZone("CEST", 2*60*60)), ID: "a/b+c=="},
+	}
+
+	for _, want := range tests {
+		t.Run(want.ID, func(t *testing.T) {
+			s := encodeOrderCursor(want)
+			got, err := decodeOrderCursor(s)
+			if err != nil {
+				t.Fatalf("decodeOrderCursor(%q): %v", s, err)
+			}
+			if !got.CreatedAt.Equal(want.CreatedAt) || got.ID != want.ID {
+				t.Errorf("decoded %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestDecodeOrderCursorRejectsInvalid(t *testing.T) {
+	tests := map[string]string{
+		"not base64":   "!!!",
+		"not JSON":     encodeRaw("not json"),
+		"missing ID":   encodeRaw(`{"t":"2024-05-01T10:00:00Z"}`),
+		"invalid time": encodeRaw(`{"t":"yesterday","id":"order-1"}`),
+		"padded":       encodeOrderCursor(orderCursor{ID: "order-1"}) + "==",
+	}
+
+	for name, cursor := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := decodeOrderCursor(cursor); !errors.Is(err, domain.ErrInvalidCursor) {
+				t.Errorf("decodeOrderCursor(%q) = %v, want %v", cursor, err, domain.ErrInvalidCursor)
+			}
+		})
+	}
+}
+
+func encodeRaw(s string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(s))
+}
+
+func TestOrderListQuery(t *testing.T) {
+	from := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	after := orderCursor{CreatedAt: time.Date(2024, 5, 15, 9, 30, 0, 0, time.UTC), ID: "order-7"}
+
+	tests := []struct {
+		name   string
+		filter domain.OrderFilter
+		want   bson.M
+	}{
+		{name: "no filter", filter: domain.OrderFilter{}, want: bson.M{}},
+		{
+			name:   "customer and status",
+			filter: domain.OrderFilter{CustomerID: "customer-1", Status: domain.OrderStatusShipped},
+			want:   bson.M{"customer_id": "customer-1", "status": domain.OrderStatusShipped},
+		},
+		{
+			name:   "created from",
+			filter: domain.OrderFilter{CreatedFrom: from},
+			want:   bson.M{"created_at": bson.M{"$gte": from}},
+		},
+		{
+			name:   "created range",
+			filter: domain.OrderFilter{CreatedFrom: from, CreatedTo: to},
+			want:   bson.M{"created_at": bson.M{"$gte": from, "$lt": to}},
+		},
+		{
+			name:   "cursor",
+			filter: domain.OrderFilter{CustomerID: "customer-1", Cursor: encodeOrderCursor(after)},
+			want: bson.M{
+				"customer_id": "customer-1",
+				"$or": bson.A{
+					bson.M{"created_at": bson.M{"$lt": after.CreatedAt}},
+					bson.M{"created_at": after.CreatedAt, "id": bson.M{"$lt": "order-7"}},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := orderListQuery(tt.filter)
+			if err != nil {
+				t.Fatalf("orderListQuery: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("orderListQuery = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOrderListQueryRejectsInvalidCursor(t *testing.T) {
+	if _, err := orderListQuery(domain.OrderFilter{Cursor: "!!!"}); !errors.Is(err, domain.ErrInvalidCursor) {
+		t.Errorf("orderListQuery = %v, want %v", err, domain.ErrInvalidCursor)
+	}
+}
+
+func TestNewOrderPage(t *testing.T) {
+	base := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	orders := make([]domain.Order, 4)
+	for i := range orders {
+		// Newest first, with a tie on created_at between the last two.
+		orders[i] = domain.Order{ID: fmt.Sprintf("order-%d", 4-i), CreatedAt: base.Add(-time.Duration(min(i, 2)) * time.Minute)}
+	}
+
+	tests := []struct {
+		name       string
+		fetched    int
+		limit      int
+		wantIDs    []string
+		wantCursor *orderCursor
+	}{
+		{name: "last page", fetched: 2, limit: 3, wantIDs: []string{"order-4", "order-3"}},
+		{name: "exactly full last page", fetched: 3, limit: 3, wantIDs: []string{"order-4", "order-3", "order-2"}},
+		{
+			name:       "more pages",
+			fetched:    4,
+			limit:      3,
+			wantIDs:    []string{"order-4", "order-3", "order-2"},
+			wantCursor: &orderCursor{CreatedAt: base.Add(-2 * time.Minute), ID: "order-2"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page := newOrderPage(orders[:tt.fetched], tt.limit)
+
+			var ids []string
+			for _, order := range page.Orders {
+				ids = append(ids, order.ID)
+			}
+			if !reflect.DeepEqual(ids, tt.wantIDs) {
+				t.Errorf("page orders = %v, want %v", ids, tt.wantIDs)
+			}
+
+			if tt.wantCursor == nil {
+				if page.NextCursor != "" {
+					t.Errorf("next cursor = %q on the last page", page.NextCursor)
+				}
+				return
+			}
+			c, err := decodeOrderCursor(page.NextCursor)
+			if err != nil {
+				t.Fatalf("decodeOrderCursor: %v", err)
+			}
+			if !c.CreatedAt.Equal(tt.wantCursor.CreatedAt) || c.ID != tt.wantCursor.ID {
+				t.Errorf("next cursor = %+v, want %+v", c, *tt.wantCursor)
+			}
+
+			// The next page resumes strictly after the last order of this one.
+			query, err := orderListQuery(domain.OrderFilter{Cursor: page.NextCursor})
+			if err != nil {
+				t.Fatalf("orderListQuery: %v", err)
+			}
+			wantOr := bson.A{
+				bson.M{"created_at": bson.M{"$lt": c.CreatedAt}},
+				bson.M{"created_at": c.CreatedAt, "id": bson.M{"$lt": "order-2"}},
+			}
+			if !reflect.DeepEqual(query["$or"], wantOr) {
+				t.Errorf("next page query = %v, want $or %v", query, wantOr)
+			}
+		})
+	}
+}
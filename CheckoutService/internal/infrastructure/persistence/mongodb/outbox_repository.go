@@ -0,0 +1,49 @@
+package mongodb
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/egannguyen/go-kafka-ecommerce/checkout-service/internal/domain"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type outboxRepository struct {
+	db *mongo.Database
+}
+
+func NewOutboxRepository(db *mongo.Database) domain.OutboxRepository {
+	return &outboxRepository{db: db}
+}
+
+// FetchPending returns undispatched messages, oldest first and in stream
+// version order within each key.
+func (r *outboxRepository) FetchPending(ctx context.Context, limit int) ([]domain.OutboxMessage, error) {
+	coll := r.db.Collection("outbox")
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "key", Value: 1}, {Key: "version", Value: 1}}).
+		SetLimit(int64(limit))
+	cursor, err := coll.Find(ctx, bson.M{"dispatched_at": nil}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query outbox: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var messages []domain.OutboxMessage
+	if err := cursor.All(ctx, &messages); err != nil {
+		return nil, fmt.Errorf("failed to decode outbox messages: %w", err)
+	}
+	return messages, nil
+}
+
+func (r *outboxRepository) MarkDispatched(ctx context.Context, ids []string) error {
+	coll := r.db.Collection("outbox")
+	_, err := coll.UpdateMany(ctx, bson.M{"id": bson.M{"$in": ids}}, bson.M{"$set": bson.M{"dispatched_at": time.Now()}})
+	if err != nil {
+		return fmt.Errorf("failed to mark outbox message dispatched: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package mongodb
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/egannguyen/go-kafka-ecommerce/checkout-service/internal/domain"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type sagaRepository struct {
+	db *mongo.Database
+}
+
+func NewSagaRepository(db *mongo.Database) domain.SagaRepository {
+	return &sagaRepository{db: db}
+}
+
+func (r *sagaRepository) Save(ctx context.Context, saga *domain.CheckoutSaga) error {
+	coll := r.db.Collection("sagas")
+	saga.UpdatedAt = time.Now()
+
+	opts := options.Replace().SetUpsert(true)
+	_, err := coll.ReplaceOne(ctx, bson.M{"order_id": saga.OrderID}, saga, opts)
+	if err != nil {
+		return fmt.Errorf("failed to save saga: %w", err)
+	}
+	return nil
+}
+
+func (r *sagaRepository) Get(ctx context.Context, orderID string) (*domain.CheckoutSaga, error) {
+	coll := r.db.Collection("sagas")
+	var saga domain.CheckoutSaga
+	err := coll.FindOne(ctx, bson.M{"order_id": orderID}).Decode(&saga)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get saga: %w", err)
+	}
+	return &saga, nil
+}
+
+func (r *sagaRepository) FindIncomplete(ctx context.Context) ([]domain.CheckoutSaga, error) {
+	coll := r.db.Collection("sagas")
+	filter := bson.M{"status": bson.M{"$in": []string{domain.SagaStatusRunning, domain.SagaStatusCompensating}}}
+	opts := options.Find().SetSort(bson.M{"created_at": 1})
+	cursor, err := coll.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query sagas: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var sagas []domain.CheckoutSaga
+	if err := cursor.All(ctx, &sagas); err != nil {
+		return nil, fmt.Errorf("failed to decode sagas: %w", err)
+	}
+	return sagas, nil
+}
@@ -0,0 +1,56 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/egannguyen/go-kafka-ecommerce/eventsourcing/pgstore"
+	_ "github.com/lib/pq"
+)
+
+// schema holds the tables of CheckoutService in a database shared with other services.
+const schema = "checkout"
+
+// InitDB connects to PostgreSQL and migrates the event store and outbox tables.
+func InitDB(dsn string) (*sql.DB, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+	if err := db.PingContext(ctx); err != nil {
+		return nil, fmt.Errorf("failed to ping database: %w", err)
+	}
+
+	if err := pgstore.New(db, schema).Migrate(ctx); err != nil {
+		return nil, err
+	}
+	if err := migrateDB(ctx, db); err != nil {
+		return nil, fmt.Errorf("failed to migrate database: %w", err)
+	}
+
+	slog.Info("PostgreSQL connected for CheckoutService")
+	return db, nil
+}
+
+func migrateDB(ctx context.Context, db *sql.DB) error {
+	_, err := db.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS checkout.outbox (
+			id TEXT PRIMARY KEY,
+			topic TEXT NOT NULL,
+			key TEXT NOT NULL,
+			version INT NOT NULL,
+			event_type TEXT NOT NULL,
+			payload JSONB NOT NULL,
+			created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+			dispatched_at TIMESTAMP WITH TIME ZONE
+		);
+		CREATE INDEX IF NOT EXISTS outbox_pending ON checkout.outbox (created_at, key, version) WHERE dispatched_at IS NULL;
+	`)
+	return err
+}
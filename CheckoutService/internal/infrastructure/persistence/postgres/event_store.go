@@ -0,0 +1,36 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/egannguyen/go-kafka-ecommerce/checkout-service/internal/domain"
+	"github.com/egannguyen/go-kafka-ecommerce/eventsourcing/pgstore"
+	"github.com/google/uuid"
+)
+
+// eventStore is the shared PostgreSQL event store plus the outbox of this service.
+type eventStore struct {
+	*pgstore.Store
+}
+
+func NewEventStore(db *sql.DB) domain.EventStore {
+	return &eventStore{Store: pgstore.New(db, schema)}
+}
+
+// SaveEventsWithOutbox inserts an outbox message per event in the append transaction.
+func (s *eventStore) SaveEventsWithOutbox(ctx context.Context, streamID string, streamType string, expectedVersion int, events []domain.Event, topic string) error {
+	return s.Append(ctx, streamID, streamType, expectedVersion, events, func(tx *sql.Tx, records []domain.EventRecord) error {
+		for _, rec := range records {
+			_, err := tx.ExecContext(ctx,
+				"INSERT INTO checkout.outbox (id, topic, key, version, event_type, payload, created_at) VALUES ($1, $2, $3, $4, $5, $6, $7)",
+				uuid.NewString(), topic, rec.StreamID, rec.Version, rec.EventType, string(rec.Payload), rec.CreatedAt,
+			)
+			if err != nil {
+				return fmt.Errorf("failed to insert outbox message: %w", err)
+			}
+		}
+		return nil
+	})
+}
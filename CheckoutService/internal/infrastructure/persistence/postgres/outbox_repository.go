@@ -0,0 +1,52 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/egannguyen/go-kafka-ecommerce/checkout-service/internal/domain"
+	"github.com/lib/pq"
+)
+
+type outboxRepository struct {
+	db *sql.DB
+}
+
+func NewOutboxRepository(db *sql.DB) domain.OutboxRepository {
+	return &outboxRepository{db: db}
+}
+
+// FetchPending returns undispatched messages, oldest first and in stream
+// version order within each key.
+func (r *outboxRepository) FetchPending(ctx context.Context, limit int) ([]domain.OutboxMessage, error) {
+	rows, err := r.db.QueryContext(ctx,
+		"SELECT id, topic, key, version, event_type, payload, created_at FROM checkout.outbox WHERE dispatched_at IS NULL ORDER BY created_at, key, version LIMIT $1",
+		limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query outbox: %w", err)
+	}
+	defer rows.Close()
+
+	var messages []domain.OutboxMessage
+	for rows.Next() {
+		var m domain.OutboxMessage
+		if err := rows.Scan(&m.ID, &m.Topic, &m.Key, &m.Version, &m.EventType, &m.Payload, &m.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to decode outbox message: %w", err)
+		}
+		messages = append(messages, m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to query outbox: %w", err)
+	}
+	return messages, nil
+}
+
+func (r *outboxRepository) MarkDispatched(ctx context.Context, ids []string) error {
+	_, err := r.db.ExecContext(ctx, "UPDATE checkout.outbox SET dispatched_at = NOW() WHERE id = ANY($1)", pq.Array(ids))
+	if err != nil {
+		return fmt.Errorf("failed to mark outbox message dispatched: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package usecase
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/egannguyen/go-kafka-ecommerce/checkout-service/internal/domain"
+)
+
+// CatchUpProjector feeds every stored event, across all streams, to a
+// projection handler and checkpoints its global position under name so a
+// restarted projector resumes where it stopped. Delivery is at-least-once:
+// events after the last checkpoint are replayed on restart, so handlers must
+// be idempotent.
+type CatchUpProjector struct {
+	name            string
+	eventStore      domain.EventStore
+	checkpoints     domain.CheckpointStore
+	checkpointEvery int
+}
+
+func NewCatchUpProjector(name string, eventStore domain.EventStore, checkpoints domain.CheckpointStore, checkpointEvery int) *CatchUpProjector {
+	if checkpointEvery < 1 {
+		checkpointEvery = 1
+	}
+	return &CatchUpProjector{
+		name:            name,
+		eventStore:      eventStore,
+		checkpoints:     checkpoints,
+		checkpointEvery: checkpointEvery,
+	}
+}
+
+// Run blocks until ctx is cancelled or handler fails.
+func (p *CatchUpProjector) Run(ctx context.Context, handler func(context.Context, domain.EventRecord) error) error {
+	from, err := p.checkpoints.Load(ctx, p.name)
+	if err != nil {
+		return err
+	}
+	slog.Info("Starting catch-up projector", "name", p.name, "from_position", from)
+
+	position := from
+	pending := 0
+	err = p.eventStore.SubscribeAll(ctx, from, func(rec domain.EventRecord) error {
+		if err := handler(ctx, rec); err != nil {
+			return err
+		}
+		position = rec.Position
+		pending++
+		if pending < p.checkpointEvery {
+			return nil
+		}
+		pending = 0
+		return p.checkpoints.Save(ctx, p.name, position)
+	})
+
+	if pending > 0 {
+		// ctx may already be cancelled; the final checkpoint must still be written.
+		if saveErr := p.checkpoints.Save(context.WithoutCancel(ctx), p.name, position); saveErr != nil {
+			slog.Error("Failed to save final checkpoint", "name", p.name, "position", position, "err", saveErr)
+		}
+	}
+	return err
+}
@@ -0,0 +1,272 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/egannguyen/go-kafka-ecommerce/checkout-service/internal/domain"
+	"github.com/egannguyen/go-kafka-ecommerce/eventsourcing"
+)
+
+// runSaga drives a checkout saga forward from its current step. Saga state is
+// persisted after every step; any step failure triggers compensation.
+func (u *checkoutUseCase) runSaga(ctx context.Context, saga *domain.CheckoutSaga) error {
+	for {
+		var err error
+		switch saga.Step {
+		case domain.SagaStepReserveInventory:
+			err = u.reserveInventory(ctx, saga)
+		case domain.SagaStepChargePayment:
+			err = u.chargePayment(ctx, saga)
+		case domain.SagaStepPlaceOrder:
+			err = u.placeOrder(ctx, saga)
+		case domain.SagaStepConfirmOrder:
+			// Confirmation happens asynchronously in HandleOrderPlaced.
+			return nil
+		default:
+			err = fmt.Errorf("unknown saga step: %s", saga.Step)
+		}
+
+		if err != nil {
+			return u.compensate(ctx, saga, err)
+		}
+	}
+}
+
+func (u *checkoutUseCase) reserveInventory(ctx context.Context, saga *domain.CheckoutSaga) error {
+	var pending []domain.OrderItem
+	for _, item := range saga.Items {
+		if !saga.IsReserved(item.ProductID) {
+			pending = append(pending, item)
+		}
+	}
+
+	if len(pending) > 0 {
+		if err := u.inventoryService.Reserve(ctx, saga.OrderID, pending); err != nil {
+			return fmt.Errorf("failed to reserve inventory: %w", err)
+		}
+	}
+
+	for _, item := range pending {
+		saga.Reservations = append(saga.Reservations, domain.SagaReservation{
+			ProductID: item.ProductID,
+			Quantity:  item.Quantity,
+		})
+	}
+
+	saga.Step = domain.SagaStepChargePayment
+	return u.sagaRepo.Save(ctx, saga)
+}
+
+func (u *checkoutUseCase) chargePayment(ctx context.Context, saga *domain.CheckoutSaga) error {
+	var totalPrice float64
+	for _, item := range saga.Items {
+		totalPrice += item.Price * float64(item.Quantity)
+	}
+	totalPrice = domain.RoundPrice(totalPrice)
+	saga.TotalPrice = totalPrice
+
+	if saga.CreditCard == nil && saga.PaymentToken == "" {
+		return errors.New("payment details are no longer available")
+	}
+
+	amount := domain.NewMoney(domain.BaseCurrency, totalPrice)
+	if saga.Currency != "" && saga.Currency != domain.BaseCurrency {
+		converted, err := u.currencyService.Convert(ctx, amount, saga.Currency)
+		if err != nil {
+			return fmt.Errorf("failed to convert total to %s: %w", saga.Currency, err)
+		}
+		amount = converted
+	}
+
+	var txID string
+	var err error
+	if saga.CreditCard != nil {
+		txID, err = u.paymentService.Charge(ctx, amount, *saga.CreditCard)
+	} else {
+		txID, err = u.paymentService.ChargeToken(ctx, amount, saga.PaymentToken)
+	}
+	if err != nil {
+		slog.Error("Payment failed", "order_id", saga.OrderID, "err", err)
+		return fmt.Errorf("payment failed: %w", err)
+	}
+	slog.Info("Payment successful", "order_id", saga.OrderID, "transaction_id", txID, "amount", amount.Amount(), "currency", amount.CurrencyCode)
+
+	saga.TransactionID = txID
+	saga.ChargedAmount = amount
+	saga.Step = domain.SagaStepPlaceOrder
+	return u.sagaRepo.Save(ctx, saga)
+}
+
+func (u *checkoutUseCase) placeOrder(ctx context.Context, saga *domain.CheckoutSaga) error {
+	placedEvent := domain.OrderPlaced{
+		OrderID:       saga.OrderID,
+		CustomerID:    saga.CustomerID,
+		CartID:        saga.CartID,
+		Items:         saga.Items,
+		TotalPrice:    saga.TotalPrice,
+		BaseAmount:    domain.NewMoney(domain.BaseCurrency, saga.TotalPrice),
+		ChargedAmount: saga.ChargedAmount,
+		TransactionID: saga.TransactionID,
+		PricedAt:      saga.PricedAt,
+		PlacedAt:      time.Now(),
+	}
+
+	records, err := u.eventStore.LoadEvents(ctx, saga.OrderID)
+	if err != nil {
+		return fmt.Errorf("failed to load order history: %w", err)
+	}
+
+	// A previous run may have stored OrderPlaced (and queued it in the outbox)
+	// before crashing, in which case there is nothing left to do for this step.
+	if len(records) == 0 {
+		// Stop the reservations from expiring before the order exists. This
+		// fails if the checkout took longer than the reservation TTL.
+		if err := u.inventoryService.Hold(ctx, saga.OrderID, productIDs(saga.Items)); err != nil {
+			return fmt.Errorf("failed to hold inventory: %w", err)
+		}
+
+		// A conflict means a concurrent run of this saga stored OrderPlaced first.
+		err := u.eventStore.SaveEventsWithOutbox(ctx, saga.OrderID, "order", 0, []domain.Event{placedEvent}, "orders.placed")
+		if err != nil && !errors.Is(err, domain.ErrConcurrencyConflict) {
+			return fmt.Errorf("failed to save OrderPlaced event: %w", err)
+		}
+	}
+
+	saga.Step = domain.SagaStepConfirmOrder
+	return u.sagaRepo.Save(ctx, saga)
+}
+
+// compensate undoes every completed saga step in reverse order: the payment is
+// refunded, reservations are released and the order is marked as failed. The
+// original cause is returned wrapped once compensation succeeds.
+func (u *checkoutUseCase) compensate(ctx context.Context, saga *domain.CheckoutSaga, cause error) error {
+	slog.Warn("Compensating checkout saga", "order_id", saga.OrderID, "step", saga.Step, "err", cause)
+
+	saga.Status = domain.SagaStatusCompensating
+	saga.FailureReason = cause.Error()
+	if err := u.sagaRepo.Save(ctx, saga); err != nil {
+		return fmt.Errorf("failed to persist compensating saga: %w (cause: %v)", err, cause)
+	}
+
+	if saga.TransactionID != "" && !saga.PaymentRefunded {
+		if err := u.paymentService.Refund(ctx, saga.TransactionID); err != nil {
+			return fmt.Errorf("failed to refund payment %s: %w (cause: %v)", saga.TransactionID, err, cause)
+		}
+		saga.PaymentRefunded = true
+		if err := u.sagaRepo.Save(ctx, saga); err != nil {
+			return fmt.Errorf("failed to persist refund: %w (cause: %v)", err, cause)
+		}
+	}
+
+	// Release every item, not only the recorded reservations: a failed
+	// Reserve call may have reserved some items before giving up. Releasing
+	// is idempotent per order, so items never reserved are skipped.
+	if !saga.InventoryReleased {
+		if err := u.inventoryService.Release(ctx, saga.OrderID, productIDs(saga.Items)); err != nil {
+			return fmt.Errorf("failed to release inventory: %w (cause: %v)", err, cause)
+		}
+		saga.InventoryReleased = true
+		for i := range saga.Reservations {
+			saga.Reservations[i].Released = true
+		}
+		if err := u.sagaRepo.Save(ctx, saga); err != nil {
+			return fmt.Errorf("failed to persist released reservations: %w (cause: %v)", err, cause)
+		}
+	}
+
+	if err := u.failOrder(ctx, saga.OrderID, saga.FailureReason); err != nil {
+		return fmt.Errorf("%w (cause: %v)", err, cause)
+	}
+
+	saga.Status = domain.SagaStatusFailed
+	if err := u.sagaRepo.Save(ctx, saga); err != nil {
+		return fmt.Errorf("failed to persist failed saga: %w (cause: %v)", err, cause)
+	}
+
+	return fmt.Errorf("checkout failed: %w", cause)
+}
+
+func productIDs(items []domain.OrderItem) []string {
+	ids := make([]string, 0, len(items))
+	for _, item := range items {
+		ids = append(ids, item.ProductID)
+	}
+	return ids
+}
+
+// failOrder appends OrderFailed to the order stream unless it is already there.
+func (u *checkoutUseCase) failOrder(ctx context.Context, orderID, reason string) error {
+	return eventsourcing.RetryOnConflict(ctx, maxConflictAttempts, func(attempt int) error {
+		aggregate := domain.NewOrderAggregate(orderID)
+		if _, err := u.loadAggregate(ctx, "order", domain.OrderSnapshotSchemaVersion, aggregate); err != nil {
+			return err
+		}
+
+		if aggregate.Status == domain.OrderStatusFailed {
+			return nil
+		}
+
+		failedEvent := domain.OrderFailed{
+			OrderID:  orderID,
+			Reason:   reason,
+			FailedAt: time.Now(),
+		}
+
+		err := u.eventStore.SaveEventsWithOutbox(ctx, orderID, "order", aggregate.GetVersion(), []domain.Event{failedEvent}, "orders.failed")
+		if err != nil {
+			return fmt.Errorf("failed to save OrderFailed event: %w", err)
+		}
+		return nil
+	})
+}
+
+// completeSaga marks the saga of an order as completed once the order was
+// confirmed, or cancelled before it could be.
+func (u *checkoutUseCase) completeSaga(ctx context.Context, orderID string) error {
+	saga, err := u.sagaRepo.Get(ctx, orderID)
+	if err != nil {
+		return fmt.Errorf("failed to load checkout saga: %w", err)
+	}
+	if saga == nil || saga.IsTerminal() {
+		return nil
+	}
+
+	saga.Status = domain.SagaStatusCompleted
+	return u.sagaRepo.Save(ctx, saga)
+}
+
+// ResumeSagas picks up sagas left unfinished by a previous process. Sagas that
+// were interrupted while charging are compensated, since the outcome of the
+// charge is unknown and retrying it could bill the customer twice.
+func (u *checkoutUseCase) ResumeSagas(ctx context.Context) error {
+	sagas, err := u.sagaRepo.FindIncomplete(ctx)
+	if err != nil {
+		return err
+	}
+
+	for i := range sagas {
+		saga := &sagas[i]
+		slog.Info("Resuming checkout saga", "order_id", saga.OrderID, "step", saga.Step, "status", saga.Status)
+
+		switch {
+		case saga.Status == domain.SagaStatusCompensating:
+			err = u.compensate(ctx, saga, errors.New(saga.FailureReason))
+		case saga.Step == domain.SagaStepChargePayment && saga.TransactionID == "":
+			err = u.compensate(ctx, saga, errors.New("checkout interrupted during payment"))
+		case saga.Step == domain.SagaStepConfirmOrder:
+			// OrderPlaced is already in the outbox; HandleOrderPlaced completes the saga.
+			continue
+		default:
+			err = u.runSaga(ctx, saga)
+		}
+
+		if err != nil {
+			slog.Error("Failed to resume checkout saga", "order_id", saga.OrderID, "err", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,177 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/egannguyen/go-kafka-ecommerce/checkout-service/internal/domain"
+)
+
+func TestPlaceOrderRunsSaga(t *testing.T) {
+	f := newCheckoutFixture()
+	if err := f.placeOrder("order-1"); err != nil {
+		t.Fatalf("PlaceOrder: %v", err)
+	}
+
+	saga := f.saga("order-1")
+	if saga.Step != domain.SagaStepConfirmOrder || saga.Status != domain.SagaStatusRunning {
+		t.Errorf("saga at %s/%s, want %s/%s", saga.Step, saga.Status, domain.SagaStepConfirmOrder, domain.SagaStatusRunning)
+	}
+	if len(saga.Reservations) != 2 || saga.TransactionID != "tx-1" {
+		t.Errorf("saga reservations = %v, transaction = %q", saga.Reservations, saga.TransactionID)
+	}
+	if want := domain.NewMoney(domain.BaseCurrency, 56.99); saga.ChargedAmount != want {
+		t.Errorf("charged %+v, want %+v", saga.ChargedAmount, want)
+	}
+	f.assertStatus(t, "order-1", domain.OrderStatusPlaced)
+	if f.inventory.called("Hold", "order-1") != 1 {
+		t.Error("reservations were not held once the order was placed")
+	}
+}
+
+func TestSagaCompensation(t *testing.T) {
+	tests := []struct {
+		name       string
+		setup      func(f *checkoutFixture)
+		wantErr    error
+		wantRefund []string
+	}{
+		{
+			name:    "charge failure",
+			setup:   func(f *checkoutFixture) { f.payment.chargeErr = errPaymentDeclined },
+			wantErr: errPaymentDeclined,
+		},
+		{
+			name:       "place failure",
+			setup:      func(f *checkoutFixture) { f.inventory.fail["Hold"] = true },
+			wantErr:    errInventory,
+			wantRefund: []string{"tx-1"},
+		},
+		{
+			name:    "reserve failure",
+			setup:   func(f *checkoutFixture) { f.inventory.fail["Reserve"] = true },
+			wantErr: errInventory,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newCheckoutFixture()
+			tt.setup(f)
+
+			if err := f.placeOrder("order-1"); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("PlaceOrder = %v, want %v", err, tt.wantErr)
+			}
+
+			saga := f.saga("order-1")
+			if saga.Status != domain.SagaStatusFailed {
+				t.Errorf("saga status = %s, want %s", saga.Status, domain.SagaStatusFailed)
+			}
+			if !saga.InventoryReleased || f.inventory.called("Release", "order-1") != 1 {
+				t.Error("reservations were not released")
+			}
+			for _, r := range saga.Reservations {
+				if !r.Released {
+					t.Errorf("reservation of %s not marked released", r.ProductID)
+				}
+			}
+			if refunds := f.payment.refunded(); !slices.Equal(refunds, tt.wantRefund) {
+				t.Errorf("refunds = %v, want %v", refunds, tt.wantRefund)
+			}
+			if saga.PaymentRefunded != (len(tt.wantRefund) > 0) {
+				t.Errorf("saga payment refunded = %v", saga.PaymentRefunded)
+			}
+			f.assertStatus(t, "order-1", domain.OrderStatusFailed)
+		})
+	}
+}
+
+func TestResumeSagas(t *testing.T) {
+	ctx := context.Background()
+	f := newCheckoutFixture()
+	items := []domain.OrderItem{{ProductID: "p1", Name: "Sunglasses", Price: 19.99, Quantity: 1}}
+
+	// Interrupted after reserving, while charging: the charge outcome is unknown.
+	charging := domain.NewCheckoutSaga("charging", items, time.Now())
+	charging.Step = domain.SagaStepChargePayment
+	charging.Reservations = []domain.SagaReservation{{ProductID: "p1", Quantity: 1}}
+
+	// Interrupted while compensating a declined payment.
+	compensating := domain.NewCheckoutSaga("compensating", items, time.Now())
+	compensating.Step = domain.SagaStepChargePayment
+	compensating.Status = domain.SagaStatusCompensating
+	compensating.FailureReason = "payment failed: card declined"
+
+	// Placed, waiting for OrderPlaced to be consumed.
+	confirming := domain.NewCheckoutSaga("confirming", items, time.Now())
+	confirming.Step = domain.SagaStepConfirmOrder
+	confirming.TransactionID = "tx-confirming"
+
+	for _, saga := range []*domain.CheckoutSaga{charging, compensating, confirming} {
+		if err := f.sagas.Save(ctx, saga); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := f.uc.ResumeSagas(ctx); err != nil {
+		t.Fatalf("ResumeSagas: %v", err)
+	}
+
+	for _, orderID := range []string{"charging", "compensating"} {
+		saga := f.saga(orderID)
+		if saga.Status != domain.SagaStatusFailed {
+			t.Errorf("%s saga status = %s, want %s", orderID, saga.Status, domain.SagaStatusFailed)
+		}
+		if f.inventory.called("Release", orderID) != 1 {
+			t.Errorf("%s reservations were not released", orderID)
+		}
+		f.assertStatus(t, orderID, domain.OrderStatusFailed)
+	}
+	if reason := f.saga("charging").FailureReason; reason != "checkout interrupted during payment" {
+		t.Errorf("charging saga failure reason = %q", reason)
+	}
+	if refunds := f.payment.refunded(); len(refunds) != 0 {
+		t.Errorf("refunded %v without a recorded charge", refunds)
+	}
+	if len(f.payment.charges) != 0 {
+		t.Error("a resumed saga charged again")
+	}
+
+	saga := f.saga("confirming")
+	if saga.Status != domain.SagaStatusRunning || saga.Step != domain.SagaStepConfirmOrder {
+		t.Errorf("confirming saga moved to %s/%s", saga.Step, saga.Status)
+	}
+	if n := len(f.inventory.calls); n != 2 {
+		t.Errorf("inventory calls = %v, want only the two releases", f.inventory.calls)
+	}
+	if records, _ := f.events.LoadEvents(ctx, "confirming"); len(records) != 0 {
+		t.Errorf("confirming order stream has %d events, want it left alone", len(records))
+	}
+}
+
+func TestResumeSagaBeforeChargeWithoutPaymentDetails(t *testing.T) {
+	ctx := context.Background()
+	f := newCheckoutFixture()
+
+	// Reserved before the restart; the card was only held in memory.
+	saga := domain.NewCheckoutSaga("order-1", []domain.OrderItem{{ProductID: "p1", Price: 19.99, Quantity: 1}}, time.Now())
+	saga.Step = domain.SagaStepReserveInventory
+	if err := f.sagas.Save(ctx, saga); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := f.uc.ResumeSagas(ctx); err != nil {
+		t.Fatalf("ResumeSagas: %v", err)
+	}
+
+	if saga := f.saga("order-1"); saga.Status != domain.SagaStatusFailed {
+		t.Errorf("saga status = %s, want %s", saga.Status, domain.SagaStatusFailed)
+	}
+	if len(f.payment.charges) != 0 {
+		t.Error("charged without payment details")
+	}
+	f.assertStatus(t, "order-1", domain.OrderStatusFailed)
+}
@@ -0,0 +1,267 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/egannguyen/go-kafka-ecommerce/checkout-service/internal/domain"
+	"github.com/egannguyen/go-kafka-ecommerce/eventsourcing/memstore"
+)
+
+// fakeEventStore is the in-memory event store plus an outbox recording the
+// topic every saved event was queued for.
+type fakeEventStore struct {
+	*memstore.Store
+
+	mu     sync.Mutex
+	outbox []outboxEntry
+}
+
+type outboxEntry struct {
+	topic string
+	event domain.Event
+}
+
+func newFakeEventStore() *fakeEventStore {
+	return &fakeEventStore{Store: memstore.New()}
+}
+
+func (s *fakeEventStore) SaveEventsWithOutbox(ctx context.Context, aggregateID string, aggregateType string, expectedVersion int, events []domain.Event, topic string) error {
+	if err := s.SaveEvents(ctx, aggregateID, aggregateType, expectedVersion, events); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, e := range events {
+		s.outbox = append(s.outbox, outboxEntry{topic: topic, event: e})
+	}
+	return nil
+}
+
+// topics returns the topics queued in the outbox, in order.
+func (s *fakeEventStore) topics() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	topics := make([]string, len(s.outbox))
+	for i, entry := range s.outbox {
+		topics[i] = entry.topic
+	}
+	return topics
+}
+
+// fakeSagaRepo keeps copies of the saved sagas, as a database would.
+type fakeSagaRepo struct {
+	mu    sync.Mutex
+	sagas map[string]domain.CheckoutSaga
+}
+
+func newFakeSagaRepo() *fakeSagaRepo {
+	return &fakeSagaRepo{sagas: make(map[string]domain.CheckoutSaga)}
+}
+
+func (r *fakeSagaRepo) Save(ctx context.Context, saga *domain.CheckoutSaga) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored := *saga
+	stored.Reservations = append([]domain.SagaReservation(nil), saga.Reservations...)
+	stored.CreditCard, stored.PaymentToken = nil, ""
+	r.sagas[saga.OrderID] = stored
+	return nil
+}
+
+func (r *fakeSagaRepo) Get(ctx context.Context, orderID string) (*domain.CheckoutSaga, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	saga, ok := r.sagas[orderID]
+	if !ok {
+		return nil, nil
+	}
+	return &saga, nil
+}
+
+func (r *fakeSagaRepo) FindIncomplete(ctx context.Context) ([]domain.CheckoutSaga, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var sagas []domain.CheckoutSaga
+	for _, saga := range r.sagas {
+		if !saga.IsTerminal() {
+			sagas = append(sagas, saga)
+		}
+	}
+	return sagas, nil
+}
+
+// fakeInventory records the calls made for every order. Calls listed in
+// fail return errInventory.
+type fakeInventory struct {
+	mu    sync.Mutex
+	calls []string // "<method> <orderID>"
+	fail  map[string]bool
+}
+
+var errInventory = errors.New("inventory unavailable")
+
+func (f *fakeInventory) record(method, orderID string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = append(f.calls, method+" "+orderID)
+	if f.fail[method] {
+		return errInventory
+	}
+	return nil
+}
+
+func (f *fakeInventory) Reserve(ctx context.Context, orderID string, items []domain.OrderItem) error {
+	return f.record("Reserve", orderID)
+}
+
+func (f *fakeInventory) Hold(ctx context.Context, orderID string, productIDs []string) error {
+	return f.record("Hold", orderID)
+}
+
+func (f *fakeInventory) Release(ctx context.Context, orderID string, productIDs []string) error {
+	return f.record("Release", orderID)
+}
+
+func (f *fakeInventory) Commit(ctx context.Context, orderID string, productIDs []string) error {
+	return f.record("Commit", orderID)
+}
+
+func (f *fakeInventory) Restock(ctx context.Context, orderID string, productIDs []string, reason string) error {
+	return f.record("Restock", orderID)
+}
+
+func (f *fakeInventory) called(method, orderID string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	n := 0
+	for _, call := range f.calls {
+		if call == method+" "+orderID {
+			n++
+		}
+	}
+	return n
+}
+
+// fakePayment charges successfully unless chargeErr is set.
+type fakePayment struct {
+	mu        sync.Mutex
+	chargeErr error
+	charges   []domain.Money
+	refunds   []string
+}
+
+var errPaymentDeclined = errors.New("card declined")
+
+func (f *fakePayment) Charge(ctx context.Context, amount domain.Money, card domain.CreditCardInfo) (string, error) {
+	return f.charge(amount)
+}
+
+func (f *fakePayment) ChargeToken(ctx context.Context, amount domain.Money, token string) (string, error) {
+	return f.charge(amount)
+}
+
+func (f *fakePayment) charge(amount domain.Money) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.chargeErr != nil {
+		return "", f.chargeErr
+	}
+	f.charges = append(f.charges, amount)
+	return fmt.Sprintf("tx-%d", len(f.charges)), nil
+}
+
+func (f *fakePayment) Tokenize(ctx context.Context, card domain.CreditCardInfo) (string, error) {
+	return "token", nil
+}
+
+func (f *fakePayment) Refund(ctx context.Context, transactionID string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.refunds = append(f.refunds, transactionID)
+	return nil
+}
+
+func (f *fakePayment) refunded() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.refunds...)
+}
+
+// fakeCatalog serves a fixed set of products.
+type fakeCatalog map[string]domain.Product
+
+func (c fakeCatalog) GetProduct(ctx context.Context, id string) (*domain.Product, error) {
+	product, ok := c[id]
+	if !ok {
+		return nil, nil
+	}
+	return &product, nil
+}
+
+func (c fakeCatalog) ListProducts(ctx context.Context) ([]domain.Product, error) {
+	products := make([]domain.Product, 0, len(c))
+	for _, product := range c {
+		products = append(products, product)
+	}
+	return products, nil
+}
+
+// fakeCurrency converts at a fixed rate of 2 units per base currency unit.
+type fakeCurrency struct{}
+
+func (fakeCurrency) Convert(ctx context.Context, from domain.Money, toCode string) (domain.Money, error) {
+	return domain.NewMoney(toCode, from.Amount()*2), nil
+}
+
+var testCatalog = fakeCatalog{
+	"p1": {ID: "p1", Name: "Sunglasses", Price: 19.99},
+	"p2": {ID: "p2", Name: "Tank Top", Price: 18.5},
+}
+
+// checkoutFixture is a checkout use case wired to fakes.
+type checkoutFixture struct {
+	uc        *checkoutUseCase
+	events    *fakeEventStore
+	sagas     *fakeSagaRepo
+	inventory *fakeInventory
+	payment   *fakePayment
+}
+
+func newCheckoutFixture() *checkoutFixture {
+	f := &checkoutFixture{
+		events:    newFakeEventStore(),
+		sagas:     newFakeSagaRepo(),
+		inventory: &fakeInventory{fail: make(map[string]bool)},
+		payment:   &fakePayment{},
+	}
+	f.uc = NewCheckoutUseCase(nil, testCatalog, fakeCurrency{}, f.payment, f.inventory, nil, f.events, f.sagas, nil, nil).(*checkoutUseCase)
+	return f
+}
+
+// placeOrder runs a checkout of one p1 and two p2 paid by card.
+func (f *checkoutFixture) placeOrder(orderID string) error {
+	return f.uc.PlaceOrder(context.Background(), &domain.PlaceOrder{
+		OrderID:    orderID,
+		CustomerID: "customer-1",
+		Items: []domain.OrderItem{
+			{ProductID: "p1", Quantity: 1},
+			{ProductID: "p2", Quantity: 2},
+		},
+		CreditCard: &domain.CreditCardInfo{Number: "4432801561520454", CVV: 672, ExpirationMonth: 1, ExpirationYear: 2030},
+	})
+}
+
+// order loads the current state of an order stream.
+func (f *checkoutFixture) order(orderID string) (*domain.OrderAggregate, error) {
+	return f.uc.loadOrder(context.Background(), orderID)
+}
+
+// saga returns the stored saga of an order.
+func (f *checkoutFixture) saga(orderID string) *domain.CheckoutSaga {
+	saga, _ := f.sagas.Get(context.Background(), orderID)
+	return saga
+}
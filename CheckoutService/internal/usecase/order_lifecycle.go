@@ -0,0 +1,197 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/egannguyen/go-kafka-ecommerce/checkout-service/internal/domain"
+	"github.com/egannguyen/go-kafka-ecommerce/eventsourcing"
+)
+
+// Topics carrying order lifecycle transitions after confirmation.
+const (
+	TopicOrderCancelled       = "orders.cancelled"
+	TopicOrderShipped         = "orders.shipped"
+	TopicOrderDelivered       = "orders.delivered"
+	TopicOrderReturnRequested = "orders.return_requested"
+	TopicOrderRefunded        = "orders.refunded"
+)
+
+// loadOrder rehydrates an order aggregate, returning ErrOrderNotFound for an empty stream.
+func (u *checkoutUseCase) loadOrder(ctx context.Context, orderID string) (*domain.OrderAggregate, error) {
+	aggregate := domain.NewOrderAggregate(orderID)
+	exists, err := u.loadAggregate(ctx, "order", domain.OrderSnapshotSchemaVersion, aggregate)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, domain.ErrOrderNotFound
+	}
+	return aggregate, nil
+}
+
+// transitionOrder checks that the order may move to status and then appends
+// event to its stream, queueing it for topic. If the order changed since it
+// was loaded, aggregate is reloaded and the transition checked again.
+func (u *checkoutUseCase) transitionOrder(ctx context.Context, aggregate *domain.OrderAggregate, status string, event domain.Event, topic string) error {
+	_, err := u.transitionOrderFrom(ctx, aggregate, status, event, topic)
+	return err
+}
+
+// transitionOrderFrom is transitionOrder that also returns the status the
+// order moved from.
+func (u *checkoutUseCase) transitionOrderFrom(ctx context.Context, aggregate *domain.OrderAggregate, status string, event domain.Event, topic string) (string, error) {
+	var from string
+	err := eventsourcing.RetryOnConflict(ctx, maxConflictAttempts, func(attempt int) error {
+		if attempt > 1 {
+			fresh, err := u.loadOrder(ctx, aggregate.GetAggregateID())
+			if err != nil {
+				return err
+			}
+			*aggregate = *fresh
+		}
+
+		if err := aggregate.CanTransitionTo(status); err != nil {
+			return err
+		}
+
+		err := u.eventStore.SaveEventsWithOutbox(ctx, aggregate.GetAggregateID(), "order", aggregate.GetVersion(), []domain.Event{event}, topic)
+		if err != nil {
+			return fmt.Errorf("failed to save %s event: %w", event.EventType(), err)
+		}
+		from = aggregate.Status
+		return aggregate.ApplyEvent(event)
+	})
+	return from, err
+}
+
+// CancelOrder cancels an order that has not shipped yet, releases its
+// inventory reservations, restocks the inventory already committed to a
+// confirmed order and refunds the payment.
+func (u *checkoutUseCase) CancelOrder(ctx context.Context, orderID, reason string) error {
+	slog.Info("UseCase: Cancelling order", "order_id", orderID)
+
+	aggregate, err := u.loadOrder(ctx, orderID)
+	if err != nil {
+		return err
+	}
+
+	event := domain.OrderCancelled{
+		OrderID:     orderID,
+		Reason:      reason,
+		CancelledAt: time.Now(),
+	}
+	from, err := u.transitionOrderFrom(ctx, aggregate, domain.OrderStatusCancelled, event, TopicOrderCancelled)
+	if err != nil {
+		return err
+	}
+	// A placed order is cancelled before its saga confirmed it, and it never will.
+	if err := u.completeSaga(ctx, orderID); err != nil {
+		slog.Error("Failed to complete checkout saga of cancelled order", "order_id", orderID, "err", err)
+	}
+
+	if err := u.inventoryService.Release(ctx, orderID, productIDs(aggregate.Items)); err != nil {
+		slog.Error("Failed to release inventory for cancelled order", "order_id", orderID, "err", err)
+	}
+	// Confirming an order commits its reservations, so they are gone from
+	// the available pool until restocked.
+	if from == domain.OrderStatusConfirmed {
+		if err := u.inventoryService.Restock(ctx, orderID, productIDs(aggregate.Items), "order cancelled"); err != nil {
+			slog.Error("Failed to restock inventory for cancelled order", "order_id", orderID, "err", err)
+		}
+	}
+
+	return u.refund(ctx, aggregate)
+}
+
+func (u *checkoutUseCase) ShipOrder(ctx context.Context, orderID, carrier, trackingNumber string) error {
+	slog.Info("UseCase: Shipping order", "order_id", orderID, "carrier", carrier)
+
+	aggregate, err := u.loadOrder(ctx, orderID)
+	if err != nil {
+		return err
+	}
+
+	event := domain.OrderShipped{
+		OrderID:        orderID,
+		Carrier:        carrier,
+		TrackingNumber: trackingNumber,
+		ShippedAt:      time.Now(),
+	}
+	return u.transitionOrder(ctx, aggregate, domain.OrderStatusShipped, event, TopicOrderShipped)
+}
+
+func (u *checkoutUseCase) DeliverOrder(ctx context.Context, orderID string) error {
+	slog.Info("UseCase: Delivering order", "order_id", orderID)
+
+	aggregate, err := u.loadOrder(ctx, orderID)
+	if err != nil {
+		return err
+	}
+
+	event := domain.OrderDelivered{
+		OrderID:     orderID,
+		DeliveredAt: time.Now(),
+	}
+	return u.transitionOrder(ctx, aggregate, domain.OrderStatusDelivered, event, TopicOrderDelivered)
+}
+
+func (u *checkoutUseCase) RequestReturn(ctx context.Context, orderID, reason string) error {
+	slog.Info("UseCase: Requesting order return", "order_id", orderID)
+
+	aggregate, err := u.loadOrder(ctx, orderID)
+	if err != nil {
+		return err
+	}
+
+	event := domain.OrderReturnRequested{
+		OrderID:     orderID,
+		Reason:      reason,
+		RequestedAt: time.Now(),
+	}
+	return u.transitionOrder(ctx, aggregate, domain.OrderStatusReturnRequested, event, TopicOrderReturnRequested)
+}
+
+// RefundOrder refunds a cancelled or returned order. It can be called again
+// if the automatic refund after cancellation failed, and does nothing once
+// the order is refunded.
+func (u *checkoutUseCase) RefundOrder(ctx context.Context, orderID string) error {
+	slog.Info("UseCase: Refunding order", "order_id", orderID)
+
+	aggregate, err := u.loadOrder(ctx, orderID)
+	if err != nil {
+		return err
+	}
+	return u.refund(ctx, aggregate)
+}
+
+func (u *checkoutUseCase) refund(ctx context.Context, aggregate *domain.OrderAggregate) error {
+	if aggregate.Status == domain.OrderStatusRefunded {
+		return nil
+	}
+	if err := aggregate.CanTransitionTo(domain.OrderStatusRefunded); err != nil {
+		return err
+	}
+
+	orderID := aggregate.GetAggregateID()
+	saga, err := u.sagaRepo.Get(ctx, orderID)
+	if err != nil {
+		return fmt.Errorf("failed to load checkout saga: %w", err)
+	}
+	if saga == nil || saga.TransactionID == "" {
+		return fmt.Errorf("no payment transaction recorded for order %s", orderID)
+	}
+
+	if err := u.paymentService.Refund(ctx, saga.TransactionID); err != nil {
+		return fmt.Errorf("failed to refund payment %s: %w", saga.TransactionID, err)
+	}
+
+	event := domain.OrderRefunded{
+		OrderID:       orderID,
+		TransactionID: saga.TransactionID,
+		RefundedAt:    time.Now(),
+	}
+	return u.transitionOrder(ctx, aggregate, domain.OrderStatusRefunded, event, TopicOrderRefunded)
+}
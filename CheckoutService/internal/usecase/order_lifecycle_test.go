@@ -0,0 +1,194 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/egannguyen/go-kafka-ecommerce/checkout-service/internal/domain"
+)
+
+// confirmedOrder places an order and confirms it, as the OrderPlaced consumer does.
+func (f *checkoutFixture) confirmedOrder(t *testing.T, orderID string) {
+	t.Helper()
+	if err := f.placeOrder(orderID); err != nil {
+		t.Fatalf("PlaceOrder: %v", err)
+	}
+	if err := f.uc.HandleOrderPlaced(context.Background(), &domain.OrderPlaced{OrderID: orderID}); err != nil {
+		t.Fatalf("HandleOrderPlaced: %v", err)
+	}
+}
+
+func (f *checkoutFixture) assertStatus(t *testing.T, orderID, want string) {
+	t.Helper()
+	order, err := f.order(orderID)
+	if err != nil {
+		t.Fatalf("loading order: %v", err)
+	}
+	if order.Status != want {
+		t.Errorf("order status = %s, want %s", order.Status, want)
+	}
+}
+
+func TestCancelConfirmedOrder(t *testing.T) {
+	f := newCheckoutFixture()
+	f.confirmedOrder(t, "order-1")
+
+	if err := f.uc.CancelOrder(context.Background(), "order-1", "changed my mind"); err != nil {
+		t.Fatalf("CancelOrder: %v", err)
+	}
+
+	f.assertStatus(t, "order-1", domain.OrderStatusRefunded)
+	if f.inventory.called("Release", "order-1") != 1 {
+		t.Error("inventory was not released")
+	}
+	if f.inventory.called("Restock", "order-1") != 1 {
+		t.Error("committed inventory was not restocked")
+	}
+	if refunds := f.payment.refunded(); !slices.Equal(refunds, []string{"tx-1"}) {
+		t.Errorf("refunds = %v, want [tx-1]", refunds)
+	}
+	if topics := f.events.topics(); !slices.Equal(topics, []string{"orders.placed", "orders.confirmed", TopicOrderCancelled, TopicOrderRefunded}) {
+		t.Errorf("outbox topics = %v", topics)
+	}
+}
+
+func TestCancelPlacedOrderCompletesSaga(t *testing.T) {
+	ctx := context.Background()
+	f := newCheckoutFixture()
+	if err := f.placeOrder("order-1"); err != nil {
+		t.Fatalf("PlaceOrder: %v", err)
+	}
+
+	if err := f.uc.CancelOrder(ctx, "order-1", "changed my mind"); err != nil {
+		t.Fatalf("CancelOrder: %v", err)
+	}
+	// OrderPlaced reaches the consumer after the cancellation.
+	if err := f.uc.HandleOrderPlaced(ctx, &domain.OrderPlaced{OrderID: "order-1"}); err != nil {
+		t.Fatalf("HandleOrderPlaced: %v", err)
+	}
+
+	f.assertStatus(t, "order-1", domain.OrderStatusRefunded)
+	if f.inventory.called("Release", "order-1") != 1 {
+		t.Error("inventory was not released")
+	}
+	if f.inventory.called("Restock", "order-1") != 0 {
+		t.Error("inventory of an unconfirmed order was restocked")
+	}
+	if saga := f.saga("order-1"); saga.Status != domain.SagaStatusCompleted {
+		t.Errorf("saga status = %s, want %s", saga.Status, domain.SagaStatusCompleted)
+	}
+	if sagas, _ := f.sagas.FindIncomplete(ctx); len(sagas) != 0 {
+		t.Errorf("%d sagas left incomplete", len(sagas))
+	}
+}
+
+func TestHandleOrderPlacedAfterFailureCompletesSaga(t *testing.T) {
+	ctx := context.Background()
+	f := newCheckoutFixture()
+	if err := f.placeOrder("order-1"); err != nil {
+		t.Fatalf("PlaceOrder: %v", err)
+	}
+	if err := f.uc.failOrder(ctx, "order-1", "fraud check failed"); err != nil {
+		t.Fatalf("failOrder: %v", err)
+	}
+
+	if err := f.uc.HandleOrderPlaced(ctx, &domain.OrderPlaced{OrderID: "order-1"}); err != nil {
+		t.Fatalf("HandleOrderPlaced: %v", err)
+	}
+
+	f.assertStatus(t, "order-1", domain.OrderStatusFailed)
+	if saga := f.saga("order-1"); !saga.IsTerminal() {
+		t.Errorf("saga status = %s, want it finished", saga.Status)
+	}
+}
+
+func TestRefundOrderTwiceIsNoOp(t *testing.T) {
+	ctx := context.Background()
+	f := newCheckoutFixture()
+	f.confirmedOrder(t, "order-1")
+	if err := f.uc.CancelOrder(ctx, "order-1", "changed my mind"); err != nil {
+		t.Fatalf("CancelOrder: %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := f.uc.RefundOrder(ctx, "order-1"); err != nil {
+			t.Fatalf("RefundOrder #%d: %v", i+1, err)
+		}
+	}
+
+	if refunds := f.payment.refunded(); len(refunds) != 1 {
+		t.Errorf("payment refunded %d times, want once", len(refunds))
+	}
+	order, _ := f.order("order-1")
+	if order.Version != 4 {
+		t.Errorf("order stream at version %d, want 4 (placed, confirmed, cancelled, refunded)", order.Version)
+	}
+}
+
+func TestReturnDeliveredOrder(t *testing.T) {
+	ctx := context.Background()
+	f := newCheckoutFixture()
+	f.confirmedOrder(t, "order-1")
+
+	steps := []struct {
+		name   string
+		run    func() error
+		status string
+	}{
+		{"ship", func() error { return f.uc.ShipOrder(ctx, "order-1", "UPS", "1Z999") }, domain.OrderStatusShipped},
+		{"deliver", func() error { return f.uc.DeliverOrder(ctx, "order-1") }, domain.OrderStatusDelivered},
+		{"request return", func() error { return f.uc.RequestReturn(ctx, "order-1", "too small") }, domain.OrderStatusReturnRequested},
+		{"refund", func() error { return f.uc.RefundOrder(ctx, "order-1") }, domain.OrderStatusRefunded},
+	}
+	for _, step := range steps {
+		if err := step.run(); err != nil {
+			t.Fatalf("%s: %v", step.name, err)
+		}
+		f.assertStatus(t, "order-1", step.status)
+	}
+
+	if refunds := f.payment.refunded(); !slices.Equal(refunds, []string{"tx-1"}) {
+		t.Errorf("refunds = %v, want [tx-1]", refunds)
+	}
+}
+
+func TestLifecycleRejectsInvalidTransitions(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name string
+		run  func(f *checkoutFixture) error
+	}{
+		{"cancel shipped order", func(f *checkoutFixture) error {
+			if err := f.uc.ShipOrder(ctx, "order-1", "UPS", "1Z999"); err != nil {
+				return err
+			}
+			return f.uc.CancelOrder(ctx, "order-1", "too late")
+		}},
+		{"deliver unshipped order", func(f *checkoutFixture) error { return f.uc.DeliverOrder(ctx, "order-1") }},
+		{"return undelivered order", func(f *checkoutFixture) error { return f.uc.RequestReturn(ctx, "order-1", "") }},
+		{"refund confirmed order", func(f *checkoutFixture) error { return f.uc.RefundOrder(ctx, "order-1") }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newCheckoutFixture()
+			f.confirmedOrder(t, "order-1")
+
+			if err := tt.run(f); !errors.Is(err, domain.ErrInvalidTransition) {
+				t.Fatalf("got %v, want %v", err, domain.ErrInvalidTransition)
+			}
+			if f.inventory.called("Release", "order-1") != 0 || len(f.payment.refunded()) != 0 {
+				t.Error("a rejected transition released inventory or refunded the payment")
+			}
+		})
+	}
+}
+
+func TestLifecycleOfUnknownOrder(t *testing.T) {
+	f := newCheckoutFixture()
+	if err := f.uc.CancelOrder(context.Background(), "missing", ""); !errors.Is(err, domain.ErrOrderNotFound) {
+		t.Errorf("CancelOrder = %v, want %v", err, domain.ErrOrderNotFound)
+	}
+}
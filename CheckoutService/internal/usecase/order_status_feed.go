@@ -0,0 +1,126 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/egannguyen/go-kafka-ecommerce/checkout-service/internal/domain"
+)
+
+// OrderStatusTopics are the topics whose events change an order's status.
+var OrderStatusTopics = []string{
+	"orders.placed",
+	"orders.confirmed",
+	"orders.failed",
+	TopicOrderCancelled,
+	TopicOrderShipped,
+	TopicOrderDelivered,
+	TopicOrderReturnRequested,
+	TopicOrderRefunded,
+}
+
+// OrderStatusFeed wakes up the status streams watching an order on this
+// instance whenever an event of the order is consumed from Kafka. It only
+// signals that the order changed; watchers read the changes from the event
+// store, so a missed or duplicated signal loses nothing.
+type OrderStatusFeed struct {
+	mu       sync.Mutex
+	watchers map[string]map[chan struct{}]struct{}
+}
+
+func NewOrderStatusFeed() *OrderStatusFeed {
+	return &OrderStatusFeed{watchers: make(map[string]map[chan struct{}]struct{})}
+}
+
+// HandleOrderEvent notifies the watchers of the order an event payload refers to.
+func (f *OrderStatusFeed) HandleOrderEvent(ctx context.Context, payload []byte) error {
+	var event struct {
+		OrderID string `json:"order_id"`
+	}
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return fmt.Errorf("failed to decode order event: %w", err)
+	}
+	f.Notify(event.OrderID)
+	return nil
+}
+
+// Notify wakes up every watcher of the order without blocking.
+func (f *OrderStatusFeed) Notify(orderID string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for ch := range f.watchers[orderID] {
+		select {
+		case ch <- struct{}{}:
+		default: // a wake-up is already pending
+		}
+	}
+}
+
+// Watch returns a channel signalled whenever the order changes and a function
+// to stop watching.
+func (f *OrderStatusFeed) Watch(orderID string) (<-chan struct{}, func()) {
+	ch := make(chan struct{}, 1)
+
+	f.mu.Lock()
+	if f.watchers[orderID] == nil {
+		f.watchers[orderID] = make(map[chan struct{}]struct{})
+	}
+	f.watchers[orderID][ch] = struct{}{}
+	f.mu.Unlock()
+
+	return ch, func() {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		delete(f.watchers[orderID], ch)
+		if len(f.watchers[orderID]) == 0 {
+			delete(f.watchers, orderID)
+		}
+	}
+}
+
+// OrderStatusChanges returns the changes of the order after afterVersion and
+// whether the order reached a final status. It returns ErrOrderNotFound for an
+// order that was neither placed nor submitted.
+func (u *checkoutUseCase) OrderStatusChanges(ctx context.Context, orderID string, afterVersion int) ([]domain.OrderStatusChange, bool, error) {
+	records, err := u.eventStore.LoadEvents(ctx, orderID)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to load order history: %w", err)
+	}
+
+	if len(records) == 0 {
+		order, err := u.orderRepo.FindByID(ctx, orderID)
+		if err != nil {
+			return nil, false, err
+		}
+		if order == nil {
+			return nil, false, fmt.Errorf("%w: %s", domain.ErrOrderNotFound, orderID)
+		}
+		// Submitted but not processed yet.
+		return nil, false, nil
+	}
+
+	aggregate := domain.NewOrderAggregate(orderID)
+	var changes []domain.OrderStatusChange
+	for _, rec := range records {
+		event, err := domain.DecodeOrderEvent(rec)
+		if err != nil {
+			return nil, false, err
+		}
+		if err := aggregate.ApplyEvent(event); err != nil {
+			return nil, false, err
+		}
+		if rec.Version <= afterVersion {
+			continue
+		}
+		changes = append(changes, domain.OrderStatusChange{
+			OrderID:    orderID,
+			Version:    rec.Version,
+			EventType:  rec.EventType,
+			Status:     aggregate.Status,
+			OccurredAt: rec.CreatedAt,
+		})
+	}
+	return changes, aggregate.IsFinal(), nil
+}
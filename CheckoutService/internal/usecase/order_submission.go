@@ -0,0 +1,115 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/egannguyen/go-kafka-ecommerce/checkout-service/internal/domain"
+)
+
+// TopicOrderCommands carries PlaceOrder commands submitted asynchronously.
+const TopicOrderCommands = "orders.commands"
+
+// SubmitOrder validates the command and queues it on TopicOrderCommands
+// instead of running the checkout, so traffic spikes are absorbed by Kafka.
+// The order is reported as pending until a consumer processes the command.
+// The card is exchanged for a PaymentService token first: only the token is
+// queued, so no topic (nor its retry and dead-letter topics) holds card data.
+func (u *checkoutUseCase) SubmitOrder(ctx context.Context, cmd *domain.PlaceOrder) error {
+	slog.Info("UseCase: Submitting order", "order_id", cmd.OrderID, "items", len(cmd.Items), "cart_id", cmd.CartID)
+
+	if len(cmd.Items) == 0 && cmd.CartID == "" {
+		return fmt.Errorf("%w: order must have at least one item", domain.ErrInvalidOrder)
+	}
+	if err := validatePlaceOrder(cmd); err != nil {
+		return err
+	}
+
+	queued := *cmd
+	queued.CreditCard = nil
+	if cmd.CreditCard != nil {
+		token, err := u.paymentService.Tokenize(ctx, *cmd.CreditCard)
+		if err != nil {
+			return fmt.Errorf("failed to tokenize payment details: %w", err)
+		}
+		queued.PaymentToken = token
+	}
+
+	if err := u.commandPublisher.PublishEvent(ctx, TopicOrderCommands, cmd.OrderID, &queued); err != nil {
+		return fmt.Errorf("failed to publish PlaceOrder command: %w", err)
+	}
+
+	// Marked after publishing so a failed publish leaves no order stuck in
+	// pending; MarkPending is a no-op if the command was already processed.
+	if err := u.orderRepo.MarkPending(ctx, cmd.OrderID, cmd.CustomerID, time.Now()); err != nil {
+		return err
+	}
+	return nil
+}
+
+// HandlePlaceOrderCommand runs a submitted PlaceOrder command. Commands that
+// are rejected, e.g. for a stale price, fail the order so its status leaves
+// pending; retrying them would fail the same way.
+func (u *checkoutUseCase) HandlePlaceOrderCommand(ctx context.Context, cmd *domain.PlaceOrder) error {
+	var err error
+	if cmd.CartID != "" && len(cmd.Items) == 0 {
+		err = u.PlaceOrderFromCart(ctx, &domain.PlaceOrderFromCart{
+			OrderID:      cmd.OrderID,
+			CustomerID:   cmd.CustomerID,
+			CartID:       cmd.CartID,
+			CreditCard:   cmd.CreditCard,
+			PaymentToken: cmd.PaymentToken,
+			Currency:     cmd.Currency,
+		})
+	} else {
+		err = u.PlaceOrder(ctx, cmd)
+	}
+
+	if isRejection(err) {
+		slog.Warn("PlaceOrder command rejected", "order_id", cmd.OrderID, "err", err)
+		return u.failOrder(ctx, cmd.OrderID, err.Error())
+	}
+	return err
+}
+
+func (u *checkoutUseCase) GetOrder(ctx context.Context, orderID string) (*domain.Order, error) {
+	order, err := u.orderRepo.FindByID(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	if order == nil {
+		return nil, fmt.Errorf("%w: %s", domain.ErrOrderNotFound, orderID)
+	}
+	return order, nil
+}
+
+// validatePlaceOrder checks what can be checked without calling other services.
+func validatePlaceOrder(cmd *domain.PlaceOrder) error {
+	if cmd.OrderID == "" {
+		return fmt.Errorf("%w: missing order id", domain.ErrInvalidOrder)
+	}
+	for _, item := range cmd.Items {
+		if item.ProductID == "" {
+			return fmt.Errorf("%w: item without product_id", domain.ErrInvalidOrder)
+		}
+		if item.Quantity <= 0 {
+			return fmt.Errorf("%w: invalid quantity %d for product %s", domain.ErrInvalidOrder, item.Quantity, item.ProductID)
+		}
+	}
+	if (cmd.CreditCard == nil || cmd.CreditCard.Number == "") && cmd.PaymentToken == "" {
+		return fmt.Errorf("%w: missing payment details", domain.ErrInvalidOrder)
+	}
+	return nil
+}
+
+// isRejection reports whether err rejects the order itself rather than
+// reporting a failure to process it.
+func isRejection(err error) bool {
+	return errors.Is(err, domain.ErrInvalidOrder) ||
+		errors.Is(err, domain.ErrProductUnavailable) ||
+		errors.Is(err, domain.ErrPriceChanged) ||
+		errors.Is(err, domain.ErrCartNotOwned)
+}
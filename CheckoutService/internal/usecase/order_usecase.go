@@ -4,26 +4,51 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"strings"
 	"time"
 
 	"github.com/egannguyen/go-kafka-ecommerce/checkout-service/internal/domain"
+	"github.com/egannguyen/go-kafka-ecommerce/eventsourcing"
 )
 
 type CheckoutUseCase interface {
 	GetProducts(ctx context.Context) ([]domain.Product, error)
-	GetRecentOrders(ctx context.Context, limit int) ([]domain.Order, error)
+	ListOrders(ctx context.Context, filter domain.OrderFilter) (*domain.OrderPage, error)
 	PlaceOrder(ctx context.Context, cmd *domain.PlaceOrder) error
+	PlaceOrderFromCart(ctx context.Context, cmd *domain.PlaceOrderFromCart) error
+	SubmitOrder(ctx context.Context, cmd *domain.PlaceOrder) error
+	HandlePlaceOrderCommand(ctx context.Context, cmd *domain.PlaceOrder) error
+	GetOrder(ctx context.Context, orderID string) (*domain.Order, error)
+	OrderStatusChanges(ctx context.Context, orderID string, afterVersion int) ([]domain.OrderStatusChange, bool, error)
 	HandleOrderPlaced(ctx context.Context, event *domain.OrderPlaced) error
 	HandleOrderConfirmed(ctx context.Context, event *domain.OrderConfirmed) error
+	ResumeSagas(ctx context.Context) error
+
+	CancelOrder(ctx context.Context, orderID, reason string) error
+	ShipOrder(ctx context.Context, orderID, carrier, trackingNumber string) error
+	DeliverOrder(ctx context.Context, orderID string) error
+	RequestReturn(ctx context.Context, orderID, reason string) error
+	RefundOrder(ctx context.Context, orderID string) error
+	// ProjectOrderEvent applies a stored order event to the orders projection.
+	// Records of other stream types are ignored.
+	ProjectOrderEvent(ctx context.Context, rec domain.EventRecord) error
 }
 
+// maxConflictAttempts bounds the appends of an order change racing other
+// writers of the same order stream.
+const maxConflictAttempts = 5
+
 type checkoutUseCase struct {
-	orderRepo       domain.OrderRepository
-	productService  domain.ProductService
-	currencyService domain.CurrencyService
-	paymentService  domain.PaymentService
-	eventStore      domain.EventStore
-	publisher       domain.Publisher
+	orderRepo        domain.OrderRepository
+	productService   domain.ProductService
+	currencyService  domain.CurrencyService
+	paymentService   domain.PaymentService
+	inventoryService domain.InventoryService
+	cartService      domain.CartService
+	eventStore       domain.EventStore
+	sagaRepo         domain.SagaRepository
+	commandPublisher domain.Publisher
+	snapshotPolicy   domain.SnapshotPolicy
 }
 
 func NewCheckoutUseCase(
@@ -31,16 +56,24 @@ func NewCheckoutUseCase(
 	productService domain.ProductService,
 	currencyService domain.CurrencyService,
 	paymentService domain.PaymentService,
+	inventoryService domain.InventoryService,
+	cartService domain.CartService,
 	eventStore domain.EventStore,
-	publisher domain.Publisher,
+	sagaRepo domain.SagaRepository,
+	commandPublisher domain.Publisher,
+	snapshotPolicy domain.SnapshotPolicy,
 ) CheckoutUseCase {
 	return &checkoutUseCase{
-		orderRepo:       orderRepo,
-		productService:  productService,
-		currencyService: currencyService,
-		paymentService:  paymentService,
-		eventStore:      eventStore,
-		publisher:       publisher,
+		orderRepo:        orderRepo,
+		productService:   productService,
+		currencyService:  currencyService,
+		paymentService:   paymentService,
+		inventoryService: inventoryService,
+		cartService:      cartService,
+		eventStore:       eventStore,
+		sagaRepo:         sagaRepo,
+		commandPublisher: commandPublisher,
+		snapshotPolicy:   snapshotPolicy,
 	}
 }
 
@@ -48,18 +81,28 @@ func (u *checkoutUseCase) GetProducts(ctx context.Context) ([]domain.Product, er
 	return u.productService.ListProducts(ctx)
 }
 
-func (u *checkoutUseCase) GetRecentOrders(ctx context.Context, limit int) ([]domain.Order, error) {
-	if limit <= 0 {
-		limit = 50
+func (u *checkoutUseCase) ListOrders(ctx context.Context, filter domain.OrderFilter) (*domain.OrderPage, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = domain.DefaultOrderPageSize
+	}
+	if filter.Limit > domain.MaxOrderPageSize {
+		filter.Limit = domain.MaxOrderPageSize
 	}
-	return u.orderRepo.FindRecent(ctx, limit)
+	return u.orderRepo.List(ctx, filter)
 }
 
 func (u *checkoutUseCase) PlaceOrder(ctx context.Context, cmd *domain.PlaceOrder) error {
 	slog.Info("UseCase: Placing order", "order_id", cmd.OrderID, "items", len(cmd.Items))
 
 	if len(cmd.Items) == 0 {
-		return fmt.Errorf("order must have at least one item")
+		return fmt.Errorf("%w: order must have at least one item", domain.ErrInvalidOrder)
+	}
+	if err := validatePlaceOrder(cmd); err != nil {
+		return err
+	}
+	currency := strings.ToUpper(cmd.Currency)
+	if currency == "" {
+		currency = domain.BaseCurrency
 	}
 
 	records, err := u.eventStore.LoadEvents(ctx, cmd.OrderID)
@@ -72,122 +115,171 @@ func (u *checkoutUseCase) PlaceOrder(ctx context.Context, cmd *domain.PlaceOrder
 		return nil
 	}
 
-	for _, item := range cmd.Items {
-		invRecords, err := u.eventStore.LoadEvents(ctx, item.ProductID)
-		if err != nil {
-			return fmt.Errorf("failed to load inventory history for %s: %w", item.ProductID, err)
-		}
-
-		invAgg := domain.NewInventoryAggregate(item.ProductID)
-		// Simulating legacy seed for stock check if no events exist
-		if len(invRecords) == 0 {
-			p, err := u.productService.GetProduct(ctx, item.ProductID)
-			if err == nil && p != nil {
-				invAgg.HardStock = p.Stock
-			}
-		}
-
-		if err := invAgg.Rehydrate(invRecords); err != nil {
-			return fmt.Errorf("failed to rehydrate inventory aggregate: %w", err)
-		}
-
-		if invAgg.AvailableStock() < item.Quantity {
-			return fmt.Errorf("insufficient stock for product %s (available: %d, requested: %d)", item.ProductID, invAgg.AvailableStock(), item.Quantity)
-		}
+	saga, err := u.sagaRepo.Get(ctx, cmd.OrderID)
+	if err != nil {
+		return fmt.Errorf("failed to load checkout saga: %w", err)
 	}
-
-	var totalPrice float64
-	for _, item := range cmd.Items {
-		totalPrice += item.Price * float64(item.Quantity)
-
-		resEvent := domain.InventoryReserved{
-			OrderID:   cmd.OrderID,
-			ProductID: item.ProductID,
-			Quantity:  item.Quantity,
-		}
-		err = u.eventStore.SaveEvents(ctx, item.ProductID, "inventory", -1, []domain.Event{resEvent})
-		if err != nil {
-			slog.Error("Failed to save InventoryReserved event", "err", err)
-		}
+	if saga != nil {
+		slog.Info("Checkout saga already started (idempotency)", "order_id", cmd.OrderID, "step", saga.Step, "status", saga.Status)
+		return nil
 	}
 
-	// 2. Process Payment
-	// In a real app, CreditCardInfo would come from the command
-	// For now, we use a mock card if not provided
-	mockCard := domain.CreditCardInfo{
-		Number:          "1234-5678-9012-3456",
-		CVV:             123,
-		ExpirationMonth: 12,
-		ExpirationYear:  2025,
+	pricedAt := time.Now()
+	items, err := u.priceItems(ctx, cmd.Items)
+	if err != nil {
+		return err
 	}
 
-	amount := domain.Money{
-		CurrencyCode: "USD", // Assuming prices are in USD for now
-		Units:        int64(totalPrice),
-		Nanos:        int32((totalPrice - float64(int64(totalPrice))) * 1e9),
+	saga = domain.NewCheckoutSaga(cmd.OrderID, items, pricedAt)
+	saga.CustomerID = cmd.CustomerID
+	saga.CartID = cmd.CartID
+	saga.Currency = currency
+	saga.CreditCard = cmd.CreditCard
+	saga.PaymentToken = cmd.PaymentToken
+	if err := u.sagaRepo.Save(ctx, saga); err != nil {
+		return fmt.Errorf("failed to start checkout saga: %w", err)
 	}
 
-	txID, err := u.paymentService.Charge(ctx, amount, mockCard)
-	if err != nil {
-		slog.Error("Payment failed", "order_id", cmd.OrderID, "err", err)
-		// Compensation logic would go here (e.g., releasing inventory)
-		return fmt.Errorf("payment failed: %w", err)
-	}
-	slog.Info("Payment successful", "order_id", cmd.OrderID, "transaction_id", txID)
+	return u.runSaga(ctx, saga)
+}
 
-	placedEvent := domain.OrderPlaced{
-		OrderID:    cmd.OrderID,
-		Items:      cmd.Items,
-		TotalPrice: totalPrice,
-		PlacedAt:   time.Now(),
+// PlaceOrderFromCart orders the current content of a cart. The cart keeps its
+// items until OrderPlaced reaches CartService, which then checks it out.
+func (u *checkoutUseCase) PlaceOrderFromCart(ctx context.Context, cmd *domain.PlaceOrderFromCart) error {
+	slog.Info("UseCase: Placing order from cart", "order_id", cmd.OrderID, "cart_id", cmd.CartID)
+
+	if cmd.CartID == "" {
+		return fmt.Errorf("%w: missing cart id", domain.ErrInvalidOrder)
 	}
 
-	err = u.eventStore.SaveEvents(ctx, cmd.OrderID, "order", 0, []domain.Event{placedEvent})
+	cart, err := u.cartService.GetCart(ctx, cmd.CartID)
 	if err != nil {
-		return fmt.Errorf("failed to save OrderPlaced event: %w", err)
+		return fmt.Errorf("failed to get cart %s: %w", cmd.CartID, err)
+	}
+	if cart.CustomerID != "" && cart.CustomerID != cmd.CustomerID {
+		return fmt.Errorf("%w: %s", domain.ErrCartNotOwned, cmd.CartID)
 	}
+	if len(cart.Items) == 0 {
+		return fmt.Errorf("%w: cart %s is empty", domain.ErrInvalidOrder, cmd.CartID)
+	}
+
+	return u.PlaceOrder(ctx, &domain.PlaceOrder{
+		OrderID:      cmd.OrderID,
+		CustomerID:   cmd.CustomerID,
+		CartID:       cmd.CartID,
+		Items:        cart.Items,
+		CreditCard:   cmd.CreditCard,
+		PaymentToken: cmd.PaymentToken,
+		Currency:     cmd.Currency,
+	})
+}
 
-	if err := u.publisher.PublishEvent(ctx, "orders.placed", cmd.OrderID, placedEvent); err != nil {
-		return fmt.Errorf("failed to publish OrderPlaced event: %w", err)
+// priceItems resolves every item against the catalog and returns the items
+// with the catalog name and price. Client prices are never trusted: a price
+// that differs from the catalog rejects the order with a PriceChangedError
+// listing the current prices, while an item without a price is simply priced.
+func (u *checkoutUseCase) priceItems(ctx context.Context, items []domain.OrderItem) ([]domain.OrderItem, error) {
+	priced := make([]domain.OrderItem, 0, len(items))
+	var changes []domain.PriceChange
+	for _, item := range items {
+		product, err := u.productService.GetProduct(ctx, item.ProductID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get product %s: %w", item.ProductID, err)
+		}
+		if product == nil {
+			return nil, fmt.Errorf("%w: %s", domain.ErrProductUnavailable, item.ProductID)
+		}
+
+		price := domain.RoundPrice(product.Price)
+		if item.Price != 0 && !domain.PriceMatches(item.Price, price) {
+			changes = append(changes, domain.PriceChange{
+				ProductID:    item.ProductID,
+				Name:         product.Name,
+				ClientPrice:  item.Price,
+				CurrentPrice: price,
+			})
+		}
+
+		priced = append(priced, domain.OrderItem{
+			ProductID: item.ProductID,
+			Name:      product.Name,
+			Price:     price,
+			Quantity:  item.Quantity,
+		})
 	}
 
-	return nil
+	if len(changes) > 0 {
+		return nil, &domain.PriceChangedError{Changes: changes}
+	}
+	return priced, nil
 }
 
 func (u *checkoutUseCase) HandleOrderPlaced(ctx context.Context, event *domain.OrderPlaced) error {
 	slog.Info("UseCase: Confirming order", "order_id", event.OrderID)
 
-	if err := u.orderRepo.UpdateOrderProjection(ctx, *event); err != nil {
-		slog.Error("Failed to update projection for OrderPlaced", "err", err)
-	}
+	var status string
+	skipped := false
+	err := eventsourcing.RetryOnConflict(ctx, maxConflictAttempts, func(attempt int) error {
+		aggregate := domain.NewOrderAggregate(event.OrderID)
+		if _, err := u.loadAggregate(ctx, "order", domain.OrderSnapshotSchemaVersion, aggregate); err != nil {
+			return err
+		}
+		status = aggregate.Status
 
-	records, err := u.eventStore.LoadEvents(ctx, event.OrderID)
-	if err != nil {
-		return fmt.Errorf("failed to load order events: %w", err)
-	}
+		if aggregate.Status == domain.OrderStatusConfirmed {
+			return nil
+		}
+		if err := aggregate.CanTransitionTo(domain.OrderStatusConfirmed); err != nil {
+			slog.Warn("Skipping order confirmation", "order_id", event.OrderID, "err", err)
+			skipped = true
+			return nil
+		}
 
-	aggregate := domain.NewOrderAggregate(event.OrderID)
-	if err := aggregate.Rehydrate(records); err != nil {
-		return fmt.Errorf("failed to rehydrate order aggregate: %w", err)
-	}
+		confirmedEvent := domain.OrderConfirmed{
+			OrderID:     event.OrderID,
+			ConfirmedAt: time.Now(),
+		}
 
-	if aggregate.Status == "confirmed" {
+		err := u.eventStore.SaveEventsWithOutbox(ctx, event.OrderID, "order", aggregate.GetVersion(), []domain.Event{confirmedEvent}, "orders.confirmed")
+		if err != nil {
+			return fmt.Errorf("failed to save OrderConfirmed event: %w", err)
+		}
 		return nil
+	})
+	if err != nil {
+		return err
 	}
-
-	confirmedEvent := domain.OrderConfirmed{
-		OrderID:     event.OrderID,
-		ConfirmedAt: time.Now(),
+	// Only confirmation is left for a placed order, so once it was cancelled
+	// or failed instead the saga will never be confirmed and is done. An
+	// order without history is not placed yet and keeps its saga running.
+	if skipped && status == domain.OrderStatusPending {
+		return nil
 	}
 
-	err = u.eventStore.SaveEvents(ctx, event.OrderID, "order", aggregate.GetVersion(), []domain.Event{confirmedEvent})
+	return u.completeSaga(ctx, event.OrderID)
+}
+
+// HandleOrderConfirmed commits the order's reservations, decrementing the
+// physical stock.
+func (u *checkoutUseCase) HandleOrderConfirmed(ctx context.Context, event *domain.OrderConfirmed) error {
+	aggregate, err := u.loadOrder(ctx, event.OrderID)
 	if err != nil {
-		return fmt.Errorf("failed to save OrderConfirmed event: %w", err)
+		return err
 	}
-
-	return u.publisher.PublishEvent(ctx, "orders.confirmed", event.OrderID, confirmedEvent)
+	if err := u.inventoryService.Commit(ctx, event.OrderID, productIDs(aggregate.Items)); err != nil {
+		return fmt.Errorf("failed to commit inventory: %w", err)
+	}
+	return nil
 }
 
-func (u *checkoutUseCase) HandleOrderConfirmed(ctx context.Context, event *domain.OrderConfirmed) error {
-	return u.orderRepo.UpdateOrderProjection(ctx, *event)
+func (u *checkoutUseCase) ProjectOrderEvent(ctx context.Context, rec domain.EventRecord) error {
+	if rec.StreamType != "order" {
+		return nil
+	}
+
+	event, err := domain.DecodeOrderEvent(rec)
+	if err != nil {
+		return fmt.Errorf("failed to decode order event %s version %d: %w", rec.StreamID, rec.Version, err)
+	}
+	return u.orderRepo.UpdateOrderProjection(ctx, event, rec.Version)
 }
@@ -0,0 +1,118 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/egannguyen/go-kafka-ecommerce/checkout-service/internal/domain"
+)
+
+func TestPriceItems(t *testing.T) {
+	catalog := fakeCatalog{
+		"p1": {ID: "p1", Name: "Sunglasses", Price: 19.99},
+		// Catalog prices arrive as float32 over gRPC.
+		"p2": {ID: "p2", Name: "Tank Top", Price: float64(float32(18.5))},
+		"p3": {ID: "p3", Name: "Watch", Price: float64(float32(109.99))},
+	}
+
+	tests := []struct {
+		name        string
+		items       []domain.OrderItem
+		want        []domain.OrderItem
+		wantChanges []domain.PriceChange
+		wantErr     error
+	}{
+		{
+			name:  "items without prices are priced from the catalog",
+			items: []domain.OrderItem{{ProductID: "p1", Quantity: 2}, {ProductID: "p3", Quantity: 1}},
+			want: []domain.OrderItem{
+				{ProductID: "p1", Name: "Sunglasses", Price: 19.99, Quantity: 2},
+				{ProductID: "p3", Name: "Watch", Price: 109.99, Quantity: 1},
+			},
+		},
+		{
+			name:  "matching client prices are accepted",
+			items: []domain.OrderItem{{ProductID: "p2", Name: "Client name", Price: 18.5, Quantity: 1}, {ProductID: "p3", Price: 109.99, Quantity: 1}},
+			want: []domain.OrderItem{
+				{ProductID: "p2", Name: "Tank Top", Price: 18.5, Quantity: 1},
+				{ProductID: "p3", Name: "Watch", Price: 109.99, Quantity: 1},
+			},
+		},
+		{
+			name:  "stale client prices are rejected with every change",
+			items: []domain.OrderItem{{ProductID: "p1", Price: 17.99, Quantity: 1}, {ProductID: "p2", Price: 18.5, Quantity: 1}, {ProductID: "p3", Price: 99.99, Quantity: 1}},
+			wantChanges: []domain.PriceChange{
+				{ProductID: "p1", Name: "Sunglasses", ClientPrice: 17.99, CurrentPrice: 19.99},
+				{ProductID: "p3", Name: "Watch", ClientPrice: 99.99, CurrentPrice: 109.99},
+			},
+			wantErr: domain.ErrPriceChanged,
+		},
+		{
+			name:    "unknown products are rejected",
+			items:   []domain.OrderItem{{ProductID: "p1", Quantity: 1}, {ProductID: "missing", Quantity: 1}},
+			wantErr: domain.ErrProductUnavailable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uc := &checkoutUseCase{productService: catalog}
+			got, err := uc.priceItems(context.Background(), tt.items)
+			if !errors.Is(err, tt.wantErr) || (tt.wantErr == nil && err != nil) {
+				t.Fatalf("priceItems error = %v, want %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("priceItems = %+v, want %+v", got, tt.want)
+			}
+
+			var changed *domain.PriceChangedError
+			if errors.As(err, &changed) != (tt.wantChanges != nil) {
+				t.Fatalf("priceItems error = %v, want a PriceChangedError: %v", err, tt.wantChanges != nil)
+			}
+			if changed != nil && !reflect.DeepEqual(changed.Changes, tt.wantChanges) {
+				t.Errorf("changes = %+v, want %+v", changed.Changes, tt.wantChanges)
+			}
+		})
+	}
+}
+
+func TestPlaceOrderRejectsStalePricesBeforeStartingSaga(t *testing.T) {
+	f := newCheckoutFixture()
+	err := f.uc.PlaceOrder(context.Background(), &domain.PlaceOrder{
+		OrderID:      "order-1",
+		Items:        []domain.OrderItem{{ProductID: "p1", Price: 9.99, Quantity: 1}},
+		PaymentToken: "token",
+	})
+	if !errors.Is(err, domain.ErrPriceChanged) {
+		t.Fatalf("PlaceOrder = %v, want %v", err, domain.ErrPriceChanged)
+	}
+	if saga := f.saga("order-1"); saga != nil {
+		t.Errorf("saga started at %s", saga.Step)
+	}
+	if len(f.inventory.calls) != 0 || len(f.payment.charges) != 0 {
+		t.Error("a rejected order reserved inventory or charged the customer")
+	}
+}
+
+func TestPlaceOrderConvertsChargedAmount(t *testing.T) {
+	f := newCheckoutFixture()
+	err := f.uc.PlaceOrder(context.Background(), &domain.PlaceOrder{
+		OrderID:      "order-1",
+		Items:        []domain.OrderItem{{ProductID: "p1", Quantity: 2}},
+		PaymentToken: "token",
+		Currency:     "eur",
+	})
+	if err != nil {
+		t.Fatalf("PlaceOrder: %v", err)
+	}
+
+	saga := f.saga("order-1")
+	if saga.TotalPrice != 39.98 {
+		t.Errorf("total = %v, want 39.98 in %s", saga.TotalPrice, domain.BaseCurrency)
+	}
+	if want := domain.NewMoney("EUR", 79.96); saga.ChargedAmount != want {
+		t.Errorf("charged %+v, want %+v", saga.ChargedAmount, want)
+	}
+}
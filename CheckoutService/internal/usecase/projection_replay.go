@@ -0,0 +1,131 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/egannguyen/go-kafka-ecommerce/checkout-service/internal/domain"
+)
+
+// ReplayProgress is reported periodically while replaying events.
+type ReplayProgress struct {
+	Processed int64
+	Total     int64
+}
+
+// catchUpPageSize is the number of events read per page when catching a
+// projection up with events appended during a rebuild.
+const catchUpPageSize = 500
+
+// OrderProjectionReplayer rebuilds the orders read model from the order streams.
+type OrderProjectionReplayer struct {
+	eventStore  domain.EventStore
+	projections domain.OrderProjectionStore
+	live        domain.OrderRepository
+}
+
+func NewOrderProjectionReplayer(eventStore domain.EventStore, projections domain.OrderProjectionStore, live domain.OrderRepository) *OrderProjectionReplayer {
+	return &OrderProjectionReplayer{
+		eventStore:  eventStore,
+		projections: projections,
+		live:        live,
+	}
+}
+
+// Rebuild replays every order event into the shadow projection and then either
+// swaps it in or, on a dry run, diffs it against the live projection and drops
+// it. progress is called every progressEvery events.
+//
+// The live projection keeps changing while the rebuild runs. Before the swap,
+// the shadow projection is caught up with the events appended since the
+// replay and receives the pending orders of the live one; after the swap, the
+// events appended in the meantime are applied once more, which the projection
+// ignores for orders it is already up to date with.
+func (r *OrderProjectionReplayer) Rebuild(ctx context.Context, dryRun bool, progressEvery int64, progress func(ReplayProgress)) (*domain.ProjectionDiff, error) {
+	total, err := r.eventStore.CountEvents(ctx, "order")
+	if err != nil {
+		return nil, err
+	}
+
+	shadow, err := r.projections.ResetShadow(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var processed, position int64
+	err = r.eventStore.ForEachEvent(ctx, "order", func(rec domain.EventRecord) error {
+		position = rec.Position
+		event, err := domain.DecodeOrderEvent(rec)
+		if err != nil {
+			return fmt.Errorf("stream %s version %d: %w", rec.StreamID, rec.Version, err)
+		}
+		if err := shadow.UpdateOrderProjection(ctx, event, rec.Version); err != nil {
+			return fmt.Errorf("stream %s version %d: %w", rec.StreamID, rec.Version, err)
+		}
+
+		processed++
+		if progressEvery > 0 && processed%progressEvery == 0 {
+			progress(ReplayProgress{Processed: processed, Total: total})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to replay order events: %w", err)
+	}
+	progress(ReplayProgress{Processed: processed, Total: total})
+
+	if position, err = r.catchUp(ctx, shadow, position); err != nil {
+		return nil, err
+	}
+	copied, err := r.projections.CopyPending(ctx)
+	if err != nil {
+		return nil, err
+	}
+	slog.Info("Caught up rebuilt order projection", "position", position, "pending_orders", copied)
+
+	if !dryRun {
+		slog.Info("Swapping rebuilt order projection into place")
+		if err := r.projections.Swap(ctx); err != nil {
+			return nil, err
+		}
+		_, err := r.catchUp(ctx, r.live, position)
+		return nil, err
+	}
+
+	diff, err := r.projections.Diff(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.projections.DropShadow(ctx); err != nil {
+		return nil, err
+	}
+	return diff, nil
+}
+
+// catchUp applies the order events after position to orders and returns the
+// position of the last event read.
+func (r *OrderProjectionReplayer) catchUp(ctx context.Context, orders domain.OrderRepository, position int64) (int64, error) {
+	for {
+		page, err := r.eventStore.ReadAll(ctx, position, catchUpPageSize)
+		if err != nil {
+			return position, fmt.Errorf("failed to read events after position %d: %w", position, err)
+		}
+		for _, rec := range page {
+			position = rec.Position
+			if rec.StreamType != "order" {
+				continue
+			}
+			event, err := domain.DecodeOrderEvent(rec)
+			if err != nil {
+				return position, fmt.Errorf("stream %s version %d: %w", rec.StreamID, rec.Version, err)
+			}
+			if err := orders.UpdateOrderProjection(ctx, event, rec.Version); err != nil {
+				return position, fmt.Errorf("stream %s version %d: %w", rec.StreamID, rec.Version, err)
+			}
+		}
+		if len(page) < catchUpPageSize {
+			return position, nil
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+
+	"github.com/egannguyen/go-kafka-ecommerce/checkout-service/internal/domain"
+)
+
+// loadAggregate restores agg from the latest snapshot of its stream and
+// replays the events recorded after it. When the replayed tail reaches the
+// configured interval for streamType, a new snapshot is saved. It reports
+// whether the stream has any history at all.
+func (u *checkoutUseCase) loadAggregate(ctx context.Context, streamType string, schemaVersion int, agg domain.SnapshotAggregate) (bool, error) {
+	snapshot, records, err := u.eventStore.LoadFromSnapshot(ctx, agg.GetAggregateID(), schemaVersion)
+	if err != nil {
+		return false, fmt.Errorf("failed to load %s history: %w", streamType, err)
+	}
+
+	if snapshot != nil {
+		if err := json.Unmarshal(snapshot.State, agg); err != nil {
+			return false, fmt.Errorf("failed to decode %s snapshot: %w", streamType, err)
+		}
+	}
+
+	if err := agg.Rehydrate(records); err != nil {
+		return false, fmt.Errorf("failed to rehydrate %s aggregate: %w", streamType, err)
+	}
+
+	if u.snapshotPolicy.ShouldSnapshot(streamType, len(records)) {
+		u.saveSnapshot(ctx, streamType, schemaVersion, agg)
+	}
+
+	return snapshot != nil || len(records) > 0, nil
+}
+
+// saveSnapshot stores the aggregate state. Failures are only logged since the
+// events remain the source of truth.
+func (u *checkoutUseCase) saveSnapshot(ctx context.Context, streamType string, schemaVersion int, agg domain.SnapshotAggregate) {
+	state, err := json.Marshal(agg)
+	if err != nil {
+		slog.Error("Failed to marshal snapshot", "stream_id", agg.GetAggregateID(), "err", err)
+		return
+	}
+
+	err = u.eventStore.SaveSnapshot(ctx, domain.Snapshot{
+		StreamID:      agg.GetAggregateID(),
+		StreamType:    streamType,
+		Version:       agg.GetVersion(),
+		SchemaVersion: schemaVersion,
+		State:         state,
+	})
+	if err != nil {
+		slog.Error("Failed to save snapshot", "stream_id", agg.GetAggregateID(), "err", err)
+	}
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"net"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/egannguyen/go-kafka-ecommerce/eventsourcing/outbox"
+	deliveryGrpc "github.com/egannguyen/go-kafka-ecommerce/inventory-service/internal/delivery/grpc"
+	"github.com/egannguyen/go-kafka-ecommerce/inventory-service/internal/delivery/grpc/pb"
+	"github.com/egannguyen/go-kafka-ecommerce/inventory-service/internal/domain"
+	"github.com/egannguyen/go-kafka-ecommerce/inventory-service/internal/infrastructure/grpc"
+	"github.com/egannguyen/go-kafka-ecommerce/inventory-service/internal/infrastructure/messaging/kafka"
+	"github.com/egannguyen/go-kafka-ecommerce/inventory-service/internal/infrastructure/persistence/mongodb"
+	"github.com/egannguyen/go-kafka-ecommerce/inventory-service/internal/usecase"
+	stdgrpc "google.golang.org/grpc"
+)
+
+func main() {
+	// --- 1. Infrastructure Layer ---
+	mongoURL := getEnv("MONGODB_URL", "mongodb://mongodb:27017")
+	db, err := mongodb.InitDB(mongoURL)
+	if err != nil {
+		slog.Error("Failed to init mongodb", "err", err)
+		os.Exit(1)
+	}
+
+	eventStore := mongodb.NewEventStore(db)
+	outboxRepo := mongodb.NewOutboxRepository(db)
+
+	catalog, err := grpc.NewProductCatalogClient(getEnv("PRODUCT_CATALOG_ADDR", "localhost:50051"))
+	if err != nil {
+		slog.Error("Failed to init product catalog client", "err", err)
+		os.Exit(1)
+	}
+
+	publisher := kafka.NewKafkaPublisher([]string{getEnv("KAFKA_BROKERS", "localhost:9092")})
+
+	// --- 2. Application Layer (Use Cases) ---
+	reservationTTL := getEnvDuration("RESERVATION_TTL", 15*time.Minute)
+	snapshotPolicy := domain.SnapshotPolicy{
+		"inventory": getEnvInt("SNAPSHOT_INTERVAL_INVENTORY", 100),
+	}
+	inventoryUseCase := usecase.NewInventoryUseCase(eventStore, catalog, reservationTTL, snapshotPolicy)
+	outboxRelay := outbox.NewRelay(outboxRepo, publisher, 500*time.Millisecond, 100)
+	sweeper := usecase.NewReservationSweeper(inventoryUseCase, getEnvDuration("RESERVATION_SWEEP_INTERVAL", time.Minute))
+
+	// --- 3. Interface Layer (gRPC Delivery) ---
+	grpcSrv := stdgrpc.NewServer()
+	pb.RegisterInventoryServiceServer(grpcSrv, deliveryGrpc.NewServer(inventoryUseCase))
+
+	// --- 4. Start Application ---
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	// Outbox relay: outbox collection -> Kafka
+	go outboxRelay.Run(ctx)
+
+	// Reservation sweeper: expired reservations -> ReservationReleased
+	go sweeper.Run(ctx)
+
+	// Seed products that have no inventory yet; the catalog may still be starting.
+	go func() {
+		for {
+			err := inventoryUseCase.SeedFromCatalog(ctx)
+			if err == nil {
+				return
+			}
+			slog.Warn("Failed to seed inventory from catalog, retrying", "err", err)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(5 * time.Second):
+			}
+		}
+	}()
+
+	go func() {
+		lis, err := net.Listen("tcp", ":50051")
+		if err != nil {
+			slog.Error("gRPC failed to listen", "err", err)
+			cancel()
+			return
+		}
+		slog.Info("InventoryService gRPC starting on :50051")
+		if err := grpcSrv.Serve(lis); err != nil {
+			slog.Error("gRPC server error", "err", err)
+			cancel()
+		}
+	}()
+
+	<-ctx.Done()
+	slog.Info("Shutting down InventoryService...")
+	grpcSrv.GracefulStop()
+	if err := publisher.Close(); err != nil {
+		slog.Error("Failed to close kafka publisher", "err", err)
+	}
+	slog.Info("InventoryService exited")
+}
+
+func getEnv(key, fallback string) string {
+	if val := os.Getenv(key); val != "" {
+		return val
+	}
+	return fallback
+}
+
+func getEnvInt(key string, fallback int) int {
+	if val := os.Getenv(key); val != "" {
+		if n, err := strconv.Atoi(val); err == nil {
+			return n
+		}
+		slog.Warn("Ignoring invalid integer env var", "key", key, "value", val)
+	}
+	return fallback
+}
+
+func getEnvDuration(key string, fallback time.Duration) time.Duration {
+	if val := os.Getenv(key); val != "" {
+		if d, err := time.ParseDuration(val); err == nil {
+			return d
+		}
+		slog.Warn("Ignoring invalid duration env var", "key", key, "value", val)
+	}
+	return fallback
+}
@@ -0,0 +1,105 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+
+	"github.com/egannguyen/go-kafka-ecommerce/inventory-service/internal/delivery/grpc/pb"
+	"github.com/egannguyen/go-kafka-ecommerce/inventory-service/internal/domain"
+	"github.com/egannguyen/go-kafka-ecommerce/inventory-service/internal/usecase"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type Server struct {
+	pb.UnimplementedInventoryServiceServer
+	useCase usecase.InventoryUseCase
+}
+
+func NewServer(useCase usecase.InventoryUseCase) *Server {
+	return &Server{useCase: useCase}
+}
+
+func (s *Server) Reserve(ctx context.Context, req *pb.ReserveRequest) (*pb.ReserveResponse, error) {
+	items := make([]domain.Item, 0, len(req.Items))
+	for _, item := range req.Items {
+		items = append(items, domain.Item{ProductID: item.ProductId, Quantity: int(item.Quantity)})
+	}
+
+	if err := s.useCase.Reserve(ctx, req.OrderId, items); err != nil {
+		return nil, toStatus(err)
+	}
+	return &pb.ReserveResponse{}, nil
+}
+
+func (s *Server) Hold(ctx context.Context, req *pb.HoldRequest) (*pb.HoldResponse, error) {
+	if err := s.useCase.Hold(ctx, req.OrderId, req.ProductIds); err != nil {
+		return nil, toStatus(err)
+	}
+	return &pb.HoldResponse{}, nil
+}
+
+func (s *Server) Release(ctx context.Context, req *pb.ReleaseRequest) (*pb.ReleaseResponse, error) {
+	if err := s.useCase.Release(ctx, req.OrderId, req.ProductIds); err != nil {
+		return nil, toStatus(err)
+	}
+	return &pb.ReleaseResponse{}, nil
+}
+
+func (s *Server) Commit(ctx context.Context, req *pb.CommitRequest) (*pb.CommitResponse, error) {
+	if err := s.useCase.Commit(ctx, req.OrderId, req.ProductIds); err != nil {
+		return nil, toStatus(err)
+	}
+	return &pb.CommitResponse{}, nil
+}
+
+func (s *Server) Restock(ctx context.Context, req *pb.RestockRequest) (*pb.RestockResponse, error) {
+	if err := s.useCase.Restock(ctx, req.OrderId, req.ProductIds, req.Reason); err != nil {
+		return nil, toStatus(err)
+	}
+	return &pb.RestockResponse{}, nil
+}
+
+func (s *Server) Adjust(ctx context.Context, req *pb.AdjustRequest) (*pb.Availability, error) {
+	a, err := s.useCase.Adjust(ctx, req.ProductId, int(req.Delta), req.Reason)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return toPbAvailability(*a), nil
+}
+
+func (s *Server) GetAvailability(ctx context.Context, req *pb.GetAvailabilityRequest) (*pb.GetAvailabilityResponse, error) {
+	availability, err := s.useCase.GetAvailability(ctx, req.ProductIds)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	resp := &pb.GetAvailabilityResponse{}
+	for _, a := range availability {
+		resp.Items = append(resp.Items, toPbAvailability(a))
+	}
+	return resp, nil
+}
+
+func toPbAvailability(a domain.Availability) *pb.Availability {
+	return &pb.Availability{
+		ProductId:     a.ProductID,
+		HardStock:     int32(a.HardStock),
+		ReservedStock: int32(a.ReservedStock),
+		Available:     int32(a.Available),
+		Version:       int32(a.Version),
+	}
+}
+
+// toStatus maps domain errors to gRPC codes so callers can tell a business
+// rejection from an outage.
+func toStatus(err error) error {
+	switch {
+	case errors.Is(err, domain.ErrInsufficientStock), errors.Is(err, domain.ErrReservationNotFound):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, domain.ErrConcurrencyConflict):
+		return status.Error(codes.Aborted, err.Error())
+	default:
+		return status.Error(codes.Internal, err.Error())
+	}
+}
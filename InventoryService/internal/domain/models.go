@@ -0,0 +1,224 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/egannguyen/go-kafka-ecommerce/eventsourcing"
+	"github.com/egannguyen/go-kafka-ecommerce/eventsourcing/outbox"
+)
+
+var (
+	ErrInsufficientStock   = errors.New("insufficient stock")
+	ErrReservationNotFound = errors.New("reservation not found")
+)
+
+// ErrConcurrencyConflict is returned by EventStore.Append when another writer
+// appended to one of the streams first.
+var ErrConcurrencyConflict = eventsourcing.ErrConcurrencyConflict
+
+// Item is a quantity of a product to reserve.
+type Item struct {
+	ProductID string `json:"product_id"`
+	Quantity  int    `json:"quantity"`
+}
+
+// Availability is the stock position of a product.
+type Availability struct {
+	ProductID     string `json:"product_id"`
+	HardStock     int    `json:"hard_stock"`
+	ReservedStock int    `json:"reserved_stock"`
+	Available     int    `json:"available"`
+	Version       int    `json:"version"`
+}
+
+// CatalogProduct is the subset of a catalog product needed to seed inventory.
+type CatalogProduct struct {
+	ID    string
+	Stock int
+}
+
+// --- Events ---
+
+type Event = eventsourcing.Event
+
+// ProductStockUpdated sets the physical stock of a product.
+type ProductStockUpdated struct {
+	ProductID string `json:"product_id"`
+	NewStock  int    `json:"new_stock"`
+	Reason    string `json:"reason,omitempty"`
+}
+
+func (e ProductStockUpdated) EventType() string { return "ProductStockUpdated" }
+
+// InventoryReserved locks stock for an order until ExpiresAt, unless the
+// reservation is held or committed before then.
+type InventoryReserved struct {
+	OrderID   string    `json:"order_id"`
+	ProductID string    `json:"product_id"`
+	Quantity  int       `json:"quantity"`
+	ExpiresAt time.Time `json:"expires_at,omitempty"`
+}
+
+func (e InventoryReserved) EventType() string { return "InventoryReserved" }
+
+// ReservationHeld is emitted once the order of a reservation was placed; the
+// reservation no longer expires and is kept until committed or released.
+type ReservationHeld struct {
+	OrderID   string `json:"order_id"`
+	ProductID string `json:"product_id"`
+}
+
+func (e ReservationHeld) EventType() string { return "ReservationHeld" }
+
+type ReservationReleased struct {
+	OrderID   string `json:"order_id"`
+	ProductID string `json:"product_id"`
+	Quantity  int    `json:"quantity"`
+	Reason    string `json:"reason,omitempty"`
+}
+
+func (e ReservationReleased) EventType() string { return "ReservationReleased" }
+
+type ReservationConfirmed struct {
+	OrderID   string `json:"order_id"`
+	ProductID string `json:"product_id"`
+	Quantity  int    `json:"quantity"`
+}
+
+func (e ReservationConfirmed) EventType() string { return "ReservationConfirmed" }
+
+// ReservationReturned puts the stock committed for an order back on the
+// shelf, e.g. when a confirmed order is cancelled.
+type ReservationReturned struct {
+	OrderID   string `json:"order_id"`
+	ProductID string `json:"product_id"`
+	Quantity  int    `json:"quantity"`
+	Reason    string `json:"reason,omitempty"`
+}
+
+func (e ReservationReturned) EventType() string { return "ReservationReturned" }
+
+// StockChanged is published to Kafka after every change to a product's
+// inventory so other services, such as the catalog, can mirror it.
+type StockChanged struct {
+	ProductID     string    `json:"product_id"`
+	HardStock     int       `json:"hard_stock"`
+	ReservedStock int       `json:"reserved_stock"`
+	Available     int       `json:"available"`
+	Version       int       `json:"version"`
+	ChangedAt     time.Time `json:"changed_at"`
+}
+
+// EventRecord represents an event stored in the event store.
+type EventRecord = eventsourcing.EventRecord
+
+// AggregateBase provides a basic implementation for an aggregate.
+type AggregateBase = eventsourcing.AggregateBase
+
+// OutboxMessage is a message waiting to be published to Kafka by the outbox relay.
+type OutboxMessage = outbox.Message
+
+// Reservation is the stock an order holds on a product.
+type Reservation struct {
+	Quantity  int
+	ExpiresAt time.Time // zero once the reservation is held
+}
+
+// Expired reports whether the reservation lapsed before its order was placed.
+func (r Reservation) Expired(now time.Time) bool {
+	return !r.ExpiresAt.IsZero() && now.After(r.ExpiresAt)
+}
+
+// InventoryAggregate manages the stock of a product by replaying events.
+type InventoryAggregate struct {
+	AggregateBase
+	HardStock     int                    // Total physical items
+	ReservedStock int                    // Items locked for pending orders
+	Reservations  map[string]Reservation // By order ID
+	Committed     map[string]int         // Quantity committed, by order ID
+}
+
+// NewInventoryAggregate creates a new InventoryAggregate.
+func NewInventoryAggregate(productID string) *InventoryAggregate {
+	return &InventoryAggregate{
+		AggregateBase: AggregateBase{ID: productID},
+		Reservations:  make(map[string]Reservation),
+		Committed:     make(map[string]int),
+	}
+}
+
+// AvailableStock returns the stock available for new reservations.
+func (a *InventoryAggregate) AvailableStock() int {
+	return a.HardStock - a.ReservedStock
+}
+
+func (a *InventoryAggregate) Availability() Availability {
+	return Availability{
+		ProductID:     a.ID,
+		HardStock:     a.HardStock,
+		ReservedStock: a.ReservedStock,
+		Available:     a.AvailableStock(),
+		Version:       a.Version,
+	}
+}
+
+// ApplyEvent mutates the aggregate state based on the event.
+func (a *InventoryAggregate) ApplyEvent(e Event) error {
+	switch e := e.(type) {
+	case ProductStockUpdated:
+		a.HardStock = e.NewStock
+	case InventoryReserved:
+		a.ReservedStock += e.Quantity
+		r := a.Reservations[e.OrderID]
+		r.Quantity += e.Quantity
+		r.ExpiresAt = e.ExpiresAt
+		a.Reservations[e.OrderID] = r
+	case ReservationHeld:
+		if r, ok := a.Reservations[e.OrderID]; ok {
+			r.ExpiresAt = time.Time{}
+			a.Reservations[e.OrderID] = r
+		}
+	case ReservationReleased:
+		a.ReservedStock -= e.Quantity
+		a.release(e.OrderID, e.Quantity)
+	case ReservationConfirmed:
+		a.ReservedStock -= e.Quantity
+		a.HardStock -= e.Quantity
+		a.release(e.OrderID, e.Quantity)
+		a.Committed[e.OrderID] += e.Quantity
+	case ReservationReturned:
+		a.HardStock += e.Quantity
+		delete(a.Committed, e.OrderID)
+	default:
+		return fmt.Errorf("unknown event type for InventoryAggregate: %s", e.EventType())
+	}
+	a.Version++
+	return nil
+}
+
+func (a *InventoryAggregate) release(orderID string, quantity int) {
+	r := a.Reservations[orderID]
+	if r.Quantity <= quantity {
+		delete(a.Reservations, orderID)
+		return
+	}
+	r.Quantity -= quantity
+	a.Reservations[orderID] = r
+}
+
+// inventoryEvents decodes the events of product inventory streams.
+var inventoryEvents = eventsourcing.NewRegistry(
+	ProductStockUpdated{},
+	InventoryReserved{},
+	ReservationHeld{},
+	ReservationReleased{},
+	ReservationConfirmed{},
+	ReservationReturned{},
+)
+
+// Rehydrate rebuilds the aggregate from a list of records.
+func (a *InventoryAggregate) Rehydrate(records []EventRecord) error {
+	return eventsourcing.Rehydrate(a, inventoryEvents, records)
+}
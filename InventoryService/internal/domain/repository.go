@@ -0,0 +1,43 @@
+package domain
+
+import (
+	"context"
+
+	"github.com/egannguyen/go-kafka-ecommerce/eventsourcing/outbox"
+)
+
+// StreamAppend is a batch of events to append to one stream, together with
+// the outbox messages to queue for them.
+type StreamAppend struct {
+	StreamID        string
+	ExpectedVersion int
+	Events          []Event
+	Outbox          []OutboxMessage
+}
+
+type EventStore interface {
+	// Append writes every StreamAppend in a single transaction: either all
+	// streams are appended or none is. It returns ErrConcurrencyConflict if any
+	// stream is no longer at its expected version.
+	Append(ctx context.Context, appends []StreamAppend) error
+	LoadEvents(ctx context.Context, streamID string) ([]EventRecord, error)
+	// LoadFromSnapshot returns the latest snapshot of the stream written with
+	// schemaVersion (nil if there is none) and the events recorded after it.
+	LoadFromSnapshot(ctx context.Context, streamID string, schemaVersion int) (*Snapshot, []EventRecord, error)
+	SaveSnapshot(ctx context.Context, snapshot Snapshot) error
+	ListStreamIDs(ctx context.Context) ([]string, error)
+}
+
+// OutboxRepository gives the outbox relay access to queued messages.
+type OutboxRepository = outbox.Repository
+
+type Publisher interface {
+	PublishEvent(ctx context.Context, topic string, key string, event interface{}) error
+	outbox.Publisher
+	Close() error
+}
+
+// ProductCatalog lists the catalog products inventory is seeded from.
+type ProductCatalog interface {
+	ListProducts(ctx context.Context) ([]CatalogProduct, error)
+}
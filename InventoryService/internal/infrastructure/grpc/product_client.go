@@ -0,0 +1,38 @@
+package grpc
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/egannguyen/go-kafka-ecommerce/inventory-service/internal/delivery/grpc/pb"
+	"github.com/egannguyen/go-kafka-ecommerce/inventory-service/internal/domain"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+type productCatalogClient struct {
+	client pb.ProductCatalogServiceClient
+}
+
+func NewProductCatalogClient(addr string) (domain.ProductCatalog, error) {
+	conn, err := grpc.Dial(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to product catalog service: %w", err)
+	}
+
+	client := pb.NewProductCatalogServiceClient(conn)
+	return &productCatalogClient{client: client}, nil
+}
+
+func (c *productCatalogClient) ListProducts(ctx context.Context) ([]domain.CatalogProduct, error) {
+	resp, err := c.client.ListProducts(ctx, &pb.ListProductsRequest{})
+	if err != nil {
+		return nil, err
+	}
+
+	products := make([]domain.CatalogProduct, 0, len(resp.Products))
+	for _, p := range resp.Products {
+		products = append(products, domain.CatalogProduct{ID: p.Id, Stock: int(p.Stock)})
+	}
+	return products, nil
+}
@@ -0,0 +1,62 @@
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/egannguyen/go-kafka-ecommerce/eventsourcing/outbox"
+	"github.com/egannguyen/go-kafka-ecommerce/inventory-service/internal/domain"
+	kafkaGo "github.com/segmentio/kafka-go"
+)
+
+type kafkaPublisher struct {
+	writer *kafkaGo.Writer
+}
+
+// NewKafkaPublisher creates a publisher whose single writer is shared by every
+// publish; the topic is set per message. Keys are hashed so all changes to a
+// product land on the same partition in order.
+func NewKafkaPublisher(brokers []string) domain.Publisher {
+	return &kafkaPublisher{
+		writer: &kafkaGo.Writer{
+			Addr:                   kafkaGo.TCP(brokers...),
+			Balancer:               &kafkaGo.Hash{},
+			AllowAutoTopicCreation: true,
+		},
+	}
+}
+
+func (p *kafkaPublisher) PublishEvent(ctx context.Context, topic string, key string, event interface{}) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	return p.writer.WriteMessages(ctx, kafkaGo.Message{
+		Topic: topic,
+		Key:   []byte(key),
+		Value: payload,
+	})
+}
+
+// PublishBatch writes every message with one WriteMessages call. kafka-go
+// reports per-message failures as WriteErrors, returned as PublishErrors.
+func (p *kafkaPublisher) PublishBatch(ctx context.Context, messages []domain.OutboxMessage) error {
+	msgs := make([]kafkaGo.Message, len(messages))
+	for i, m := range messages {
+		msgs[i] = kafkaGo.Message{Topic: m.Topic, Key: []byte(m.Key), Value: m.Payload}
+	}
+
+	err := p.writer.WriteMessages(ctx, msgs...)
+	var writeErrs kafkaGo.WriteErrors
+	if errors.As(err, &writeErrs) {
+		return outbox.PublishErrors(writeErrs)
+	}
+	return err
+}
+
+func (p *kafkaPublisher) Close() error {
+	return p.writer.Close()
+}
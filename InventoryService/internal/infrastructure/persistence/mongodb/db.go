@@ -0,0 +1,60 @@
+package mongodb
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/egannguyen/go-kafka-ecommerce/eventsourcing/mongostore"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func InitDB(uri string) (*mongo.Database, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri))
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to mongodb: %w", err)
+	}
+
+	err = client.Ping(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to ping mongodb: %w", err)
+	}
+
+	db := client.Database("ecommerce_inventory")
+
+	if err := createIndexes(ctx, db); err != nil {
+		return nil, fmt.Errorf("failed to create indexes: %w", err)
+	}
+
+	slog.Info("MongoDB connected for InventoryService")
+	return db, nil
+}
+
+func createIndexes(ctx context.Context, db *mongo.Database) error {
+	// Events stored before the shared event store carry no stream type; every
+	// stream of this database is a product inventory stream.
+	_, err := db.Collection("events").UpdateMany(ctx, bson.M{"stream_type": bson.M{"$exists": false}}, bson.M{"$set": bson.M{"stream_type": "inventory"}})
+	if err != nil {
+		return fmt.Errorf("failed to backfill event stream types: %w", err)
+	}
+
+	if err := mongostore.EnsureIndexes(ctx, db); err != nil {
+		return err
+	}
+
+	_, err = db.Collection("outbox").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "dispatched_at", Value: 1}, {Key: "created_at", Value: 1}}},
+		{Keys: bson.D{{Key: "id", Value: 1}}, Options: options.Index().SetUnique(true)},
+	})
+	if err != nil {
+		return fmt.Errorf("outbox: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,71 @@
+package mongodb
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/egannguyen/go-kafka-ecommerce/eventsourcing/mongostore"
+	"github.com/egannguyen/go-kafka-ecommerce/inventory-service/internal/domain"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// streamType is the stream type of product inventory streams.
+const streamType = "inventory"
+
+// eventStore is the shared Mongo event store plus the outbox of this service.
+type eventStore struct {
+	store *mongostore.Store
+	db    *mongo.Database
+}
+
+func NewEventStore(db *mongo.Database) domain.EventStore {
+	return &eventStore{store: mongostore.New(db), db: db}
+}
+
+// Append inserts the outbox messages of every stream in the append transaction.
+func (s *eventStore) Append(ctx context.Context, appends []domain.StreamAppend) error {
+	now := time.Now()
+	streams := make([]mongostore.StreamAppend, 0, len(appends))
+	var outbox []interface{}
+	for _, a := range appends {
+		streams = append(streams, mongostore.StreamAppend{
+			StreamID:        a.StreamID,
+			StreamType:      streamType,
+			ExpectedVersion: a.ExpectedVersion,
+			Events:          a.Events,
+		})
+		for _, msg := range a.Outbox {
+			if msg.CreatedAt.IsZero() {
+				msg.CreatedAt = now
+			}
+			outbox = append(outbox, msg)
+		}
+	}
+
+	return s.store.AppendStreams(ctx, streams, func(sessCtx mongo.SessionContext, records []domain.EventRecord) error {
+		if len(outbox) == 0 {
+			return nil
+		}
+		if _, err := s.db.Collection("outbox").InsertMany(sessCtx, outbox); err != nil {
+			return fmt.Errorf("failed to insert outbox messages: %w", err)
+		}
+		return nil
+	})
+}
+
+func (s *eventStore) LoadEvents(ctx context.Context, streamID string) ([]domain.EventRecord, error) {
+	return s.store.LoadEvents(ctx, streamID)
+}
+
+func (s *eventStore) LoadFromSnapshot(ctx context.Context, streamID string, schemaVersion int) (*domain.Snapshot, []domain.EventRecord, error) {
+	return s.store.LoadFromSnapshot(ctx, streamID, schemaVersion)
+}
+
+func (s *eventStore) SaveSnapshot(ctx context.Context, snapshot domain.Snapshot) error {
+	return s.store.SaveSnapshot(ctx, snapshot)
+}
+
+func (s *eventStore) ListStreamIDs(ctx context.Context) ([]string, error) {
+	return s.store.ListStreamIDs(ctx, streamType)
+}
@@ -0,0 +1,412 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/egannguyen/go-kafka-ecommerce/eventsourcing"
+	"github.com/egannguyen/go-kafka-ecommerce/inventory-service/internal/domain"
+	"github.com/google/uuid"
+)
+
+// TopicStockChanged carries a StockChanged message after every inventory change.
+const TopicStockChanged = "inventory.stock_changed"
+
+// maxConflictAttempts bounds the appends of an inventory change racing other
+// writers. Contention is per product and concurrent checkouts often share
+// one, so it is higher than for per-order streams.
+const maxConflictAttempts = 8
+
+type InventoryUseCase interface {
+	// Reserve reserves stock for every item of an order. Items already
+	// reserved for the order are skipped, so retries are safe.
+	Reserve(ctx context.Context, orderID string, items []domain.Item) error
+	// Hold stops an order's reservations from expiring once the order is placed.
+	// It returns ErrReservationNotFound if a reservation already lapsed.
+	Hold(ctx context.Context, orderID string, productIDs []string) error
+	// Release returns the stock reserved for an order to the available pool.
+	Release(ctx context.Context, orderID string, productIDs []string) error
+	// Commit turns an order's reservations into a decrement of the physical stock.
+	Commit(ctx context.Context, orderID string, productIDs []string) error
+	// Restock returns the stock committed for an order to the physical stock.
+	// Products whose stock was not committed for the order are left untouched.
+	Restock(ctx context.Context, orderID string, productIDs []string, reason string) error
+	// Adjust changes the physical stock of a product by delta.
+	Adjust(ctx context.Context, productID string, delta int, reason string) (*domain.Availability, error)
+	GetAvailability(ctx context.Context, productIDs []string) ([]domain.Availability, error)
+	// ReleaseExpired releases every reservation that expired before now and
+	// returns how many were released.
+	ReleaseExpired(ctx context.Context, now time.Time) (int, error)
+	// SeedFromCatalog creates the inventory of catalog products that have none yet.
+	SeedFromCatalog(ctx context.Context) error
+}
+
+type inventoryUseCase struct {
+	eventStore     domain.EventStore
+	catalog        domain.ProductCatalog
+	reservationTTL time.Duration
+	snapshotPolicy domain.SnapshotPolicy
+}
+
+// NewInventoryUseCase creates the use case. Reservations expire after
+// reservationTTL unless their order is placed first.
+func NewInventoryUseCase(eventStore domain.EventStore, catalog domain.ProductCatalog, reservationTTL time.Duration, snapshotPolicy domain.SnapshotPolicy) InventoryUseCase {
+	return &inventoryUseCase{
+		eventStore:     eventStore,
+		catalog:        catalog,
+		reservationTTL: reservationTTL,
+		snapshotPolicy: snapshotPolicy,
+	}
+}
+
+// load restores the inventory of a product from its latest snapshot and
+// replays the events recorded after it, saving a new snapshot once the
+// replayed tail reaches the configured interval.
+func (u *inventoryUseCase) load(ctx context.Context, productID string) (*domain.InventoryAggregate, error) {
+	snapshot, records, err := u.eventStore.LoadFromSnapshot(ctx, productID, domain.InventorySnapshotSchemaVersion)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load inventory history: %w", err)
+	}
+
+	agg := domain.NewInventoryAggregate(productID)
+	if snapshot != nil {
+		if err := json.Unmarshal(snapshot.State, agg); err != nil {
+			return nil, fmt.Errorf("failed to decode inventory snapshot: %w", err)
+		}
+	}
+
+	if err := agg.Rehydrate(records); err != nil {
+		return nil, fmt.Errorf("failed to rehydrate inventory for %s: %w", productID, err)
+	}
+
+	if u.snapshotPolicy.ShouldSnapshot("inventory", len(records)) {
+		u.saveSnapshot(ctx, agg)
+	}
+	return agg, nil
+}
+
+// saveSnapshot stores the inventory state. Failures are only logged since the
+// events remain the source of truth.
+func (u *inventoryUseCase) saveSnapshot(ctx context.Context, agg *domain.InventoryAggregate) {
+	state, err := json.Marshal(agg)
+	if err != nil {
+		slog.Error("Failed to marshal inventory snapshot", "product_id", agg.ID, "err", err)
+		return
+	}
+
+	err = u.eventStore.SaveSnapshot(ctx, domain.Snapshot{
+		StreamID:      agg.ID,
+		StreamType:    "inventory",
+		Version:       agg.GetVersion(),
+		SchemaVersion: domain.InventorySnapshotSchemaVersion,
+		State:         state,
+	})
+	if err != nil {
+		slog.Error("Failed to save inventory snapshot", "product_id", agg.ID, "err", err)
+	}
+}
+
+// update loads the inventory of each product, asks decide for the events to
+// append to it and appends them to all products in one transaction, so the
+// operation applies to every product or to none. decide receives the index
+// of the product and may return no events to leave it untouched. When another
+// writer changed one of the products in the meantime, the whole operation is
+// reloaded and decided again.
+func (u *inventoryUseCase) update(ctx context.Context, productIDs []string, decide func(i int, agg *domain.InventoryAggregate) ([]domain.Event, error)) error {
+	return eventsourcing.RetryOnConflict(ctx, maxConflictAttempts, func(int) error {
+		var appends []domain.StreamAppend
+		for i, productID := range productIDs {
+			agg, err := u.load(ctx, productID)
+			if err != nil {
+				return err
+			}
+
+			events, err := decide(i, agg)
+			if err != nil {
+				return err
+			}
+			if len(events) == 0 {
+				continue
+			}
+
+			a, err := u.streamAppend(agg, events)
+			if err != nil {
+				return err
+			}
+			appends = append(appends, a)
+		}
+		return u.eventStore.Append(ctx, appends)
+	})
+}
+
+// streamAppend applies events to agg and builds the append for its stream,
+// queueing a StockChanged message describing the resulting stock.
+func (u *inventoryUseCase) streamAppend(agg *domain.InventoryAggregate, events []domain.Event) (domain.StreamAppend, error) {
+	expectedVersion := agg.Version
+	for _, event := range events {
+		if err := agg.ApplyEvent(event); err != nil {
+			return domain.StreamAppend{}, err
+		}
+	}
+
+	a := agg.Availability()
+	payload, err := json.Marshal(domain.StockChanged{
+		ProductID:     a.ProductID,
+		HardStock:     a.HardStock,
+		ReservedStock: a.ReservedStock,
+		Available:     a.Available,
+		Version:       a.Version,
+		ChangedAt:     time.Now(),
+	})
+	if err != nil {
+		return domain.StreamAppend{}, fmt.Errorf("failed to marshal stock change: %w", err)
+	}
+
+	return domain.StreamAppend{
+		StreamID:        agg.ID,
+		ExpectedVersion: expectedVersion,
+		Events:          events,
+		Outbox: []domain.OutboxMessage{{
+			ID:      uuid.NewString(),
+			Topic:   TopicStockChanged,
+			Key:     agg.ID,
+			Version: agg.Version,
+			Payload: payload,
+		}},
+	}, nil
+}
+
+// Reserve reserves all items or none. Quantities of repeated products are
+// summed so each product stream is appended once.
+func (u *inventoryUseCase) Reserve(ctx context.Context, orderID string, items []domain.Item) error {
+	slog.Info("UseCase: Reserving inventory", "order_id", orderID, "items", len(items))
+
+	var productIDs []string
+	quantities := make(map[string]int)
+	for _, item := range items {
+		if item.Quantity <= 0 {
+			return fmt.Errorf("invalid quantity %d for product %s", item.Quantity, item.ProductID)
+		}
+		if _, ok := quantities[item.ProductID]; !ok {
+			productIDs = append(productIDs, item.ProductID)
+		}
+		quantities[item.ProductID] += item.Quantity
+	}
+
+	expiresAt := time.Now().Add(u.reservationTTL)
+	return u.update(ctx, productIDs, func(i int, agg *domain.InventoryAggregate) ([]domain.Event, error) {
+		if _, ok := agg.Reservations[orderID]; ok {
+			return nil, nil
+		}
+
+		quantity := quantities[agg.ID]
+		if agg.AvailableStock() < quantity {
+			return nil, fmt.Errorf("%w for product %s (available: %d, requested: %d)", domain.ErrInsufficientStock, agg.ID, agg.AvailableStock(), quantity)
+		}
+
+		return []domain.Event{domain.InventoryReserved{
+			OrderID:   orderID,
+			ProductID: agg.ID,
+			Quantity:  quantity,
+			ExpiresAt: expiresAt,
+		}}, nil
+	})
+}
+
+func (u *inventoryUseCase) Hold(ctx context.Context, orderID string, productIDs []string) error {
+	slog.Info("UseCase: Holding inventory", "order_id", orderID, "products", len(productIDs))
+
+	now := time.Now()
+	return u.update(ctx, unique(productIDs), func(i int, agg *domain.InventoryAggregate) ([]domain.Event, error) {
+		r, ok := agg.Reservations[orderID]
+		if !ok || r.Expired(now) {
+			return nil, fmt.Errorf("%w: order %s on product %s", domain.ErrReservationNotFound, orderID, agg.ID)
+		}
+		if r.ExpiresAt.IsZero() {
+			return nil, nil
+		}
+
+		return []domain.Event{domain.ReservationHeld{
+			OrderID:   orderID,
+			ProductID: agg.ID,
+		}}, nil
+	})
+}
+
+func (u *inventoryUseCase) Release(ctx context.Context, orderID string, productIDs []string) error {
+	slog.Info("UseCase: Releasing inventory", "order_id", orderID, "products", len(productIDs))
+
+	return u.update(ctx, unique(productIDs), func(i int, agg *domain.InventoryAggregate) ([]domain.Event, error) {
+		r, ok := agg.Reservations[orderID]
+		if !ok {
+			return nil, nil
+		}
+
+		return []domain.Event{domain.ReservationReleased{
+			OrderID:   orderID,
+			ProductID: agg.ID,
+			Quantity:  r.Quantity,
+		}}, nil
+	})
+}
+
+func (u *inventoryUseCase) Commit(ctx context.Context, orderID string, productIDs []string) error {
+	slog.Info("UseCase: Committing inventory", "order_id", orderID, "products", len(productIDs))
+
+	return u.update(ctx, unique(productIDs), func(i int, agg *domain.InventoryAggregate) ([]domain.Event, error) {
+		r, ok := agg.Reservations[orderID]
+		if !ok {
+			return nil, nil
+		}
+
+		return []domain.Event{domain.ReservationConfirmed{
+			OrderID:   orderID,
+			ProductID: agg.ID,
+			Quantity:  r.Quantity,
+		}}, nil
+	})
+}
+
+func (u *inventoryUseCase) Restock(ctx context.Context, orderID string, productIDs []string, reason string) error {
+	slog.Info("UseCase: Restocking inventory", "order_id", orderID, "products", len(productIDs))
+
+	return u.update(ctx, unique(productIDs), func(i int, agg *domain.InventoryAggregate) ([]domain.Event, error) {
+		quantity, ok := agg.Committed[orderID]
+		if !ok {
+			return nil, nil
+		}
+
+		return []domain.Event{domain.ReservationReturned{
+			OrderID:   orderID,
+			ProductID: agg.ID,
+			Quantity:  quantity,
+			Reason:    reason,
+		}}, nil
+	})
+}
+
+func (u *inventoryUseCase) Adjust(ctx context.Context, productID string, delta int, reason string) (*domain.Availability, error) {
+	slog.Info("UseCase: Adjusting stock", "product_id", productID, "delta", delta, "reason", reason)
+
+	var result domain.Availability
+	err := u.update(ctx, []string{productID}, func(i int, agg *domain.InventoryAggregate) ([]domain.Event, error) {
+		newStock := agg.HardStock + delta
+		if newStock < agg.ReservedStock {
+			return nil, fmt.Errorf("%w: stock of %s cannot drop to %d with %d reserved", domain.ErrInsufficientStock, productID, newStock, agg.ReservedStock)
+		}
+
+		result = domain.Availability{
+			ProductID:     productID,
+			HardStock:     newStock,
+			ReservedStock: agg.ReservedStock,
+			Available:     newStock - agg.ReservedStock,
+			Version:       agg.Version + 1,
+		}
+		return []domain.Event{domain.ProductStockUpdated{
+			ProductID: productID,
+			NewStock:  newStock,
+			Reason:    reason,
+		}}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (u *inventoryUseCase) GetAvailability(ctx context.Context, productIDs []string) ([]domain.Availability, error) {
+	result := make([]domain.Availability, 0, len(productIDs))
+	for _, productID := range productIDs {
+		agg, err := u.load(ctx, productID)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, agg.Availability())
+	}
+	return result, nil
+}
+
+func (u *inventoryUseCase) ReleaseExpired(ctx context.Context, now time.Time) (int, error) {
+	productIDs, err := u.eventStore.ListStreamIDs(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	released := 0
+	for _, productID := range productIDs {
+		var expired int
+		err := u.update(ctx, []string{productID}, func(i int, agg *domain.InventoryAggregate) ([]domain.Event, error) {
+			var events []domain.Event
+			for orderID, r := range agg.Reservations {
+				if !r.Expired(now) {
+					continue
+				}
+				events = append(events, domain.ReservationReleased{
+					OrderID:   orderID,
+					ProductID: productID,
+					Quantity:  r.Quantity,
+					Reason:    "expired",
+				})
+			}
+			expired = len(events)
+			return events, nil
+		})
+		if err != nil {
+			return released, err
+		}
+		if expired > 0 {
+			slog.Info("Released expired reservations", "product_id", productID, "count", expired)
+		}
+		released += expired
+	}
+	return released, nil
+}
+
+func (u *inventoryUseCase) SeedFromCatalog(ctx context.Context) error {
+	products, err := u.catalog.ListProducts(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list catalog products: %w", err)
+	}
+
+	seeded := 0
+	for _, p := range products {
+		var created bool
+		err := u.update(ctx, []string{p.ID}, func(i int, agg *domain.InventoryAggregate) ([]domain.Event, error) {
+			// Another instance may have seeded the product first.
+			created = agg.Version == 0
+			if !created {
+				return nil, nil
+			}
+			return []domain.Event{domain.ProductStockUpdated{
+				ProductID: p.ID,
+				NewStock:  p.Stock,
+				Reason:    "seeded from catalog",
+			}}, nil
+		})
+		if err != nil {
+			return err
+		}
+		if created {
+			seeded++
+		}
+	}
+
+	slog.Info("Inventory seeded from catalog", "products", len(products), "seeded", seeded)
+	return nil
+}
+
+// unique returns ids without duplicates, keeping the first occurrence.
+func unique(ids []string) []string {
+	seen := make(map[string]bool, len(ids))
+	result := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			result = append(result, id)
+		}
+	}
+	return result
+}
@@ -0,0 +1,207 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/egannguyen/go-kafka-ecommerce/eventsourcing/memstore"
+	"github.com/egannguyen/go-kafka-ecommerce/inventory-service/internal/domain"
+)
+
+// memEventStore adapts the shared in-memory store to EventStore. Like the
+// Mongo one, it appends all streams of a call atomically and rejects the call
+// if any stream is no longer at its expected version.
+type memEventStore struct {
+	*memstore.Store
+
+	mu     sync.Mutex
+	outbox []domain.OutboxMessage
+}
+
+func newMemEventStore() *memEventStore {
+	return &memEventStore{Store: memstore.New()}
+}
+
+func (s *memEventStore) Append(ctx context.Context, appends []domain.StreamAppend) error {
+	streams := make([]memstore.StreamAppend, 0, len(appends))
+	var outbox []domain.OutboxMessage
+	for _, a := range appends {
+		streams = append(streams, memstore.StreamAppend{
+			StreamID:        a.StreamID,
+			StreamType:      "inventory",
+			ExpectedVersion: a.ExpectedVersion,
+			Events:          a.Events,
+		})
+		outbox = append(outbox, a.Outbox...)
+	}
+	if err := s.AppendStreams(ctx, streams); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.outbox = append(s.outbox, outbox...)
+	return nil
+}
+
+func (s *memEventStore) ListStreamIDs(ctx context.Context) ([]string, error) {
+	return s.Store.ListStreamIDs(ctx, "inventory")
+}
+
+// versions returns the current version of each stream.
+func (s *memEventStore) versions(t *testing.T, streamIDs ...string) map[string]int {
+	t.Helper()
+	versions := make(map[string]int, len(streamIDs))
+	for _, id := range streamIDs {
+		records, err := s.LoadEvents(context.Background(), id)
+		if err != nil {
+			t.Fatalf("LoadEvents %s: %v", id, err)
+		}
+		versions[id] = len(records)
+	}
+	return versions
+}
+
+func (s *memEventStore) queued() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.outbox)
+}
+
+func TestReserveConcurrentlyNeverOversells(t *testing.T) {
+	tests := []struct {
+		name     string
+		stock    int
+		orders   int
+		quantity int
+	}{
+		{name: "single items", stock: 10, orders: 50, quantity: 1},
+		{name: "stock not divisible by quantity", stock: 10, orders: 50, quantity: 3},
+		{name: "enough stock for every order", stock: 100, orders: 20, quantity: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			store := newMemEventStore()
+			uc := NewInventoryUseCase(store, nil, time.Hour, nil)
+
+			if _, err := uc.Adjust(ctx, "p1", tt.stock, "initial stock"); err != nil {
+				t.Fatalf("Adjust: %v", err)
+			}
+
+			var (
+				wg       sync.WaitGroup
+				mu       sync.Mutex
+				reserved int
+			)
+			for i := 0; i < tt.orders; i++ {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+					err := uc.Reserve(ctx, fmt.Sprintf("order-%d", i), []domain.Item{{ProductID: "p1", Quantity: tt.quantity}})
+					switch {
+					case err == nil:
+						mu.Lock()
+						reserved += tt.quantity
+						mu.Unlock()
+					case errors.Is(err, domain.ErrInsufficientStock), errors.Is(err, domain.ErrConcurrencyConflict):
+					default:
+						t.Errorf("Reserve order-%d: unexpected error: %v", i, err)
+					}
+				}(i)
+			}
+			wg.Wait()
+
+			availability, err := uc.GetAvailability(ctx, []string{"p1"})
+			if err != nil {
+				t.Fatalf("GetAvailability: %v", err)
+			}
+			a := availability[0]
+
+			if reserved == 0 {
+				t.Fatal("no reservation succeeded")
+			}
+			if a.HardStock != tt.stock {
+				t.Errorf("hard stock = %d, want %d", a.HardStock, tt.stock)
+			}
+			if a.ReservedStock != reserved {
+				t.Errorf("reserved stock = %d, want %d reserved by successful calls", a.ReservedStock, reserved)
+			}
+			if a.ReservedStock > a.HardStock {
+				t.Errorf("oversold: reserved %d of %d", a.ReservedStock, a.HardStock)
+			}
+			if a.Available < 0 {
+				t.Errorf("available stock is negative: %d", a.Available)
+			}
+			if want := min(tt.stock/tt.quantity, tt.orders) * tt.quantity; reserved > want {
+				t.Errorf("reserved %d, at most %d fits", reserved, want)
+			}
+		})
+	}
+}
+
+func TestReserveSeveralProductsIsAllOrNothing(t *testing.T) {
+	ctx := context.Background()
+	store := newMemEventStore()
+	uc := NewInventoryUseCase(store, nil, time.Hour, nil)
+
+	stock := map[string]int{"p1": 5, "p2": 1, "p3": 4}
+	for id, quantity := range stock {
+		if _, err := uc.Adjust(ctx, id, quantity, "initial stock"); err != nil {
+			t.Fatalf("Adjust %s: %v", id, err)
+		}
+	}
+	before := store.versions(t, "p1", "p2", "p3")
+	queuedBefore := store.queued()
+
+	// p2 is short; p1 and p3 alone would fit.
+	err := uc.Reserve(ctx, "order-1", []domain.Item{
+		{ProductID: "p1", Quantity: 2},
+		{ProductID: "p2", Quantity: 3},
+		{ProductID: "p3", Quantity: 1},
+	})
+	if !errors.Is(err, domain.ErrInsufficientStock) {
+		t.Fatalf("Reserve error = %v, want %v", err, domain.ErrInsufficientStock)
+	}
+	if after := store.versions(t, "p1", "p2", "p3"); !maps.Equal(after, before) {
+		t.Errorf("stream versions = %v after a failed reservation, want %v", after, before)
+	}
+	if store.queued() != queuedBefore {
+		t.Errorf("queued %d outbox messages for a failed reservation", store.queued()-queuedBefore)
+	}
+
+	availability, err := uc.GetAvailability(ctx, []string{"p1", "p2", "p3"})
+	if err != nil {
+		t.Fatalf("GetAvailability: %v", err)
+	}
+	for _, a := range availability {
+		if a.ReservedStock != 0 {
+			t.Errorf("%s has %d reserved, want none", a.ProductID, a.ReservedStock)
+		}
+	}
+
+	if err := uc.Reserve(ctx, "order-2", []domain.Item{
+		{ProductID: "p1", Quantity: 2},
+		{ProductID: "p2", Quantity: 1},
+		{ProductID: "p1", Quantity: 1},
+	}); err != nil {
+		t.Fatalf("Reserve: %v", err)
+	}
+	after := store.versions(t, "p1", "p2", "p3")
+	if after["p1"] != before["p1"]+1 || after["p2"] != before["p2"]+1 || after["p3"] != before["p3"] {
+		t.Errorf("stream versions = %v, want one event on p1 and p2 since %v", after, before)
+	}
+	availability, err = uc.GetAvailability(ctx, []string{"p1", "p2"})
+	if err != nil {
+		t.Fatalf("GetAvailability: %v", err)
+	}
+	if availability[0].ReservedStock != 3 || availability[1].ReservedStock != 1 {
+		t.Errorf("reserved = %d and %d, want 3 and 1", availability[0].ReservedStock, availability[1].ReservedStock)
+	}
+}
@@ -0,0 +1,40 @@
+package usecase
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// ReservationSweeper periodically releases reservations whose order was never
+// placed, e.g. because the checkout crashed or was abandoned mid-way.
+type ReservationSweeper struct {
+	useCase  InventoryUseCase
+	interval time.Duration
+}
+
+func NewReservationSweeper(useCase InventoryUseCase, interval time.Duration) *ReservationSweeper {
+	return &ReservationSweeper{useCase: useCase, interval: interval}
+}
+
+// Run sweeps until ctx is cancelled.
+func (s *ReservationSweeper) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		n, err := s.useCase.ReleaseExpired(ctx, time.Now())
+		if err != nil {
+			slog.Error("Reservation sweep failed", "err", err)
+		}
+		if n > 0 {
+			slog.Info("Reservation sweep released expired reservations", "count", n)
+		}
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"github.com/egannguyen/go-kafka-ecommerce/payment-service/internal/delivery/grpc"
 	"github.com/egannguyen/go-kafka-ecommerce/payment-service/internal/delivery/grpc/pb"
@@ -27,8 +28,15 @@ func main() {
 	}
 	defer db.Close()
 
+	tokenTTL := time.Hour
+	if v := os.Getenv("PAYMENT_TOKEN_TTL"); v != "" {
+		if tokenTTL, err = time.ParseDuration(v); err != nil {
+			log.Fatal("Invalid PAYMENT_TOKEN_TTL:", err)
+		}
+	}
+
 	repo := postgres.NewTransactionRepository(db)
-	useCase := usecase.NewPaymentUseCase(repo)
+	useCase := usecase.NewPaymentUseCase(repo, postgres.NewTokenRepository(db), tokenTTL)
 	grpcSrv := g.NewServer()
 	pb.RegisterPaymentServiceServer(grpcSrv, grpc.NewServer(useCase))
 
@@ -1,3 +1,18 @@
 module github.com/egannguyen/go-kafka-ecommerce/payment-service
 
 go 1.25
+
+require (
+	github.com/google/uuid v1.6.0
+	github.com/lib/pq v1.10.9
+	google.golang.org/grpc v1.62.1
+)
+
+require (
+	github.com/golang/protobuf v1.5.3 // indirect
+	golang.org/x/net v0.20.0 // indirect
+	golang.org/x/sys v0.16.0 // indirect
+	golang.org/x/text v0.14.0 // indirect
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20240123012728-ef4313101c80 // indirect
+	google.golang.org/protobuf v1.32.0 // indirect
+)
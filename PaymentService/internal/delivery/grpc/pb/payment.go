@@ -9,6 +9,8 @@ import (
 // PaymentServiceServer is the server API for PaymentService service.
 type PaymentServiceServer interface {
 	Charge(context.Context, *ChargeRequest) (*ChargeResponse, error)
+	Refund(context.Context, *RefundRequest) (*RefundResponse, error)
+	Tokenize(context.Context, *TokenizeRequest) (*TokenizeResponse, error)
 	mustEmbedUnimplementedPaymentServiceServer()
 }
 
@@ -18,6 +20,12 @@ type UnimplementedPaymentServiceServer struct{}
 func (UnimplementedPaymentServiceServer) Charge(context.Context, *ChargeRequest) (*ChargeResponse, error) {
 	return nil, nil
 }
+func (UnimplementedPaymentServiceServer) Refund(context.Context, *RefundRequest) (*RefundResponse, error) {
+	return nil, nil
+}
+func (UnimplementedPaymentServiceServer) Tokenize(context.Context, *TokenizeRequest) (*TokenizeResponse, error) {
+	return nil, nil
+}
 func (UnimplementedPaymentServiceServer) mustEmbedUnimplementedPaymentServiceServer() {}
 
 func RegisterPaymentServiceServer(s grpc.ServiceRegistrar, srv PaymentServiceServer) {
@@ -32,6 +40,14 @@ var PaymentService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "Charge",
 			Handler:    _PaymentService_Charge_Handler,
 		},
+		{
+			MethodName: "Refund",
+			Handler:    _PaymentService_Refund_Handler,
+		},
+		{
+			MethodName: "Tokenize",
+			Handler:    _PaymentService_Tokenize_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "payment.proto",
@@ -55,6 +71,42 @@ func _PaymentService_Charge_Handler(srv interface{}, ctx context.Context, dec fu
 	return interceptor(ctx, in, info, handler)
 }
 
+func _PaymentService_Refund_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RefundRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PaymentServiceServer).Refund(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/payment.PaymentService/Refund",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PaymentServiceServer).Refund(ctx, req.(*RefundRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PaymentService_Tokenize_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TokenizeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PaymentServiceServer).Tokenize(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/payment.PaymentService/Tokenize",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PaymentServiceServer).Tokenize(ctx, req.(*TokenizeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 type CreditCardInfo struct {
 	Number          string `json:"number,omitempty"`
 	Cvv             int32  `json:"cvv,omitempty"`
@@ -68,11 +120,30 @@ type Money struct {
 	Nanos        int32  `json:"nanos,omitempty"`
 }
 
+// ChargeRequest charges either CreditCard or the card behind PaymentToken.
 type ChargeRequest struct {
-	Amount     *Money          `json:"amount,omitempty"`
-	CreditCard *CreditCardInfo `json:"credit_card,omitempty"`
+	Amount       *Money          `json:"amount,omitempty"`
+	CreditCard   *CreditCardInfo `json:"credit_card,omitempty"`
+	PaymentToken string          `json:"payment_token,omitempty"`
 }
 
 type ChargeResponse struct {
 	TransactionId string `json:"transaction_id,omitempty"`
 }
+
+type TokenizeRequest struct {
+	CreditCard *CreditCardInfo `json:"credit_card,omitempty"`
+}
+
+type TokenizeResponse struct {
+	PaymentToken string `json:"payment_token,omitempty"`
+}
+
+type RefundRequest struct {
+	TransactionId string `json:"transaction_id,omitempty"`
+}
+
+type RefundResponse struct {
+	TransactionId string `json:"transaction_id,omitempty"`
+	Status        string `json:"status,omitempty"`
+}
@@ -2,9 +2,12 @@ package grpc
 
 import (
 	"context"
+	"errors"
 
 	"github.com/egannguyen/go-kafka-ecommerce/payment-service/internal/delivery/grpc/pb"
 	"github.com/egannguyen/go-kafka-ecommerce/payment-service/internal/domain"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 type Server struct {
@@ -17,23 +20,60 @@ func NewServer(useCase domain.PaymentService) *Server {
 }
 
 func (s *Server) Charge(ctx context.Context, req *pb.ChargeRequest) (*pb.ChargeResponse, error) {
-	card := domain.CreditCardInfo{
-		Number:          req.CreditCard.Number,
-		CVV:             req.CreditCard.Cvv,
-		ExpirationMonth: req.CreditCard.ExpirationMonth,
-		ExpirationYear:  req.CreditCard.ExpirationYear,
+	if req.Amount == nil {
+		return nil, status.Error(codes.InvalidArgument, "missing amount")
 	}
-
 	amount := domain.Money{
 		CurrencyCode: req.Amount.CurrencyCode,
 		Units:        req.Amount.Units,
 		Nanos:        req.Amount.Nanos,
 	}
 
-	txID, err := s.useCase.Charge(ctx, amount, card)
+	var txID string
+	var err error
+	switch {
+	case req.PaymentToken != "":
+		txID, err = s.useCase.ChargeToken(ctx, amount, req.PaymentToken)
+	case req.CreditCard != nil:
+		txID, err = s.useCase.Charge(ctx, amount, toCard(req.CreditCard))
+	default:
+		return nil, status.Error(codes.InvalidArgument, "missing credit card or payment token")
+	}
+	if errors.Is(err, domain.ErrInvalidToken) {
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	}
 	if err != nil {
 		return nil, err
 	}
 
 	return &pb.ChargeResponse{TransactionId: txID}, nil
 }
+
+func (s *Server) Tokenize(ctx context.Context, req *pb.TokenizeRequest) (*pb.TokenizeResponse, error) {
+	if req.CreditCard == nil {
+		return nil, status.Error(codes.InvalidArgument, "missing credit card")
+	}
+
+	token, err := s.useCase.Tokenize(ctx, toCard(req.CreditCard))
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	return &pb.TokenizeResponse{PaymentToken: token}, nil
+}
+
+func toCard(card *pb.CreditCardInfo) domain.CreditCardInfo {
+	return domain.CreditCardInfo{
+		Number:          card.Number,
+		CVV:             card.Cvv,
+		ExpirationMonth: card.ExpirationMonth,
+		ExpirationYear:  card.ExpirationYear,
+	}
+}
+
+func (s *Server) Refund(ctx context.Context, req *pb.RefundRequest) (*pb.RefundResponse, error) {
+	if err := s.useCase.Refund(ctx, req.TransactionId); err != nil {
+		return nil, err
+	}
+
+	return &pb.RefundResponse{TransactionId: req.TransactionId, Status: domain.TransactionStatusRefunded}, nil
+}
@@ -1,6 +1,14 @@
 package domain
 
-import "context"
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrInvalidToken is returned when charging a payment token that is unknown,
+// expired or already used.
+var ErrInvalidToken = errors.New("invalid payment token")
 
 type CreditCardInfo struct {
 	Number          string
@@ -10,14 +18,21 @@ type CreditCardInfo struct {
 }
 
 type Transaction struct {
-	ID            string
-	AmountUnits   int64
-	AmountNanos   int32
-	CurrencyCode  string
+	ID              string
+	AmountUnits     int64
+	AmountNanos     int32
+	CurrencyCode    string
 	CardNumberLast4 string
-	CreatedAt     string
+	Status          string
+	CreatedAt       string
 }
 
+// Transaction statuses.
+const (
+	TransactionStatusCharged  = "charged"
+	TransactionStatusRefunded = "refunded"
+)
+
 type Money struct {
 	CurrencyCode string
 	Units        int64
@@ -26,8 +41,26 @@ type Money struct {
 
 type PaymentService interface {
 	Charge(ctx context.Context, amount Money, card CreditCardInfo) (string, error)
+	// ChargeToken charges the card stored under a token from Tokenize. A token
+	// can be charged only once.
+	ChargeToken(ctx context.Context, amount Money, token string) (string, error)
+	// Tokenize stores a card until it is charged or the token expires.
+	Tokenize(ctx context.Context, card CreditCardInfo) (string, error)
+	Refund(ctx context.Context, transactionID string) error
 }
 
 type TransactionRepository interface {
 	Save(ctx context.Context, tx *Transaction) error
+	FindByID(ctx context.Context, id string) (*Transaction, error)
+	UpdateStatus(ctx context.Context, id string, status string) error
+}
+
+// TokenRepository keeps tokenized cards until they are charged.
+type TokenRepository interface {
+	Save(ctx context.Context, token string, card CreditCardInfo, expiresAt time.Time) error
+	// Take deletes the token and returns its card, or nil if the token is
+	// unknown or expired.
+	Take(ctx context.Context, token string) (*CreditCardInfo, error)
+	// DeleteExpired removes the tokens that expired before now.
+	DeleteExpired(ctx context.Context, now time.Time) error
 }
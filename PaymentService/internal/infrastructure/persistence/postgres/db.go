@@ -35,6 +35,15 @@ func migrateDB(db *sql.DB) error {
 			card_number_last4 TEXT NOT NULL,
 			created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
 		);
+		ALTER TABLE transactions ADD COLUMN IF NOT EXISTS status TEXT NOT NULL DEFAULT 'charged';
+		CREATE TABLE IF NOT EXISTS payment_tokens (
+			token TEXT PRIMARY KEY,
+			card_number TEXT NOT NULL,
+			cvv INT NOT NULL,
+			expiration_month INT NOT NULL,
+			expiration_year INT NOT NULL,
+			expires_at TIMESTAMP WITH TIME ZONE NOT NULL
+		);
 	`)
 	return err
 }
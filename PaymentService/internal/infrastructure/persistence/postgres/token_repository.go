@@ -0,0 +1,54 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/egannguyen/go-kafka-ecommerce/payment-service/internal/domain"
+)
+
+type tokenRepository struct {
+	db *sql.DB
+}
+
+func NewTokenRepository(db *sql.DB) domain.TokenRepository {
+	return &tokenRepository{db: db}
+}
+
+func (r *tokenRepository) Save(ctx context.Context, token string, card domain.CreditCardInfo, expiresAt time.Time) error {
+	_, err := r.db.ExecContext(ctx,
+		"INSERT INTO payment_tokens (token, card_number, cvv, expiration_month, expiration_year, expires_at) VALUES ($1, $2, $3, $4, $5, $6)",
+		token, card.Number, card.CVV, card.ExpirationMonth, card.ExpirationYear, expiresAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save payment token: %w", err)
+	}
+	return nil
+}
+
+// Take deletes the token in the same statement that reads it, so concurrent
+// charges of one token cannot both get the card.
+func (r *tokenRepository) Take(ctx context.Context, token string) (*domain.CreditCardInfo, error) {
+	var card domain.CreditCardInfo
+	err := r.db.QueryRowContext(ctx,
+		"DELETE FROM payment_tokens WHERE token = $1 AND expires_at > now() RETURNING card_number, cvv, expiration_month, expiration_year",
+		token,
+	).Scan(&card.Number, &card.CVV, &card.ExpirationMonth, &card.ExpirationYear)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to take payment token: %w", err)
+	}
+	return &card, nil
+}
+
+func (r *tokenRepository) DeleteExpired(ctx context.Context, now time.Time) error {
+	_, err := r.db.ExecContext(ctx, "DELETE FROM payment_tokens WHERE expires_at <= $1", now)
+	if err != nil {
+		return fmt.Errorf("failed to delete expired payment tokens: %w", err)
+	}
+	return nil
+}
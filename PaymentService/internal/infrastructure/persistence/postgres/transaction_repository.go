@@ -18,11 +18,34 @@ func NewTransactionRepository(db *sql.DB) domain.TransactionRepository {
 
 func (r *transactionRepository) Save(ctx context.Context, tx *domain.Transaction) error {
 	_, err := r.db.ExecContext(ctx,
-		"INSERT INTO transactions (id, amount_units, amount_nanos, currency_code, card_number_last4) VALUES ($1, $2, $3, $4, $5)",
-		tx.ID, tx.AmountUnits, tx.AmountNanos, tx.CurrencyCode, tx.CardNumberLast4,
+		"INSERT INTO transactions (id, amount_units, amount_nanos, currency_code, card_number_last4, status) VALUES ($1, $2, $3, $4, $5, $6)",
+		tx.ID, tx.AmountUnits, tx.AmountNanos, tx.CurrencyCode, tx.CardNumberLast4, tx.Status,
 	)
 	if err != nil {
 		return fmt.Errorf("failed to save transaction: %w", err)
 	}
 	return nil
 }
+
+func (r *transactionRepository) FindByID(ctx context.Context, id string) (*domain.Transaction, error) {
+	var tx domain.Transaction
+	err := r.db.QueryRowContext(ctx,
+		"SELECT id, amount_units, amount_nanos, currency_code, card_number_last4, status, created_at FROM transactions WHERE id = $1",
+		id,
+	).Scan(&tx.ID, &tx.AmountUnits, &tx.AmountNanos, &tx.CurrencyCode, &tx.CardNumberLast4, &tx.Status, &tx.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get transaction: %w", err)
+	}
+	return &tx, nil
+}
+
+func (r *transactionRepository) UpdateStatus(ctx context.Context, id string, status string) error {
+	_, err := r.db.ExecContext(ctx, "UPDATE transactions SET status = $1 WHERE id = $2", status, id)
+	if err != nil {
+		return fmt.Errorf("failed to update transaction status: %w", err)
+	}
+	return nil
+}
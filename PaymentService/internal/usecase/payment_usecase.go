@@ -3,22 +3,63 @@ package usecase
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/egannguyen/go-kafka-ecommerce/payment-service/internal/domain"
 	"github.com/google/uuid"
 )
 
 type paymentUseCase struct {
-	repo domain.TransactionRepository
+	repo     domain.TransactionRepository
+	tokens   domain.TokenRepository
+	tokenTTL time.Duration
 }
 
-func NewPaymentUseCase(repo domain.TransactionRepository) domain.PaymentService {
-	return &paymentUseCase{repo: repo}
+// NewPaymentUseCase creates the use case. Payment tokens expire after tokenTTL
+// unless charged first.
+func NewPaymentUseCase(repo domain.TransactionRepository, tokens domain.TokenRepository, tokenTTL time.Duration) domain.PaymentService {
+	return &paymentUseCase{repo: repo, tokens: tokens, tokenTTL: tokenTTL}
 }
 
-func (u *paymentUseCase) Charge(ctx context.Context, amount domain.Money, card domain.CreditCardInfo) (string, error) {
+func (u *paymentUseCase) Tokenize(ctx context.Context, card domain.CreditCardInfo) (string, error) {
+	if err := validateCard(card); err != nil {
+		return "", err
+	}
+
+	// Expired tokens were never charged; drop them as new ones come in.
+	now := time.Now()
+	if err := u.tokens.DeleteExpired(ctx, now); err != nil {
+		return "", fmt.Errorf("failed to delete expired payment tokens: %w", err)
+	}
+
+	token := uuid.NewString()
+	if err := u.tokens.Save(ctx, token, card, now.Add(u.tokenTTL)); err != nil {
+		return "", fmt.Errorf("failed to persist payment token: %w", err)
+	}
+	return token, nil
+}
+
+func (u *paymentUseCase) ChargeToken(ctx context.Context, amount domain.Money, token string) (string, error) {
+	card, err := u.tokens.Take(ctx, token)
+	if err != nil {
+		return "", fmt.Errorf("failed to load payment token: %w", err)
+	}
+	if card == nil {
+		return "", domain.ErrInvalidToken
+	}
+	return u.Charge(ctx, amount, *card)
+}
+
+func validateCard(card domain.CreditCardInfo) error {
 	if len(card.Number) < 13 {
-		return "", fmt.Errorf("invalid credit card number length")
+		return fmt.Errorf("invalid credit card number length")
+	}
+	return nil
+}
+
+func (u *paymentUseCase) Charge(ctx context.Context, amount domain.Money, card domain.CreditCardInfo) (string, error) {
+	if err := validateCard(card); err != nil {
+		return "", err
 	}
 
 	transactionID := uuid.New().String()
@@ -35,6 +76,7 @@ func (u *paymentUseCase) Charge(ctx context.Context, amount domain.Money, card d
 		AmountNanos:     amount.Nanos,
 		CurrencyCode:    amount.CurrencyCode,
 		CardNumberLast4: last4,
+		Status:          domain.TransactionStatusCharged,
 	}
 
 	if err := u.repo.Save(ctx, tx); err != nil {
@@ -43,3 +85,22 @@ func (u *paymentUseCase) Charge(ctx context.Context, amount domain.Money, card d
 
 	return transactionID, nil
 }
+
+// Refund voids a previous charge. Refunding an already refunded transaction is a no-op.
+func (u *paymentUseCase) Refund(ctx context.Context, transactionID string) error {
+	tx, err := u.repo.FindByID(ctx, transactionID)
+	if err != nil {
+		return fmt.Errorf("failed to load transaction: %w", err)
+	}
+	if tx == nil {
+		return fmt.Errorf("transaction %s not found", transactionID)
+	}
+	if tx.Status == domain.TransactionStatusRefunded {
+		return nil
+	}
+
+	if err := u.repo.UpdateStatus(ctx, transactionID, domain.TransactionStatusRefunded); err != nil {
+		return fmt.Errorf("failed to refund transaction: %w", err)
+	}
+	return nil
+}
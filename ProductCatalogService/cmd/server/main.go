@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"log"
 	"log/slog"
 	stdhttp "net/http"
@@ -13,6 +14,8 @@ import (
 	deliveryGrpc "github.com/egannguyen/go-kafka-ecommerce/product-catalog-service/internal/delivery/grpc"
 	"github.com/egannguyen/go-kafka-ecommerce/product-catalog-service/internal/delivery/grpc/pb"
 	deliveryHttp "github.com/egannguyen/go-kafka-ecommerce/product-catalog-service/internal/delivery/http"
+	"github.com/egannguyen/go-kafka-ecommerce/product-catalog-service/internal/domain"
+	"github.com/egannguyen/go-kafka-ecommerce/product-catalog-service/internal/infrastructure/messaging/kafka"
 	"github.com/egannguyen/go-kafka-ecommerce/product-catalog-service/internal/infrastructure/persistence/mongodb"
 	"github.com/egannguyen/go-kafka-ecommerce/product-catalog-service/internal/usecase"
 	stdgrpc "google.golang.org/grpc"
@@ -43,6 +46,22 @@ func main() {
 		Handler: deliveryHttp.EnableCORS(mux),
 	}
 
+	// Kafka Consumer: inventory.stock_changed -> Product.Stock
+	kafkaBrokers := os.Getenv("KAFKA_BROKERS")
+	if kafkaBrokers == "" {
+		kafkaBrokers = "localhost:9092"
+	}
+	consumeCtx, stopConsumers := context.WithCancel(context.Background())
+	defer stopConsumers()
+	subscriber := kafka.NewKafkaSubscriber([]string{kafkaBrokers})
+	go subscriber.Consume(consumeCtx, "inventory.stock_changed", "productcatalog-stock", func(ctx context.Context, payload []byte) error {
+		var event domain.StockChanged
+		if err := json.Unmarshal(payload, &event); err != nil {
+			return err
+		}
+		return catalogUseCase.HandleStockChanged(ctx, &event)
+	})
+
 	// gRPC Server
 	grpcSrv := stdgrpc.NewServer()
 	pb.RegisterProductCatalogServiceServer(grpcSrv, deliveryGrpc.NewServer(catalogUseCase))
@@ -70,6 +89,7 @@ func main() {
 	<-quit
 
 	slog.Info("Shutting down server...")
+	stopConsumers()
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 	if err := srv.Shutdown(ctx); err != nil {
@@ -5,6 +5,8 @@ import (
 
 	"github.com/egannguyen/go-kafka-ecommerce/product-catalog-service/internal/delivery/grpc/pb"
 	"github.com/egannguyen/go-kafka-ecommerce/product-catalog-service/internal/usecase"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 type Server struct {
@@ -22,7 +24,7 @@ func (s *Server) GetProduct(ctx context.Context, req *pb.GetProductRequest) (*pb
 		return nil, err
 	}
 	if p == nil {
-		return nil, nil // Should ideally return gRPC NotFound error
+		return nil, status.Errorf(codes.NotFound, "product %s not found", req.Id)
 	}
 
 	return &pb.Product{
@@ -10,11 +10,27 @@ type Product struct {
 	Price       float64 `json:"price" bson:"price"`
 	ImageURL    string  `json:"image_url" bson:"image_url"`
 	Category    string  `json:"category" bson:"category"`
-	Stock       int     `json:"stock" bson:"stock"`
+	Stock       int     `json:"stock" bson:"stock"` // available stock, mirrored from InventoryService
+}
+
+// StockChanged is published by InventoryService after every inventory change.
+type StockChanged struct {
+	ProductID     string `json:"product_id"`
+	HardStock     int    `json:"hard_stock"`
+	ReservedStock int    `json:"reserved_stock"`
+	Available     int    `json:"available"`
+	Version       int    `json:"version"`
 }
 
 type ProductRepository interface {
 	FindAll(ctx context.Context) ([]Product, error)
 	FindByID(ctx context.Context, id string) (*Product, error)
 	Update(ctx context.Context, product *Product) error
+	// UpdateStock sets the stock of a product unless a change with the same or
+	// a newer inventory version was already applied.
+	UpdateStock(ctx context.Context, productID string, stock int, version int) error
+}
+
+type Subscriber interface {
+	Consume(ctx context.Context, topic string, groupID string, handler func(context.Context, []byte) error) error
 }
@@ -0,0 +1,48 @@
+package kafka
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/egannguyen/go-kafka-ecommerce/product-catalog-service/internal/domain"
+	kafkaGo "github.com/segmentio/kafka-go"
+)
+
+type kafkaSubscriber struct {
+	brokers []string
+}
+
+func NewKafkaSubscriber(brokers []string) domain.Subscriber {
+	return &kafkaSubscriber{brokers: brokers}
+}
+
+// Consume reads topic with the given consumer group until ctx is cancelled.
+// Failed messages are logged and skipped: the topics the catalog consumes
+// carry full state, so the next message for the same key supersedes them.
+func (k *kafkaSubscriber) Consume(ctx context.Context, topic string, groupID string, handler func(ctx context.Context, payload []byte) error) error {
+	reader := kafkaGo.NewReader(kafkaGo.ReaderConfig{
+		Brokers: k.brokers,
+		Topic:   topic,
+		GroupID: groupID,
+	})
+	defer reader.Close()
+
+	for {
+		msg, err := reader.FetchMessage(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			slog.Error("Error reading message", "topic", topic, "err", err)
+			continue
+		}
+
+		if err := handler(ctx, msg.Value); err != nil {
+			slog.Error("Error handling message", "topic", topic, "offset", msg.Offset, "err", err)
+		}
+
+		if err := reader.CommitMessages(ctx, msg); err != nil {
+			slog.Error("Failed to commit offset", "topic", topic, "offset", msg.Offset, "err", err)
+		}
+	}
+}
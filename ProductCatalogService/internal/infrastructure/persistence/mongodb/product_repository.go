@@ -56,3 +56,19 @@ func (r *productRepository) Update(ctx context.Context, p *domain.Product) error
 	}
 	return nil
 }
+
+func (r *productRepository) UpdateStock(ctx context.Context, productID string, stock int, version int) error {
+	coll := r.db.Collection("products")
+	filter := bson.M{
+		"id": productID,
+		"$or": []bson.M{
+			{"stock_version": bson.M{"$exists": false}},
+			{"stock_version": bson.M{"$lt": version}},
+		},
+	}
+	update := bson.M{"$set": bson.M{"stock": stock, "stock_version": version}}
+	if _, err := coll.UpdateOne(ctx, filter, update); err != nil {
+		return fmt.Errorf("failed to update product stock: %w", err)
+	}
+	return nil
+}
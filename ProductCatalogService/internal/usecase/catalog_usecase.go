@@ -9,6 +9,7 @@ import (
 type CatalogUseCase interface {
 	ListProducts(ctx context.Context) ([]domain.Product, error)
 	GetProduct(ctx context.Context, id string) (*domain.Product, error)
+	HandleStockChanged(ctx context.Context, event *domain.StockChanged) error
 }
 
 type catalogUseCase struct {
@@ -26,3 +27,9 @@ func (u *catalogUseCase) ListProducts(ctx context.Context) ([]domain.Product, er
 func (u *catalogUseCase) GetProduct(ctx context.Context, id string) (*domain.Product, error) {
 	return u.repo.FindByID(ctx, id)
 }
+
+// HandleStockChanged mirrors the available stock reported by InventoryService.
+// Redelivered or out-of-order changes are ignored by version.
+func (u *catalogUseCase) HandleStockChanged(ctx context.Context, event *domain.StockChanged) error {
+	return u.repo.UpdateStock(ctx, event.ProductID, event.Available, event.Version)
+}
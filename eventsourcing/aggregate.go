@@ -0,0 +1,39 @@
+package eventsourcing
+
+import "fmt"
+
+// AggregateBase provides a basic implementation for an aggregate.
+type AggregateBase struct {
+	ID      string
+	Version int
+}
+
+func (a *AggregateBase) GetAggregateID() string {
+	return a.ID
+}
+
+func (a *AggregateBase) GetVersion() int {
+	return a.Version
+}
+
+// Aggregate is state rebuilt by applying the events of its stream in order.
+// ApplyEvent increments the version for every event applied.
+type Aggregate interface {
+	GetAggregateID() string
+	GetVersion() int
+	ApplyEvent(e Event) error
+}
+
+// Rehydrate decodes records with registry and applies them to agg in order.
+func Rehydrate(agg Aggregate, registry *Registry, records []EventRecord) error {
+	for _, rec := range records {
+		e, err := registry.Decode(rec)
+		if err != nil {
+			return err
+		}
+		if err := agg.ApplyEvent(e); err != nil {
+			return fmt.Errorf("failed to apply event from stream: %w", err)
+		}
+	}
+	return nil
+}
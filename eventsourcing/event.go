@@ -0,0 +1,64 @@
+// Package eventsourcing holds the event sourcing building blocks shared by the
+// services: stored event records and snapshots, a registry decoding records
+// into their Go event types, aggregate rehydration and the event store
+// interface, implemented by the mongostore, pgstore and memstore packages.
+package eventsourcing
+
+import "time"
+
+// Event is a domain event. EventType names it in the store and must be
+// registered in the Registry used to decode the stream.
+type Event interface {
+	EventType() string
+}
+
+// Versioned is implemented by events whose payload schema has changed since
+// they were introduced. Events that do not implement it are at version 1.
+type Versioned interface {
+	SchemaVersion() int
+}
+
+// SchemaVersionOf returns the current payload schema version of e.
+func SchemaVersionOf(e Event) int {
+	if v, ok := e.(Versioned); ok {
+		return v.SchemaVersion()
+	}
+	return 1
+}
+
+// EventRecord represents an event stored in the event store.
+type EventRecord struct {
+	ID         string `json:"id" bson:"id"`
+	StreamID   string `json:"stream_id" bson:"stream_id"`
+	StreamType string `json:"stream_type" bson:"stream_type"`
+	Version    int    `json:"version" bson:"version"`
+	Position   int64  `json:"position" bson:"position"` // global, gapless across all streams
+	EventType  string `json:"event_type" bson:"event_type"`
+	// SchemaVersion is the version of the payload schema; records stored
+	// before versioning have none and are at version 1.
+	SchemaVersion int       `json:"schema_version,omitempty" bson:"schema_version,omitempty"`
+	Payload       []byte    `json:"payload" bson:"payload"`
+	CreatedAt     time.Time `json:"created_at" bson:"created_at"`
+}
+
+// Snapshot is the serialized state of an aggregate at a given stream version.
+type Snapshot struct {
+	StreamID      string    `json:"stream_id" bson:"stream_id"`
+	StreamType    string    `json:"stream_type" bson:"stream_type"`
+	Version       int       `json:"version" bson:"version"`
+	SchemaVersion int       `json:"schema_version" bson:"schema_version"`
+	State         []byte    `json:"state" bson:"state"`
+	CreatedAt     time.Time `json:"created_at" bson:"created_at"`
+}
+
+// SnapshotPolicy maps a stream type to the number of events that may be
+// replayed on load before a new snapshot is taken. Stream types without a
+// positive interval are never snapshotted.
+type SnapshotPolicy map[string]int
+
+// ShouldSnapshot reports whether replaying the given number of events past the
+// latest snapshot warrants a new one.
+func (p SnapshotPolicy) ShouldSnapshot(streamType string, replayed int) bool {
+	interval := p[streamType]
+	return interval > 0 && replayed >= interval
+}
@@ -0,0 +1,260 @@
+// Package memstore is an in-memory event store for tests and local runs.
+// Events are lost when the process exits.
+package memstore
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	es "github.com/egannguyen/go-kafka-ecommerce/eventsourcing"
+	"github.com/google/uuid"
+)
+
+type Store struct {
+	mu        sync.RWMutex
+	events    []es.EventRecord // in position order; position i+1 at index i
+	streams   map[string][]int // stream ID -> indexes into events
+	snapshots map[string][]es.Snapshot
+	appended  chan struct{} // closed and replaced on every append
+}
+
+func New() *Store {
+	return &Store{
+		streams:   make(map[string][]int),
+		snapshots: make(map[string][]es.Snapshot),
+		appended:  make(chan struct{}),
+	}
+}
+
+var _ es.GlobalEventStore = (*Store)(nil)
+
+func (s *Store) SaveEvents(ctx context.Context, streamID string, streamType string, expectedVersion int, events []es.Event) error {
+	return s.AppendStreams(ctx, []StreamAppend{{
+		StreamID:        streamID,
+		StreamType:      streamType,
+		ExpectedVersion: expectedVersion,
+		Events:          events,
+	}})
+}
+
+// StreamAppend is a batch of events to append to one stream.
+type StreamAppend struct {
+	StreamID        string
+	StreamType      string
+	ExpectedVersion int
+	Events          []es.Event
+}
+
+// AppendStreams appends to several streams atomically, like the mongostore
+// method: either every stream is appended or none is. It fails with
+// ErrConcurrencyConflict if any stream is no longer at its expected version.
+func (s *Store) AppendStreams(ctx context.Context, appends []StreamAppend) error {
+	payloads := make([][][]byte, len(appends))
+	total := 0
+	for i, a := range appends {
+		payloads[i] = make([][]byte, len(a.Events))
+		for j, event := range a.Events {
+			payload, err := json.Marshal(event)
+			if err != nil {
+				return fmt.Errorf("failed to marshal event %s: %w", event.EventType(), err)
+			}
+			payloads[i][j] = payload
+		}
+		total += len(a.Events)
+	}
+	if total == 0 {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// A stream appended twice in one call is checked against its version
+	// after the earlier append, as in a transaction.
+	pending := make(map[string]int)
+	for _, a := range appends {
+		currentVersion := len(s.streams[a.StreamID]) + pending[a.StreamID]
+		if a.ExpectedVersion != es.AnyVersion && currentVersion != a.ExpectedVersion {
+			return fmt.Errorf("%w: stream %s expected version %d, got %d", es.ErrConcurrencyConflict, a.StreamID, a.ExpectedVersion, currentVersion)
+		}
+		pending[a.StreamID] += len(a.Events)
+	}
+
+	now := time.Now()
+	for i, a := range appends {
+		for j, event := range a.Events {
+			s.streams[a.StreamID] = append(s.streams[a.StreamID], len(s.events))
+			s.events = append(s.events, es.EventRecord{
+				ID:            uuid.NewString(),
+				StreamID:      a.StreamID,
+				StreamType:    a.StreamType,
+				Version:       len(s.streams[a.StreamID]),
+				Position:      int64(len(s.events) + 1),
+				EventType:     event.EventType(),
+				SchemaVersion: es.SchemaVersionOf(event),
+				Payload:       payloads[i][j],
+				CreatedAt:     now,
+			})
+		}
+	}
+
+	close(s.appended)
+	s.appended = make(chan struct{})
+	return nil
+}
+
+// ListStreamIDs returns the IDs of the streams of streamType.
+func (s *Store) ListStreamIDs(ctx context.Context, streamType string) ([]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var ids []string
+	for id, indexes := range s.streams {
+		if len(indexes) > 0 && s.events[indexes[0]].StreamType == streamType {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+func (s *Store) LoadEvents(ctx context.Context, streamID string) ([]es.EventRecord, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.stream(streamID, 0), nil
+}
+
+func (s *Store) LoadFromSnapshot(ctx context.Context, streamID string, schemaVersion int) (*es.Snapshot, []es.EventRecord, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var snapshot *es.Snapshot
+	for _, snap := range s.snapshots[streamID] {
+		if snap.SchemaVersion == schemaVersion && (snapshot == nil || snap.Version > snapshot.Version) {
+			snap := snap
+			snapshot = &snap
+		}
+	}
+
+	fromVersion := 0
+	if snapshot != nil {
+		fromVersion = snapshot.Version
+	}
+	return snapshot, s.stream(streamID, fromVersion), nil
+}
+
+// stream returns the events of a stream after fromVersion. The caller holds mu.
+func (s *Store) stream(streamID string, fromVersion int) []es.EventRecord {
+	indexes := s.streams[streamID]
+	if fromVersion >= len(indexes) {
+		return nil
+	}
+
+	records := make([]es.EventRecord, 0, len(indexes)-fromVersion)
+	for _, i := range indexes[fromVersion:] {
+		records = append(records, s.events[i])
+	}
+	return records
+}
+
+func (s *Store) SaveSnapshot(ctx context.Context, snapshot es.Snapshot) error {
+	if snapshot.CreatedAt.IsZero() {
+		snapshot.CreatedAt = time.Now()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Snapshots are immutable per version; a concurrent writer may already have stored this one.
+	for _, snap := range s.snapshots[snapshot.StreamID] {
+		if snap.SchemaVersion == snapshot.SchemaVersion && snap.Version == snapshot.Version {
+			return nil
+		}
+	}
+	s.snapshots[snapshot.StreamID] = append(s.snapshots[snapshot.StreamID], snapshot)
+	return nil
+}
+
+func (s *Store) ForEachEvent(ctx context.Context, streamType string, fn func(es.EventRecord) error) error {
+	s.mu.RLock()
+	var records []es.EventRecord
+	for _, rec := range s.events {
+		if rec.StreamType == streamType {
+			records = append(records, rec)
+		}
+	}
+	s.mu.RUnlock()
+
+	for _, rec := range records {
+		if err := fn(rec); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Store) CountEvents(ctx context.Context, streamType string) (int64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var n int64
+	for _, rec := range s.events {
+		if rec.StreamType == streamType {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (s *Store) ReadAll(ctx context.Context, fromPosition int64, limit int) ([]es.EventRecord, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	records, _ := s.readAll(fromPosition, limit)
+	return records, nil
+}
+
+// readAll returns up to limit events after fromPosition, and a channel closed
+// on the next append. The caller holds mu.
+func (s *Store) readAll(fromPosition int64, limit int) ([]es.EventRecord, <-chan struct{}) {
+	if fromPosition < 0 {
+		fromPosition = 0
+	}
+	if fromPosition >= int64(len(s.events)) {
+		return nil, s.appended
+	}
+
+	end := len(s.events)
+	if limit > 0 && int(fromPosition)+limit < end {
+		end = int(fromPosition) + limit
+	}
+	records := make([]es.EventRecord, end-int(fromPosition))
+	copy(records, s.events[fromPosition:end])
+	return records, s.appended
+}
+
+func (s *Store) SubscribeAll(ctx context.Context, fromPosition int64, handler func(es.EventRecord) error) error {
+	position := fromPosition
+	for {
+		s.mu.RLock()
+		records, appended := s.readAll(position, 0)
+		s.mu.RUnlock()
+
+		for _, rec := range records {
+			if err := handler(rec); err != nil {
+				return err
+			}
+			position = rec.Position
+		}
+		if len(records) > 0 {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-appended:
+		}
+	}
+}
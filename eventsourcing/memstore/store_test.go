@@ -0,0 +1,199 @@
+package memstore
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	es "github.com/egannguyen/go-kafka-ecommerce/eventsourcing"
+)
+
+type itemAdded struct {
+	ProductID string `json:"product_id"`
+}
+
+func (itemAdded) EventType() string { return "ItemAdded" }
+
+func TestSaveEventsChecksExpectedVersion(t *testing.T) {
+	tests := []struct {
+		name            string
+		existing        int
+		expectedVersion int
+		wantConflict    bool
+	}{
+		{name: "new stream", existing: 0, expectedVersion: 0},
+		{name: "at expected version", existing: 2, expectedVersion: 2},
+		{name: "stream is ahead", existing: 2, expectedVersion: 1, wantConflict: true},
+		{name: "stream is behind", existing: 2, expectedVersion: 3, wantConflict: true},
+		{name: "stream exists but expected new", existing: 1, expectedVersion: 0, wantConflict: true},
+		{name: "any version", existing: 2, expectedVersion: es.AnyVersion},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			s := New()
+			for i := 0; i < tt.existing; i++ {
+				if err := s.SaveEvents(ctx, "cart-1", "cart", i, []es.Event{itemAdded{ProductID: "p1"}}); err != nil {
+					t.Fatalf("SaveEvents: %v", err)
+				}
+			}
+
+			err := s.SaveEvents(ctx, "cart-1", "cart", tt.expectedVersion, []es.Event{itemAdded{ProductID: "p2"}, itemAdded{ProductID: "p3"}})
+			if got := errors.Is(err, es.ErrConcurrencyConflict); got != tt.wantConflict {
+				t.Fatalf("SaveEvents error = %v, want conflict %v", err, tt.wantConflict)
+			}
+			if err != nil && !tt.wantConflict {
+				t.Fatalf("SaveEvents: %v", err)
+			}
+
+			records, _ := s.LoadEvents(ctx, "cart-1")
+			want := tt.existing + 2
+			if tt.wantConflict {
+				want = tt.existing
+			}
+			if len(records) != want {
+				t.Fatalf("stream has %d events, want %d", len(records), want)
+			}
+			for i, rec := range records {
+				if rec.Version != i+1 || rec.Position != int64(i+1) {
+					t.Errorf("event %d has version %d and position %d, want %d", i, rec.Version, rec.Position, i+1)
+				}
+			}
+		})
+	}
+}
+
+func TestSaveEventsConcurrentWritersConflict(t *testing.T) {
+	ctx := context.Background()
+	s := New()
+
+	const writers = 20
+	var (
+		wg        sync.WaitGroup
+		mu        sync.Mutex
+		saved     int
+		conflicts int
+	)
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := s.SaveEvents(ctx, "cart-1", "cart", 0, []es.Event{itemAdded{ProductID: "p1"}})
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case err == nil:
+				saved++
+			case errors.Is(err, es.ErrConcurrencyConflict):
+				conflicts++
+			default:
+				t.Errorf("SaveEvents: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if saved != 1 || conflicts != writers-1 {
+		t.Errorf("saved %d and conflicted %d, want 1 and %d", saved, conflicts, writers-1)
+	}
+	if records, _ := s.LoadEvents(ctx, "cart-1"); len(records) != 1 {
+		t.Errorf("stream has %d events, want 1", len(records))
+	}
+}
+
+func TestLoadFromSnapshot(t *testing.T) {
+	ctx := context.Background()
+	s := New()
+	for i := 0; i < 5; i++ {
+		if err := s.SaveEvents(ctx, "cart-1", "cart", i, []es.Event{itemAdded{ProductID: "p1"}}); err != nil {
+			t.Fatalf("SaveEvents: %v", err)
+		}
+	}
+	for _, snap := range []es.Snapshot{
+		{StreamID: "cart-1", Version: 2, SchemaVersion: 1},
+		{StreamID: "cart-1", Version: 3, SchemaVersion: 1},
+		{StreamID: "cart-1", Version: 4, SchemaVersion: 2},
+	} {
+		if err := s.SaveSnapshot(ctx, snap); err != nil {
+			t.Fatalf("SaveSnapshot: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name          string
+		schemaVersion int
+		wantSnapshot  int // 0 for none
+		wantEvents    int
+	}{
+		{name: "latest snapshot of the schema", schemaVersion: 1, wantSnapshot: 3, wantEvents: 2},
+		{name: "other schema", schemaVersion: 2, wantSnapshot: 4, wantEvents: 1},
+		{name: "no snapshot of the schema", schemaVersion: 3, wantEvents: 5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			snap, records, err := s.LoadFromSnapshot(ctx, "cart-1", tt.schemaVersion)
+			if err != nil {
+				t.Fatalf("LoadFromSnapshot: %v", err)
+			}
+			switch {
+			case tt.wantSnapshot == 0 && snap != nil:
+				t.Errorf("snapshot at version %d, want none", snap.Version)
+			case tt.wantSnapshot != 0 && (snap == nil || snap.Version != tt.wantSnapshot):
+				t.Errorf("snapshot = %+v, want version %d", snap, tt.wantSnapshot)
+			}
+			if len(records) != tt.wantEvents {
+				t.Fatalf("got %d events after the snapshot, want %d", len(records), tt.wantEvents)
+			}
+			if first := records[0].Version; first != tt.wantSnapshot+1 {
+				t.Errorf("first event has version %d, want %d", first, tt.wantSnapshot+1)
+			}
+		})
+	}
+}
+
+func TestAppendStreamsIsAllOrNothing(t *testing.T) {
+	ctx := context.Background()
+	s := New()
+	if err := s.SaveEvents(ctx, "p1", "inventory", 0, []es.Event{itemAdded{ProductID: "p1"}}); err != nil {
+		t.Fatalf("SaveEvents: %v", err)
+	}
+
+	err := s.AppendStreams(ctx, []StreamAppend{
+		{StreamID: "p1", StreamType: "inventory", ExpectedVersion: 1, Events: []es.Event{itemAdded{ProductID: "p1"}}},
+		{StreamID: "p2", StreamType: "inventory", ExpectedVersion: 1, Events: []es.Event{itemAdded{ProductID: "p2"}}},
+	})
+	if !errors.Is(err, es.ErrConcurrencyConflict) {
+		t.Fatalf("AppendStreams error = %v, want a conflict on p2", err)
+	}
+	if records, _ := s.LoadEvents(ctx, "p1"); len(records) != 1 {
+		t.Errorf("p1 has %d events after a failed append, want 1", len(records))
+	}
+
+	err = s.AppendStreams(ctx, []StreamAppend{
+		{StreamID: "p1", StreamType: "inventory", ExpectedVersion: 1, Events: []es.Event{itemAdded{ProductID: "p1"}}},
+		{StreamID: "p1", StreamType: "inventory", ExpectedVersion: 2, Events: []es.Event{itemAdded{ProductID: "p1"}}},
+		{StreamID: "p2", StreamType: "inventory", ExpectedVersion: 0, Events: []es.Event{itemAdded{ProductID: "p2"}}},
+	})
+	if err != nil {
+		t.Fatalf("AppendStreams: %v", err)
+	}
+	if records, _ := s.LoadEvents(ctx, "p1"); len(records) != 3 || records[2].Version != 3 {
+		t.Errorf("p1 = %+v, want 3 events", records)
+	}
+	all, _ := s.ReadAll(ctx, 0, 0)
+	for i, rec := range all {
+		if rec.Position != int64(i+1) {
+			t.Errorf("event %d has position %d", i, rec.Position)
+		}
+	}
+
+	ids, _ := s.ListStreamIDs(ctx, "inventory")
+	if len(ids) != 2 {
+		t.Errorf("ListStreamIDs = %v, want p1 and p2", ids)
+	}
+	if ids, _ := s.ListStreamIDs(ctx, "cart"); len(ids) != 0 {
+		t.Errorf("ListStreamIDs(cart) = %v, want none", ids)
+	}
+}
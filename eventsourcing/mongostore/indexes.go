@@ -0,0 +1,127 @@
+package mongostore
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// EnsureIndexes prepares db for the store: it numbers events stored before
+// global positions existed and creates the event and snapshot indexes.
+func EnsureIndexes(ctx context.Context, db *mongo.Database) error {
+	if err := backfillPositions(ctx, db); err != nil {
+		return fmt.Errorf("failed to backfill event positions: %w", err)
+	}
+
+	// The unique stream version makes concurrent appends to a stream conflict
+	// even when both passed the expected version check.
+	_, err := db.Collection("events").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "position", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "stream_id", Value: 1}, {Key: "version", Value: 1}}, Options: options.Index().SetUnique(true)},
+	})
+	if err != nil {
+		if dupErr := checkDuplicateVersions(ctx, db); dupErr != nil {
+			return fmt.Errorf("events: %w", dupErr)
+		}
+		return fmt.Errorf("events: %w", err)
+	}
+
+	_, err = db.Collection("snapshots").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "stream_id", Value: 1}, {Key: "schema_version", Value: 1}, {Key: "version", Value: -1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return fmt.Errorf("snapshots: %w", err)
+	}
+	return nil
+}
+
+// checkDuplicateVersions reports a stream with two events at the same version,
+// which a race could store before the unique index existed and which makes
+// building the index fail. It returns nil if there is none.
+func checkDuplicateVersions(ctx context.Context, db *mongo.Database) error {
+	cursor, err := db.Collection("events").Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$group", Value: bson.D{
+			{Key: "_id", Value: bson.D{{Key: "stream_id", Value: "$stream_id"}, {Key: "version", Value: "$version"}}},
+			{Key: "count", Value: bson.D{{Key: "$sum", Value: 1}}},
+		}}},
+		{{Key: "$match", Value: bson.D{{Key: "count", Value: bson.D{{Key: "$gt", Value: 1}}}}}},
+		{{Key: "$limit", Value: 1}},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to check event versions: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var dup struct {
+		ID struct {
+			StreamID string `bson:"stream_id"`
+			Version  int    `bson:"version"`
+		} `bson:"_id"`
+		Count int `bson:"count"`
+	}
+	if !cursor.Next(ctx) {
+		return cursor.Err()
+	}
+	if err := cursor.Decode(&dup); err != nil {
+		return fmt.Errorf("failed to check event versions: %w", err)
+	}
+	return fmt.Errorf("stream %s has %d events at version %d; remove the duplicates before starting", dup.ID.StreamID, dup.Count, dup.ID.Version)
+}
+
+// backfillPositions numbers events stored before global positions were
+// introduced, in the order they were created, and moves the position counter
+// past them. It is a no-op once every event has a position.
+func backfillPositions(ctx context.Context, db *mongo.Database) error {
+	coll := db.Collection("events")
+	filter := bson.M{"position": bson.M{"$exists": false}}
+
+	n, err := coll.CountDocuments(ctx, filter)
+	if err != nil || n == 0 {
+		return err
+	}
+
+	var counter struct {
+		Seq int64 `bson:"seq"`
+	}
+	err = db.Collection("counters").FindOne(ctx, bson.M{"_id": positionCounter}).Decode(&counter)
+	if err != nil && err != mongo.ErrNoDocuments {
+		return err
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}).SetProjection(bson.M{"_id": 1})
+	cursor, err := coll.Find(ctx, filter, opts)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	position := counter.Seq
+	for cursor.Next(ctx) {
+		var doc struct {
+			ID interface{} `bson:"_id"`
+		}
+		if err := cursor.Decode(&doc); err != nil {
+			return err
+		}
+		position++
+		if _, err := coll.UpdateByID(ctx, doc.ID, bson.M{"$set": bson.M{"position": position}}); err != nil {
+			return err
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return err
+	}
+
+	_, err = db.Collection("counters").UpdateByID(ctx, positionCounter, bson.M{"$max": bson.M{"seq": position}}, options.Update().SetUpsert(true))
+	if err != nil {
+		return err
+	}
+
+	slog.Info("Backfilled global event positions", "events", n)
+	return nil
+}
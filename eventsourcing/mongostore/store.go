@@ -0,0 +1,354 @@
+// Package mongostore is the MongoDB implementation of the event store. Appends
+// run in multi-document transactions and therefore need a replica set.
+package mongostore
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	es "github.com/egannguyen/go-kafka-ecommerce/eventsourcing"
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// positionCounter is the counters document holding the last global event position.
+const positionCounter = "event_position"
+
+// readAllPageSize is the page size SubscribeAll uses while catching up.
+const readAllPageSize = 500
+
+// AppendHook runs inside the append transaction with the records being
+// inserted, so related documents such as outbox messages are written
+// atomically with the events.
+type AppendHook func(sessCtx mongo.SessionContext, records []es.EventRecord) error
+
+// Store keeps events in the "events" collection and snapshots in "snapshots".
+type Store struct {
+	db *mongo.Database
+}
+
+func New(db *mongo.Database) *Store {
+	return &Store{db: db}
+}
+
+var _ es.GlobalEventStore = (*Store)(nil)
+
+func (s *Store) SaveEvents(ctx context.Context, streamID string, streamType string, expectedVersion int, events []es.Event) error {
+	return s.Append(ctx, streamID, streamType, expectedVersion, events, nil)
+}
+
+// Append is SaveEvents with a hook run in the same transaction; hook may be nil.
+func (s *Store) Append(ctx context.Context, streamID string, streamType string, expectedVersion int, events []es.Event, hook AppendHook) error {
+	return s.AppendStreams(ctx, []StreamAppend{{
+		StreamID:        streamID,
+		StreamType:      streamType,
+		ExpectedVersion: expectedVersion,
+		Events:          events,
+	}}, hook)
+}
+
+// StreamAppend is a batch of events to append to one stream.
+type StreamAppend struct {
+	StreamID        string
+	StreamType      string
+	ExpectedVersion int
+	Events          []es.Event
+}
+
+// AppendStreams appends to several streams in one transaction: either every
+// stream is appended or none is. It fails with ErrConcurrencyConflict if any
+// stream is no longer at its expected version. hook, which may be nil, runs
+// in the transaction with the records of all streams.
+func (s *Store) AppendStreams(ctx context.Context, appends []StreamAppend, hook AppendHook) error {
+	total := 0
+	for _, a := range appends {
+		total += len(a.Events)
+	}
+	if total == 0 {
+		return nil
+	}
+
+	session, err := s.db.Client().StartSession()
+	if err != nil {
+		return fmt.Errorf("failed to start session: %w", err)
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(sessCtx mongo.SessionContext) (interface{}, error) {
+		position, err := s.allocatePositions(sessCtx, total)
+		if err != nil {
+			return nil, err
+		}
+
+		records := make([]es.EventRecord, 0, total)
+		now := time.Now()
+		for _, a := range appends {
+			if len(a.Events) == 0 {
+				continue
+			}
+			streamRecords, err := s.appendStream(sessCtx, a, position, now)
+			if err != nil {
+				return nil, err
+			}
+			position += int64(len(streamRecords))
+			records = append(records, streamRecords...)
+		}
+
+		if hook != nil {
+			if err := hook(sessCtx, records); err != nil {
+				return nil, err
+			}
+		}
+		return nil, nil
+	})
+
+	return err
+}
+
+// appendStream inserts the events of a after checking the stream's version,
+// numbering them from the global position after position.
+func (s *Store) appendStream(sessCtx mongo.SessionContext, a StreamAppend, position int64, now time.Time) ([]es.EventRecord, error) {
+	coll := s.db.Collection("events")
+
+	var result struct {
+		Version int `bson:"version"`
+	}
+	opts := options.FindOne().SetSort(bson.M{"version": -1})
+	err := coll.FindOne(sessCtx, bson.M{"stream_id": a.StreamID}, opts).Decode(&result)
+
+	currentVersion := 0
+	if err == nil {
+		currentVersion = result.Version
+	} else if err != mongo.ErrNoDocuments {
+		return nil, fmt.Errorf("failed to get current stream version: %w", err)
+	}
+
+	if a.ExpectedVersion != es.AnyVersion && currentVersion != a.ExpectedVersion {
+		return nil, fmt.Errorf("%w: stream %s expected version %d, got %d", es.ErrConcurrencyConflict, a.StreamID, a.ExpectedVersion, currentVersion)
+	}
+
+	records := make([]es.EventRecord, 0, len(a.Events))
+	docs := make([]interface{}, 0, len(a.Events))
+	version := currentVersion
+
+	for _, event := range a.Events {
+		version++
+		position++
+		payload, err := json.Marshal(event)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal event %s: %w", event.EventType(), err)
+		}
+
+		rec := es.EventRecord{
+			ID:            uuid.NewString(),
+			StreamID:      a.StreamID,
+			StreamType:    a.StreamType,
+			Version:       version,
+			Position:      position,
+			EventType:     event.EventType(),
+			SchemaVersion: es.SchemaVersionOf(event),
+			Payload:       payload,
+			CreatedAt:     now,
+		}
+		records = append(records, rec)
+		docs = append(docs, rec)
+	}
+
+	if _, err := coll.InsertMany(sessCtx, docs); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, fmt.Errorf("%w: stream %s was appended to concurrently", es.ErrConcurrencyConflict, a.StreamID)
+		}
+		return nil, fmt.Errorf("failed to insert events: %w", err)
+	}
+	return records, nil
+}
+
+// allocatePositions reserves n global positions and returns the one before the
+// first reserved. The counter document is updated inside the caller's
+// transaction, so concurrent appends conflict on it and commit in position
+// order; a tailing reader therefore never sees a position skipped.
+func (s *Store) allocatePositions(sessCtx mongo.SessionContext, n int) (int64, error) {
+	var counter struct {
+		Seq int64 `bson:"seq"`
+	}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+	err := s.db.Collection("counters").FindOneAndUpdate(sessCtx, bson.M{"_id": positionCounter}, bson.M{"$inc": bson.M{"seq": n}}, opts).Decode(&counter)
+	if err != nil {
+		return 0, fmt.Errorf("failed to allocate event positions: %w", err)
+	}
+	return counter.Seq - int64(n), nil
+}
+
+// ListStreamIDs returns the IDs of every stream of the given type.
+func (s *Store) ListStreamIDs(ctx context.Context, streamType string) ([]string, error) {
+	values, err := s.db.Collection("events").Distinct(ctx, "stream_id", bson.M{"stream_type": streamType})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list streams: %w", err)
+	}
+
+	ids := make([]string, 0, len(values))
+	for _, v := range values {
+		if id, ok := v.(string); ok {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+func (s *Store) LoadEvents(ctx context.Context, streamID string) ([]es.EventRecord, error) {
+	return s.loadStream(ctx, streamID, 0)
+}
+
+func (s *Store) LoadFromSnapshot(ctx context.Context, streamID string, schemaVersion int) (*es.Snapshot, []es.EventRecord, error) {
+	var snapshot *es.Snapshot
+	fromVersion := 0
+
+	var latest es.Snapshot
+	opts := options.FindOne().SetSort(bson.M{"version": -1})
+	err := s.db.Collection("snapshots").FindOne(ctx, bson.M{"stream_id": streamID, "schema_version": schemaVersion}, opts).Decode(&latest)
+	if err == nil {
+		snapshot = &latest
+		fromVersion = latest.Version
+	} else if err != mongo.ErrNoDocuments {
+		return nil, nil, fmt.Errorf("failed to load snapshot: %w", err)
+	}
+
+	events, err := s.loadStream(ctx, streamID, fromVersion)
+	if err != nil {
+		return nil, nil, err
+	}
+	return snapshot, events, nil
+}
+
+// loadStream returns the events of a stream after fromVersion in version order.
+func (s *Store) loadStream(ctx context.Context, streamID string, fromVersion int) ([]es.EventRecord, error) {
+	filter := bson.M{"stream_id": streamID}
+	if fromVersion > 0 {
+		filter["version"] = bson.M{"$gt": fromVersion}
+	}
+
+	opts := options.Find().SetSort(bson.M{"version": 1})
+	cursor, err := s.db.Collection("events").Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load events: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var events []es.EventRecord
+	if err := cursor.All(ctx, &events); err != nil {
+		return nil, fmt.Errorf("failed to decode events: %w", err)
+	}
+	return events, nil
+}
+
+func (s *Store) SaveSnapshot(ctx context.Context, snapshot es.Snapshot) error {
+	if snapshot.CreatedAt.IsZero() {
+		snapshot.CreatedAt = time.Now()
+	}
+
+	// Snapshots are immutable per version; a concurrent writer may already have stored this one.
+	_, err := s.db.Collection("snapshots").InsertOne(ctx, snapshot)
+	if err != nil && !mongo.IsDuplicateKeyError(err) {
+		return fmt.Errorf("failed to save snapshot: %w", err)
+	}
+	return nil
+}
+
+func (s *Store) ForEachEvent(ctx context.Context, streamType string, fn func(es.EventRecord) error) error {
+	opts := options.Find().SetSort(bson.M{"position": 1})
+	cursor, err := s.db.Collection("events").Find(ctx, bson.M{"stream_type": streamType}, opts)
+	if err != nil {
+		return fmt.Errorf("failed to query events: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var rec es.EventRecord
+		if err := cursor.Decode(&rec); err != nil {
+			return fmt.Errorf("failed to decode event: %w", err)
+		}
+		if err := fn(rec); err != nil {
+			return err
+		}
+	}
+	return cursor.Err()
+}
+
+func (s *Store) CountEvents(ctx context.Context, streamType string) (int64, error) {
+	n, err := s.db.Collection("events").CountDocuments(ctx, bson.M{"stream_type": streamType})
+	if err != nil {
+		return 0, fmt.Errorf("failed to count events: %w", err)
+	}
+	return n, nil
+}
+
+func (s *Store) ReadAll(ctx context.Context, fromPosition int64, limit int) ([]es.EventRecord, error) {
+	opts := options.Find().SetSort(bson.M{"position": 1}).SetLimit(int64(limit))
+	cursor, err := s.db.Collection("events").Find(ctx, bson.M{"position": bson.M{"$gt": fromPosition}}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read events: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var events []es.EventRecord
+	if err := cursor.All(ctx, &events); err != nil {
+		return nil, fmt.Errorf("failed to decode events: %w", err)
+	}
+	return events, nil
+}
+
+// SubscribeAll opens a change stream on the events collection before paging
+// through history, so nothing inserted while catching up is missed; events
+// seen in both are delivered once.
+func (s *Store) SubscribeAll(ctx context.Context, fromPosition int64, handler func(es.EventRecord) error) error {
+	pipeline := mongo.Pipeline{{{Key: "$match", Value: bson.M{"operationType": "insert"}}}}
+	stream, err := s.db.Collection("events").Watch(ctx, pipeline)
+	if err != nil {
+		return fmt.Errorf("failed to watch events: %w", err)
+	}
+	defer stream.Close(ctx)
+
+	position := fromPosition
+	for {
+		page, err := s.ReadAll(ctx, position, readAllPageSize)
+		if err != nil {
+			return err
+		}
+		for _, rec := range page {
+			if err := handler(rec); err != nil {
+				return err
+			}
+			position = rec.Position
+		}
+		if len(page) < readAllPageSize {
+			break
+		}
+	}
+
+	for stream.Next(ctx) {
+		var change struct {
+			FullDocument es.EventRecord `bson:"fullDocument"`
+		}
+		if err := stream.Decode(&change); err != nil {
+			return fmt.Errorf("failed to decode change event: %w", err)
+		}
+
+		rec := change.FullDocument
+		if rec.Position <= position {
+			continue
+		}
+		if err := handler(rec); err != nil {
+			return err
+		}
+		position = rec.Position
+	}
+
+	if ctx.Err() != nil {
+		return nil
+	}
+	return fmt.Errorf("event change stream closed: %w", stream.Err())
+}
@@ -0,0 +1,52 @@
+// Package outbox relays messages that services queue in a transactional
+// outbox, next to the events they belong to, to the message broker.
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Message is an event waiting to be published by the relay.
+type Message struct {
+	ID           string     `json:"id" bson:"id"`
+	Topic        string     `json:"topic" bson:"topic"`
+	Key          string     `json:"key" bson:"key"`
+	Version      int        `json:"version" bson:"version"`
+	EventType    string     `json:"event_type,omitempty" bson:"event_type,omitempty"`
+	Payload      []byte     `json:"payload" bson:"payload"`
+	CreatedAt    time.Time  `json:"created_at" bson:"created_at"`
+	DispatchedAt *time.Time `json:"dispatched_at,omitempty" bson:"dispatched_at"`
+}
+
+// Repository gives the relay access to queued messages.
+type Repository interface {
+	// FetchPending returns up to limit undispatched messages, oldest first and
+	// in stream version order within each key.
+	FetchPending(ctx context.Context, limit int) ([]Message, error)
+	// MarkDispatched marks the messages with the given IDs dispatched in a
+	// single update.
+	MarkDispatched(ctx context.Context, ids []string) error
+}
+
+// Publisher writes already encoded messages to the broker.
+type Publisher interface {
+	// PublishBatch publishes messages in one write. If only some of them fail
+	// it returns PublishErrors.
+	PublishBatch(ctx context.Context, messages []Message) error
+}
+
+// PublishErrors reports the outcome of a partly failed PublishBatch. It is
+// indexed like the batch; a nil entry means that message was published.
+type PublishErrors []error
+
+func (e PublishErrors) Error() string {
+	failed := 0
+	for _, err := range e {
+		if err != nil {
+			failed++
+		}
+	}
+	return fmt.Sprintf("failed to publish %d of %d messages", failed, len(e))
+}
@@ -0,0 +1,102 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// Relay publishes messages queued in the outbox to the broker. Delivery is
+// at-least-once: a message is only marked dispatched after the broker accepted
+// it, so a crash in between causes it to be published again.
+type Relay struct {
+	repo         Repository
+	publisher    Publisher
+	pollInterval time.Duration
+	batchSize    int
+}
+
+func NewRelay(repo Repository, publisher Publisher, pollInterval time.Duration, batchSize int) *Relay {
+	return &Relay{
+		repo:         repo,
+		publisher:    publisher,
+		pollInterval: pollInterval,
+		batchSize:    batchSize,
+	}
+}
+
+// Run polls the outbox until ctx is cancelled.
+func (r *Relay) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		// Drain full batches back to back before waiting for the next tick.
+		for {
+			n, err := r.dispatchBatch(ctx)
+			if err != nil {
+				slog.Error("Outbox relay failed to dispatch batch", "err", err)
+				break
+			}
+			if n < r.batchSize {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// dispatchBatch publishes one batch of pending messages with a single write,
+// marks the published ones dispatched with a single update and returns how
+// many were fetched. Once a message fails, later messages with the same key
+// stay pending even if the broker accepted them, so the next batch republishes them
+// after the failed one and per-aggregate ordering is preserved.
+func (r *Relay) dispatchBatch(ctx context.Context) (int, error) {
+	messages, err := r.repo.FetchPending(ctx, r.batchSize)
+	if err != nil {
+		return 0, err
+	}
+	if len(messages) == 0 {
+		return 0, nil
+	}
+
+	var publishErrs PublishErrors
+	if err := r.publisher.PublishBatch(ctx, messages); err != nil {
+		if !errors.As(err, &publishErrs) || len(publishErrs) != len(messages) {
+			return 0, fmt.Errorf("failed to publish outbox batch: %w", err)
+		}
+	}
+
+	blocked := make(map[string]bool)
+	dispatched := make([]string, 0, len(messages))
+	for i, msg := range messages {
+		if blocked[msg.Key] {
+			continue
+		}
+		if publishErrs != nil && publishErrs[i] != nil {
+			slog.Error("Failed to publish outbox message", "id", msg.ID, "topic", msg.Topic, "key", msg.Key, "err", publishErrs[i])
+			blocked[msg.Key] = true
+			continue
+		}
+		dispatched = append(dispatched, msg.ID)
+	}
+
+	if len(dispatched) > 0 {
+		if err := r.repo.MarkDispatched(ctx, dispatched); err != nil {
+			return 0, err
+		}
+	}
+
+	if len(blocked) > 0 {
+		// Don't spin on a batch that cannot make progress.
+		return 0, nil
+	}
+	return len(messages), nil
+}
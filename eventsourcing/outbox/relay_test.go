@@ -0,0 +1,155 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+// fakeOutbox is an in-memory Repository.
+type fakeOutbox struct {
+	mu         sync.Mutex
+	messages   []Message
+	dispatched map[string]bool
+	marks      [][]string
+}
+
+func newFakeOutbox(messages ...Message) *fakeOutbox {
+	return &fakeOutbox{messages: messages, dispatched: make(map[string]bool)}
+}
+
+func (o *fakeOutbox) FetchPending(ctx context.Context, limit int) ([]Message, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	var pending []Message
+	for _, m := range o.messages {
+		if !o.dispatched[m.ID] && len(pending) < limit {
+			pending = append(pending, m)
+		}
+	}
+	return pending, nil
+}
+
+func (o *fakeOutbox) MarkDispatched(ctx context.Context, ids []string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.marks = append(o.marks, ids)
+	for _, id := range ids {
+		o.dispatched[id] = true
+	}
+	return nil
+}
+
+// fakeBatchPublisher records every PublishBatch call and fails the message
+// IDs in fail.
+type fakeBatchPublisher struct {
+	fail    map[string]bool
+	err     error // returned for the whole batch when set
+	batches [][]string
+}
+
+func (p *fakeBatchPublisher) PublishBatch(ctx context.Context, messages []Message) error {
+	ids := make([]string, len(messages))
+	errs := make(PublishErrors, len(messages))
+	failed := false
+	for i, m := range messages {
+		ids[i] = m.ID
+		if p.fail[m.ID] {
+			errs[i] = fmt.Errorf("broker rejected %s", m.ID)
+			failed = true
+		}
+	}
+	p.batches = append(p.batches, ids)
+	if p.err != nil {
+		return p.err
+	}
+	if failed {
+		return errs
+	}
+	return nil
+}
+
+func outboxMessages(keyed ...string) []Message {
+	messages := make([]Message, 0, len(keyed)/2)
+	for i := 0; i < len(keyed); i += 2 {
+		messages = append(messages, Message{ID: keyed[i], Key: keyed[i+1], Topic: "orders.shipped", Payload: []byte(`{}`)})
+	}
+	return messages
+}
+
+func TestRelayPublishesBatchInOneWrite(t *testing.T) {
+	outbox := newFakeOutbox(outboxMessages("m1", "order-1", "m2", "order-2", "m3", "order-1")...)
+	publisher := &fakeBatchPublisher{}
+	relay := NewRelay(outbox, publisher, 0, 10)
+
+	n, err := relay.dispatchBatch(context.Background())
+	if err != nil {
+		t.Fatalf("dispatchBatch: %v", err)
+	}
+	if n != 3 {
+		t.Errorf("fetched %d messages, want 3", n)
+	}
+	if want := [][]string{{"m1", "m2", "m3"}}; !reflect.DeepEqual(publisher.batches, want) {
+		t.Errorf("published %v, want %v", publisher.batches, want)
+	}
+	if want := [][]string{{"m1", "m2", "m3"}}; !reflect.DeepEqual(outbox.marks, want) {
+		t.Errorf("marked %v, want %v", outbox.marks, want)
+	}
+}
+
+func TestRelayHoldsBackKeyOfFailedMessage(t *testing.T) {
+	outbox := newFakeOutbox(outboxMessages("m1", "order-1", "m2", "order-2", "m3", "order-1", "m4", "order-2")...)
+	publisher := &fakeBatchPublisher{fail: map[string]bool{"m1": true}}
+	relay := NewRelay(outbox, publisher, 0, 10)
+
+	n, err := relay.dispatchBatch(context.Background())
+	if err != nil {
+		t.Fatalf("dispatchBatch: %v", err)
+	}
+	if n != 0 {
+		t.Errorf("dispatchBatch = %d, want 0 so the relay waits for the next tick", n)
+	}
+	// m3 was accepted but stays pending behind m1.
+	if want := [][]string{{"m2", "m4"}}; !reflect.DeepEqual(outbox.marks, want) {
+		t.Errorf("marked %v, want %v", outbox.marks, want)
+	}
+
+	publisher.fail = nil
+	if _, err := relay.dispatchBatch(context.Background()); err != nil {
+		t.Fatalf("dispatchBatch: %v", err)
+	}
+	if want := []string{"m1", "m3"}; !reflect.DeepEqual(publisher.batches[1], want) {
+		t.Errorf("republished %v, want %v in order", publisher.batches[1], want)
+	}
+	if len(outbox.dispatched) != 4 {
+		t.Errorf("%d messages dispatched, want 4", len(outbox.dispatched))
+	}
+}
+
+func TestRelayMarksNothingWhenBatchFails(t *testing.T) {
+	outbox := newFakeOutbox(outboxMessages("m1", "order-1", "m2", "order-2")...)
+	publisher := &fakeBatchPublisher{err: errors.New("broker unavailable")}
+	relay := NewRelay(outbox, publisher, 0, 10)
+
+	if _, err := relay.dispatchBatch(context.Background()); err == nil {
+		t.Fatal("dispatchBatch succeeded although the batch failed")
+	}
+	if len(outbox.marks) != 0 {
+		t.Errorf("marked %v after a failed batch", outbox.marks)
+	}
+}
+
+func TestRelaySkipsEmptyOutbox(t *testing.T) {
+	publisher := &fakeBatchPublisher{}
+	relay := NewRelay(newFakeOutbox(), publisher, 0, 10)
+
+	if n, err := relay.dispatchBatch(context.Background()); n != 0 || err != nil {
+		t.Errorf("dispatchBatch = %d, %v", n, err)
+	}
+	if len(publisher.batches) != 0 {
+		t.Errorf("published %d empty batches", len(publisher.batches))
+	}
+}
@@ -0,0 +1,315 @@
+// Package pgstore is the PostgreSQL implementation of the event store. Each
+// service keeps its tables in its own schema, so services can share a
+// database.
+package pgstore
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	es "github.com/egannguyen/go-kafka-ecommerce/eventsourcing"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// readAllPageSize is the page size SubscribeAll uses while catching up.
+const readAllPageSize = 500
+
+// pollInterval is how often SubscribeAll looks for new events once caught up.
+const pollInterval = 500 * time.Millisecond
+
+// uniqueViolation is the PostgreSQL error code of a unique constraint violation.
+const uniqueViolation = "23505"
+
+// AppendHook runs inside the append transaction with the records being
+// inserted, so related rows such as outbox messages are written atomically
+// with the events.
+type AppendHook func(tx *sql.Tx, records []es.EventRecord) error
+
+type Store struct {
+	db     *sql.DB
+	schema string
+}
+
+// New creates a store keeping its tables in schema. Call Migrate before use.
+func New(db *sql.DB, schema string) *Store {
+	return &Store{db: db, schema: pq.QuoteIdentifier(schema)}
+}
+
+var _ es.GlobalEventStore = (*Store)(nil)
+
+// Migrate creates the schema and its tables if they do not exist. Events are
+// unique per (stream_id, version); position is the global sequence, taken
+// from a single counter row so it is gapless and assigned in commit order.
+func (s *Store) Migrate(ctx context.Context) error {
+	_, err := s.db.ExecContext(ctx, s.sql(`
+		CREATE SCHEMA IF NOT EXISTS {schema};
+		CREATE TABLE IF NOT EXISTS {schema}.events (
+			position BIGINT PRIMARY KEY,
+			id TEXT NOT NULL UNIQUE,
+			stream_id TEXT NOT NULL,
+			stream_type TEXT NOT NULL,
+			version INT NOT NULL,
+			event_type TEXT NOT NULL,
+			schema_version INT NOT NULL DEFAULT 1,
+			payload JSONB NOT NULL,
+			created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+			UNIQUE (stream_id, version)
+		);
+		CREATE INDEX IF NOT EXISTS events_stream_type_position ON {schema}.events (stream_type, position);
+		CREATE TABLE IF NOT EXISTS {schema}.event_position (
+			id BOOLEAN PRIMARY KEY DEFAULT TRUE CHECK (id),
+			seq BIGINT NOT NULL
+		);
+		INSERT INTO {schema}.event_position (seq) VALUES (0) ON CONFLICT DO NOTHING;
+		CREATE TABLE IF NOT EXISTS {schema}.snapshots (
+			stream_id TEXT NOT NULL,
+			stream_type TEXT NOT NULL,
+			version INT NOT NULL,
+			schema_version INT NOT NULL,
+			state JSONB NOT NULL,
+			created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+			PRIMARY KEY (stream_id, schema_version, version)
+		);
+	`))
+	if err != nil {
+		return fmt.Errorf("failed to migrate event store: %w", err)
+	}
+	return nil
+}
+
+// sql qualifies the tables of query with the store's schema.
+func (s *Store) sql(query string) string {
+	return strings.ReplaceAll(query, "{schema}", s.schema)
+}
+
+func (s *Store) SaveEvents(ctx context.Context, streamID string, streamType string, expectedVersion int, events []es.Event) error {
+	return s.Append(ctx, streamID, streamType, expectedVersion, events, nil)
+}
+
+// Append is SaveEvents with a hook run in the same transaction; hook may be
+// nil. All events are inserted with a single statement.
+func (s *Store) Append(ctx context.Context, streamID string, streamType string, expectedVersion int, events []es.Event, hook AppendHook) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Taking positions first locks the counter row until commit, so appends
+	// commit in position order and a tailing reader never skips one.
+	var seq int64
+	err = tx.QueryRowContext(ctx, s.sql(`UPDATE {schema}.event_position SET seq = seq + $1 RETURNING seq`), len(events)).Scan(&seq)
+	if err != nil {
+		return fmt.Errorf("failed to allocate event positions: %w", err)
+	}
+	position := seq - int64(len(events))
+
+	var currentVersion int
+	err = tx.QueryRowContext(ctx, s.sql(`SELECT COALESCE(MAX(version), 0) FROM {schema}.events WHERE stream_id = $1`), streamID).Scan(&currentVersion)
+	if err != nil {
+		return fmt.Errorf("failed to get current stream version: %w", err)
+	}
+	if expectedVersion != es.AnyVersion && currentVersion != expectedVersion {
+		return fmt.Errorf("%w: stream %s expected version %d, got %d", es.ErrConcurrencyConflict, streamID, expectedVersion, currentVersion)
+	}
+
+	records := make([]es.EventRecord, 0, len(events))
+	values := make([]string, 0, len(events))
+	args := make([]interface{}, 0, len(events)*9)
+	now := time.Now()
+	for i, event := range events {
+		payload, err := json.Marshal(event)
+		if err != nil {
+			return fmt.Errorf("failed to marshal event %s: %w", event.EventType(), err)
+		}
+
+		rec := es.EventRecord{
+			ID:            uuid.NewString(),
+			StreamID:      streamID,
+			StreamType:    streamType,
+			Version:       currentVersion + i + 1,
+			Position:      position + int64(i) + 1,
+			EventType:     event.EventType(),
+			SchemaVersion: es.SchemaVersionOf(event),
+			Payload:       payload,
+			CreatedAt:     now,
+		}
+		records = append(records, rec)
+
+		n := len(args)
+		values = append(values, fmt.Sprintf("($%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d)", n+1, n+2, n+3, n+4, n+5, n+6, n+7, n+8, n+9))
+		args = append(args, rec.Position, rec.ID, rec.StreamID, rec.StreamType, rec.Version, rec.EventType, rec.SchemaVersion, string(rec.Payload), rec.CreatedAt)
+	}
+
+	_, err = tx.ExecContext(ctx, s.sql(`
+		INSERT INTO {schema}.events (position, id, stream_id, stream_type, version, event_type, schema_version, payload, created_at)
+		VALUES `)+strings.Join(values, ", "), args...)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation {
+			return fmt.Errorf("%w: stream %s was appended to concurrently", es.ErrConcurrencyConflict, streamID)
+		}
+		return fmt.Errorf("failed to insert events: %w", err)
+	}
+
+	if hook != nil {
+		if err := hook(tx, records); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit events: %w", err)
+	}
+	return nil
+}
+
+const selectEvents = `SELECT id, stream_id, stream_type, version, position, event_type, schema_version, payload, created_at FROM {schema}.events `
+
+func (s *Store) LoadEvents(ctx context.Context, streamID string) ([]es.EventRecord, error) {
+	return s.query(ctx, selectEvents+`WHERE stream_id = $1 ORDER BY version`, streamID)
+}
+
+func (s *Store) LoadFromSnapshot(ctx context.Context, streamID string, schemaVersion int) (*es.Snapshot, []es.EventRecord, error) {
+	var snapshot *es.Snapshot
+	fromVersion := 0
+
+	var latest es.Snapshot
+	err := s.db.QueryRowContext(ctx, s.sql(`
+		SELECT stream_id, stream_type, version, schema_version, state, created_at FROM {schema}.snapshots
+		WHERE stream_id = $1 AND schema_version = $2 ORDER BY version DESC LIMIT 1`), streamID, schemaVersion,
+	).Scan(&latest.StreamID, &latest.StreamType, &latest.Version, &latest.SchemaVersion, &latest.State, &latest.CreatedAt)
+	if err == nil {
+		snapshot = &latest
+		fromVersion = latest.Version
+	} else if err != sql.ErrNoRows {
+		return nil, nil, fmt.Errorf("failed to load snapshot: %w", err)
+	}
+
+	events, err := s.query(ctx, selectEvents+`WHERE stream_id = $1 AND version > $2 ORDER BY version`, streamID, fromVersion)
+	if err != nil {
+		return nil, nil, err
+	}
+	return snapshot, events, nil
+}
+
+func (s *Store) SaveSnapshot(ctx context.Context, snapshot es.Snapshot) error {
+	if snapshot.CreatedAt.IsZero() {
+		snapshot.CreatedAt = time.Now()
+	}
+
+	// Snapshots are immutable per version; a concurrent writer may already have stored this one.
+	_, err := s.db.ExecContext(ctx, s.sql(`
+		INSERT INTO {schema}.snapshots (stream_id, stream_type, version, schema_version, state, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6) ON CONFLICT DO NOTHING`),
+		snapshot.StreamID, snapshot.StreamType, snapshot.Version, snapshot.SchemaVersion, string(snapshot.State), snapshot.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save snapshot: %w", err)
+	}
+	return nil
+}
+
+func (s *Store) ForEachEvent(ctx context.Context, streamType string, fn func(es.EventRecord) error) error {
+	rows, err := s.db.QueryContext(ctx, s.sql(selectEvents+`WHERE stream_type = $1 ORDER BY position`), streamType)
+	if err != nil {
+		return fmt.Errorf("failed to query events: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		rec, err := scanRecord(rows)
+		if err != nil {
+			return err
+		}
+		if err := fn(rec); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+func (s *Store) CountEvents(ctx context.Context, streamType string) (int64, error) {
+	var n int64
+	err := s.db.QueryRowContext(ctx, s.sql(`SELECT COUNT(*) FROM {schema}.events WHERE stream_type = $1`), streamType).Scan(&n)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count events: %w", err)
+	}
+	return n, nil
+}
+
+func (s *Store) ReadAll(ctx context.Context, fromPosition int64, limit int) ([]es.EventRecord, error) {
+	return s.query(ctx, selectEvents+`WHERE position > $1 ORDER BY position LIMIT $2`, fromPosition, limit)
+}
+
+// SubscribeAll pages through history and then polls for new events.
+func (s *Store) SubscribeAll(ctx context.Context, fromPosition int64, handler func(es.EventRecord) error) error {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	position := fromPosition
+	for {
+		page, err := s.ReadAll(ctx, position, readAllPageSize)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		for _, rec := range page {
+			if err := handler(rec); err != nil {
+				return err
+			}
+			position = rec.Position
+		}
+		if len(page) == readAllPageSize {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Store) query(ctx context.Context, query string, args ...interface{}) ([]es.EventRecord, error) {
+	rows, err := s.db.QueryContext(ctx, s.sql(query), args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []es.EventRecord
+	for rows.Next() {
+		rec, err := scanRecord(rows)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, rec)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to load events: %w", err)
+	}
+	return events, nil
+}
+
+func scanRecord(rows *sql.Rows) (es.EventRecord, error) {
+	var rec es.EventRecord
+	err := rows.Scan(&rec.ID, &rec.StreamID, &rec.StreamType, &rec.Version, &rec.Position, &rec.EventType, &rec.SchemaVersion, &rec.Payload, &rec.CreatedAt)
+	if err != nil {
+		return rec, fmt.Errorf("failed to decode event: %w", err)
+	}
+	return rec, nil
+}
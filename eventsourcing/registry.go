@@ -0,0 +1,113 @@
+package eventsourcing
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+// Upcaster transforms an event payload from one schema version to the next.
+type Upcaster func(payload []byte) ([]byte, error)
+
+// Registry maps event type names to the Go types their payloads decode into,
+// and holds the upcasters bringing payloads of older schema versions up to
+// the current one.
+type Registry struct {
+	types     map[string]reflect.Type
+	versions  map[string]int
+	upcasters map[string]map[int]Upcaster // by event type, then version upcast from
+}
+
+// NewRegistry creates a registry for the given events, see Register.
+func NewRegistry(events ...Event) *Registry {
+	r := &Registry{
+		types:     make(map[string]reflect.Type),
+		versions:  make(map[string]int),
+		upcasters: make(map[string]map[int]Upcaster),
+	}
+	r.Register(events...)
+	return r
+}
+
+// Register adds events, given as zero values, to the registry. Records are
+// decoded into values of the same type, so aggregates see events exactly as
+// they were appended. It panics if an event type name is registered twice.
+func (r *Registry) Register(events ...Event) {
+	for _, e := range events {
+		name := e.EventType()
+		if _, ok := r.types[name]; ok {
+			panic(fmt.Sprintf("eventsourcing: event type %s registered twice", name))
+		}
+		r.types[name] = reflect.TypeOf(e)
+		r.versions[name] = SchemaVersionOf(e)
+	}
+}
+
+// RegisterUpcaster registers fn to transform payloads of eventType from
+// schema version from to from+1. Every version below the current one of a
+// registered event needs an upcaster for old records to load. It panics if
+// eventType is unknown, from is not below its current version or an upcaster
+// for from is already registered.
+func (r *Registry) RegisterUpcaster(eventType string, from int, fn Upcaster) {
+	current, ok := r.versions[eventType]
+	if !ok {
+		panic(fmt.Sprintf("eventsourcing: upcaster for unregistered event type %s", eventType))
+	}
+	if from < 1 || from >= current {
+		panic(fmt.Sprintf("eventsourcing: upcaster for %s from version %d, current version is %d", eventType, from, current))
+	}
+	if r.upcasters[eventType] == nil {
+		r.upcasters[eventType] = make(map[int]Upcaster)
+	}
+	if _, ok := r.upcasters[eventType][from]; ok {
+		panic(fmt.Sprintf("eventsourcing: upcaster for %s from version %d registered twice", eventType, from))
+	}
+	r.upcasters[eventType][from] = fn
+}
+
+// Decode unmarshals the payload of a stored record into its event type,
+// upcasting it first if it was stored with an older schema version.
+func (r *Registry) Decode(rec EventRecord) (Event, error) {
+	t, ok := r.types[rec.EventType]
+	if !ok {
+		return nil, fmt.Errorf("unknown event type in stream: %s", rec.EventType)
+	}
+
+	payload, err := r.upcast(rec)
+	if err != nil {
+		return nil, err
+	}
+
+	v := reflect.New(t)
+	if err := json.Unmarshal(payload, v.Interface()); err != nil {
+		return nil, fmt.Errorf("failed to decode %s event: %w", rec.EventType, err)
+	}
+	return v.Elem().Interface().(Event), nil
+}
+
+// upcast returns the payload of rec at the current schema version of its event type.
+func (r *Registry) upcast(rec EventRecord) ([]byte, error) {
+	version := rec.SchemaVersion
+	if version == 0 {
+		version = 1
+	}
+
+	current := r.versions[rec.EventType]
+	if version > current {
+		return nil, fmt.Errorf("%s event has schema version %d, newer than the supported %d", rec.EventType, version, current)
+	}
+
+	payload := rec.Payload
+	for ; version < current; version++ {
+		up, ok := r.upcasters[rec.EventType][version]
+		if !ok {
+			return nil, fmt.Errorf("no upcaster for %s event from schema version %d", rec.EventType, version)
+		}
+
+		var err error
+		if payload, err = up(payload); err != nil {
+			return nil, fmt.Errorf("failed to upcast %s event from schema version %d: %w", rec.EventType, version, err)
+		}
+	}
+	return payload, nil
+}
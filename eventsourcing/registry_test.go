@@ -0,0 +1,241 @@
+package eventsourcing
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+type itemAdded struct {
+	ProductID string `json:"product_id"`
+	Quantity  int    `json:"quantity"`
+	Currency  string `json:"currency"`
+}
+
+func (itemAdded) EventType() string  { return "ItemAdded" }
+func (itemAdded) SchemaVersion() int { return 3 }
+
+type itemRemoved struct {
+	ProductID string `json:"product_id"`
+}
+
+func (itemRemoved) EventType() string { return "ItemRemoved" }
+
+// addField returns an upcaster setting key to value in a JSON object payload.
+func addField(key string, value interface{}) Upcaster {
+	return func(payload []byte) ([]byte, error) {
+		var fields map[string]interface{}
+		if err := json.Unmarshal(payload, &fields); err != nil {
+			return nil, err
+		}
+		fields[key] = value
+		return json.Marshal(fields)
+	}
+}
+
+func newTestRegistry() *Registry {
+	r := NewRegistry(itemAdded{}, itemRemoved{})
+	r.RegisterUpcaster("ItemAdded", 1, addField("quantity", 1))
+	r.RegisterUpcaster("ItemAdded", 2, addField("currency", "USD"))
+	return r
+}
+
+func TestRegistryDecode(t *testing.T) {
+	tests := []struct {
+		name    string
+		rec     EventRecord
+		want    Event
+		wantErr string
+	}{
+		{
+			name: "current version",
+			rec:  EventRecord{EventType: "ItemAdded", SchemaVersion: 3, Payload: []byte(`{"product_id":"p1","quantity":2,"currency":"EUR"}`)},
+			want: itemAdded{ProductID: "p1", Quantity: 2, Currency: "EUR"},
+		},
+		{
+			name: "upcast from version 1",
+			rec:  EventRecord{EventType: "ItemAdded", SchemaVersion: 1, Payload: []byte(`{"product_id":"p1"}`)},
+			want: itemAdded{ProductID: "p1", Quantity: 1, Currency: "USD"},
+		},
+		{
+			name: "upcast from version 2",
+			rec:  EventRecord{EventType: "ItemAdded", SchemaVersion: 2, Payload: []byte(`{"product_id":"p1","quantity":4}`)},
+			want: itemAdded{ProductID: "p1", Quantity: 4, Currency: "USD"},
+		},
+		{
+			name: "record without schema version is version 1",
+			rec:  EventRecord{EventType: "ItemAdded", Payload: []byte(`{"product_id":"p1"}`)},
+			want: itemAdded{ProductID: "p1", Quantity: 1, Currency: "USD"},
+		},
+		{
+			name: "unversioned event",
+			rec:  EventRecord{EventType: "ItemRemoved", Payload: []byte(`{"product_id":"p1"}`)},
+			want: itemRemoved{ProductID: "p1"},
+		},
+		{
+			name:    "unknown event type",
+			rec:     EventRecord{EventType: "ItemRenamed", Payload: []byte(`{}`)},
+			wantErr: "unknown event type",
+		},
+		{
+			name:    "newer schema version",
+			rec:     EventRecord{EventType: "ItemAdded", SchemaVersion: 4, Payload: []byte(`{}`)},
+			wantErr: "newer than the supported 3",
+		},
+		{
+			name:    "invalid payload",
+			rec:     EventRecord{EventType: "ItemRemoved", Payload: []byte(`{`)},
+			wantErr: "failed to decode ItemRemoved event",
+		},
+	}
+
+	r := newTestRegistry()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := r.Decode(tt.rec)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Decode error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Decode: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Decode = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRegistryDecodeMissingUpcaster(t *testing.T) {
+	r := NewRegistry(itemAdded{})
+	r.RegisterUpcaster("ItemAdded", 2, addField("currency", "USD"))
+
+	_, err := r.Decode(EventRecord{EventType: "ItemAdded", SchemaVersion: 1, Payload: []byte(`{}`)})
+	if err == nil || !strings.Contains(err.Error(), "no upcaster for ItemAdded event from schema version 1") {
+		t.Fatalf("Decode error = %v, want missing upcaster", err)
+	}
+}
+
+func TestRegistryDecodeUpcasterError(t *testing.T) {
+	upcastErr := errors.New("bad payload")
+	r := NewRegistry(itemAdded{})
+	r.RegisterUpcaster("ItemAdded", 1, func([]byte) ([]byte, error) { return nil, upcastErr })
+	r.RegisterUpcaster("ItemAdded", 2, addField("currency", "USD"))
+
+	_, err := r.Decode(EventRecord{EventType: "ItemAdded", SchemaVersion: 1, Payload: []byte(`{}`)})
+	if !errors.Is(err, upcastErr) {
+		t.Fatalf("Decode error = %v, want %v", err, upcastErr)
+	}
+}
+
+func TestRegistryPanics(t *testing.T) {
+	tests := []struct {
+		name string
+		fn   func(r *Registry)
+	}{
+		{name: "event type registered twice", fn: func(r *Registry) { r.Register(itemRemoved{}) }},
+		{name: "upcaster for unknown event type", fn: func(r *Registry) { r.RegisterUpcaster("ItemRenamed", 1, addField("x", 1)) }},
+		{name: "upcaster from current version", fn: func(r *Registry) { r.RegisterUpcaster("ItemAdded", 3, addField("x", 1)) }},
+		{name: "upcaster from version 0", fn: func(r *Registry) { r.RegisterUpcaster("ItemAdded", 0, addField("x", 1)) }},
+		{name: "upcaster for unversioned event", fn: func(r *Registry) { r.RegisterUpcaster("ItemRemoved", 1, addField("x", 1)) }},
+		{name: "upcaster registered twice", fn: func(r *Registry) { r.RegisterUpcaster("ItemAdded", 1, addField("x", 1)) }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("expected a panic")
+				}
+			}()
+			tt.fn(newTestRegistry())
+		})
+	}
+}
+
+// basket is a test aggregate holding product quantities.
+type basket struct {
+	AggregateBase
+	Items map[string]int
+}
+
+func (b *basket) ApplyEvent(e Event) error {
+	switch e := e.(type) {
+	case itemAdded:
+		b.Items[e.ProductID] += e.Quantity
+	case itemRemoved:
+		if _, ok := b.Items[e.ProductID]; !ok {
+			return fmt.Errorf("product %s is not in the basket", e.ProductID)
+		}
+		delete(b.Items, e.ProductID)
+	default:
+		return fmt.Errorf("unexpected event %T", e)
+	}
+	b.Version++
+	return nil
+}
+
+func TestRehydrate(t *testing.T) {
+	records := []EventRecord{
+		{EventType: "ItemAdded", SchemaVersion: 1, Payload: []byte(`{"product_id":"p1"}`)},
+		{EventType: "ItemAdded", SchemaVersion: 3, Payload: []byte(`{"product_id":"p2","quantity":2,"currency":"EUR"}`)},
+		{EventType: "ItemAdded", SchemaVersion: 2, Payload: []byte(`{"product_id":"p1","quantity":3}`)},
+		{EventType: "ItemRemoved", Payload: []byte(`{"product_id":"p2"}`)},
+	}
+
+	b := &basket{AggregateBase: AggregateBase{ID: "b1"}, Items: make(map[string]int)}
+	if err := Rehydrate(b, newTestRegistry(), records); err != nil {
+		t.Fatalf("Rehydrate: %v", err)
+	}
+	if b.GetVersion() != len(records) {
+		t.Errorf("version = %d, want %d", b.GetVersion(), len(records))
+	}
+	if len(b.Items) != 1 || b.Items["p1"] != 4 {
+		t.Errorf("items = %v, want map[p1:4]", b.Items)
+	}
+}
+
+func TestRehydrateStopsAtFirstError(t *testing.T) {
+	tests := []struct {
+		name    string
+		records []EventRecord
+		wantErr string
+	}{
+		{
+			name: "unknown event type",
+			records: []EventRecord{
+				{EventType: "ItemAdded", SchemaVersion: 3, Payload: []byte(`{"product_id":"p1","quantity":1}`)},
+				{EventType: "ItemRenamed", Payload: []byte(`{}`)},
+				{EventType: "ItemAdded", SchemaVersion: 3, Payload: []byte(`{"product_id":"p1","quantity":1}`)},
+			},
+			wantErr: "unknown event type in stream: ItemRenamed",
+		},
+		{
+			name: "event rejected by the aggregate",
+			records: []EventRecord{
+				{EventType: "ItemAdded", SchemaVersion: 3, Payload: []byte(`{"product_id":"p1","quantity":1}`)},
+				{EventType: "ItemRemoved", Payload: []byte(`{"product_id":"p2"}`)},
+				{EventType: "ItemAdded", SchemaVersion: 3, Payload: []byte(`{"product_id":"p1","quantity":1}`)},
+			},
+			wantErr: "failed to apply event from stream",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := &basket{Items: make(map[string]int)}
+			err := Rehydrate(b, newTestRegistry(), tt.records)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Rehydrate error = %v, want it to contain %q", err, tt.wantErr)
+			}
+			if b.GetVersion() != 1 {
+				t.Errorf("version = %d, want 1 event applied before the error", b.GetVersion())
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package eventsourcing
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"time"
+)
+
+// AnyVersion appends to a stream whatever its current version.
+const AnyVersion = -1
+
+// ErrConcurrencyConflict is returned by SaveEvents when the stream is no longer
+// at the expected version, because another writer appended to it first. The
+// caller should reload the aggregate and decide again.
+var ErrConcurrencyConflict = errors.New("concurrency conflict")
+
+// conflictBackoff is the delay before the first retry of RetryOnConflict. It
+// doubles with every retry and a random jitter of up to the same amount is
+// added, so concurrent writers of a stream serialize after a few retries.
+const conflictBackoff = 5 * time.Millisecond
+
+// RetryOnConflict runs fn until it succeeds, fails with an error other than
+// ErrConcurrencyConflict, or attempts is reached. fn receives the attempt
+// number, starting at 1, and must reload any state it depends on when
+// retried, since each retry follows a concurrent write.
+func RetryOnConflict(ctx context.Context, attempts int, fn func(attempt int) error) error {
+	backoff := conflictBackoff
+	for attempt := 1; ; attempt++ {
+		err := fn(attempt)
+		if err == nil || !errors.Is(err, ErrConcurrencyConflict) || attempt >= attempts {
+			return err
+		}
+
+		jitter := time.Duration(rand.Int64N(int64(backoff)))
+		timer := time.NewTimer(backoff + jitter)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+		backoff *= 2
+	}
+}
+
+// EventStore persists the event streams of aggregates.
+type EventStore interface {
+	// SaveEvents appends events to a stream in one transaction. Unless
+	// expectedVersion is AnyVersion, the append fails with
+	// ErrConcurrencyConflict if the stream is no longer at expectedVersion.
+	SaveEvents(ctx context.Context, streamID string, streamType string, expectedVersion int, events []Event) error
+	LoadEvents(ctx context.Context, streamID string) ([]EventRecord, error)
+	// LoadFromSnapshot returns the latest snapshot of the stream written with
+	// schemaVersion (nil if there is none) and the events recorded after it.
+	LoadFromSnapshot(ctx context.Context, streamID string, schemaVersion int) (*Snapshot, []EventRecord, error)
+	SaveSnapshot(ctx context.Context, snapshot Snapshot) error
+}
+
+// GlobalEventStore is an EventStore that also reads events across streams in
+// global position order.
+type GlobalEventStore interface {
+	EventStore
+	// ForEachEvent calls fn for every event of the given stream type in global
+	// position order, stopping at the first error.
+	ForEachEvent(ctx context.Context, streamType string, fn func(EventRecord) error) error
+	CountEvents(ctx context.Context, streamType string) (int64, error)
+	// ReadAll returns up to limit events of any stream whose global position is
+	// greater than fromPosition, in position order.
+	ReadAll(ctx context.Context, fromPosition int64, limit int) ([]EventRecord, error)
+	// SubscribeAll is a catch-up subscription: it delivers every event after
+	// fromPosition from history, then tails new events until ctx is cancelled
+	// or handler returns an error.
+	SubscribeAll(ctx context.Context, fromPosition int64, handler func(EventRecord) error) error
+}
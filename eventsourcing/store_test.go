@@ -0,0 +1,62 @@
+package eventsourcing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestRetryOnConflict(t *testing.T) {
+	otherErr := errors.New("boom")
+	conflict := fmt.Errorf("%w: stream s1 expected version 1, got 2", ErrConcurrencyConflict)
+
+	tests := []struct {
+		name         string
+		attempts     int
+		results      []error // returned by successive calls, the last one repeated
+		wantErr      error
+		wantAttempts int
+	}{
+		{name: "success", attempts: 5, results: []error{nil}, wantAttempts: 1},
+		{name: "success after conflicts", attempts: 5, results: []error{conflict, conflict, nil}, wantAttempts: 3},
+		{name: "other error is not retried", attempts: 5, results: []error{otherErr}, wantErr: otherErr, wantAttempts: 1},
+		{name: "gives up after attempts", attempts: 3, results: []error{conflict}, wantErr: ErrConcurrencyConflict, wantAttempts: 3},
+		{name: "single attempt", attempts: 1, results: []error{conflict}, wantErr: ErrConcurrencyConflict, wantAttempts: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			err := RetryOnConflict(context.Background(), tt.attempts, func(attempt int) error {
+				calls++
+				if attempt != calls {
+					t.Errorf("attempt = %d on call %d", attempt, calls)
+				}
+				return tt.results[min(calls, len(tt.results))-1]
+			})
+			if !errors.Is(err, tt.wantErr) || (tt.wantErr == nil && err != nil) {
+				t.Errorf("RetryOnConflict = %v, want %v", err, tt.wantErr)
+			}
+			if calls != tt.wantAttempts {
+				t.Errorf("fn called %d times, want %d", calls, tt.wantAttempts)
+			}
+		})
+	}
+}
+
+func TestRetryOnConflictStopsWhenCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	calls := 0
+	err := RetryOnConflict(ctx, 10, func(int) error {
+		calls++
+		cancel()
+		return ErrConcurrencyConflict
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("RetryOnConflict = %v, want %v", err, context.Canceled)
+	}
+	if calls != 1 {
+		t.Errorf("fn called %d times after cancellation, want 1", calls)
+	}
+}
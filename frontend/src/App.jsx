@@ -2,6 +2,14 @@ import { useState, useEffect, useCallback } from 'react'
 
 const API_BASE = '/api'
 
+// Test card accepted by the demo PaymentService.
+const DEMO_CARD = {
+    number: '4242-4242-4242-4242',
+    cvv: 123,
+    expiration_month: 12,
+    expiration_year: 2030,
+}
+
 function App() {
     const [products, setProducts] = useState([])
     const [orders, setOrders] = useState([])
@@ -37,7 +45,7 @@ function App() {
         if (activeTab !== 'orders') return
         fetch(`${API_BASE}/orders`)
             .then(res => res.json())
-            .then(data => setOrders(data || []))
+            .then(data => setOrders(data.orders || []))
             .catch(err => console.error('Failed to fetch orders:', err))
     }, [activeTab])
 
@@ -84,7 +92,7 @@ function App() {
             const res = await fetch(`${API_BASE}/orders`, {
                 method: 'POST',
                 headers: { 'Content-Type': 'application/json' },
-                body: JSON.stringify({ items: cart }),
+                body: JSON.stringify({ items: cart, credit_card: DEMO_CARD, currency: 'USD' }),
             })
 
             if (!res.ok) throw new Error('Failed to place order')
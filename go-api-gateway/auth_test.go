@@ -0,0 +1,186 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testSecret = "test-secret"
+
+// signToken builds a JWT with the given header and claims, signed with
+// HS256 under secret whatever alg the header names.
+func signToken(t *testing.T, secret string, header, claims map[string]interface{}) string {
+	t.Helper()
+	encode := func(v interface{}) string {
+		data, err := json.Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return base64.RawURLEncoding.EncodeToString(data)
+	}
+
+	unsigned := encode(header) + "." + encode(claims)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(unsigned))
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func hs256() map[string]interface{} {
+	return map[string]interface{}{"alg": "HS256", "typ": "JWT"}
+}
+
+func TestVerify(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	a := newAuthenticator(testSecret)
+	valid := signToken(t, testSecret, hs256(), map[string]interface{}{"sub": "customer-1", "exp": now.Add(time.Hour).Unix()})
+
+	tests := []struct {
+		name    string
+		token   string
+		want    principal
+		wantErr bool
+	}{
+		{
+			name:  "valid",
+			token: valid,
+			want:  principal{customerID: "customer-1"},
+		},
+		{
+			name:  "role claim",
+			token: signToken(t, testSecret, hs256(), map[string]interface{}{"sub": "customer-1", "role": "operator"}),
+			want:  principal{customerID: "customer-1", role: "operator"},
+		},
+		{
+			name:    "signed with another secret",
+			token:   signToken(t, "other-secret", hs256(), map[string]interface{}{"sub": "customer-1"}),
+			wantErr: true,
+		},
+		{
+			name:    "tampered claims",
+			token:   tamper(t, valid),
+			wantErr: true,
+		},
+		{
+			name:    "alg none",
+			token:   signToken(t, testSecret, map[string]interface{}{"alg": "none"}, map[string]interface{}{"sub": "customer-1"}),
+			wantErr: true,
+		},
+		{
+			name:    "alg HS512",
+			token:   signToken(t, testSecret, map[string]interface{}{"alg": "HS512"}, map[string]interface{}{"sub": "customer-1"}),
+			wantErr: true,
+		},
+		{
+			name:    "expired",
+			token:   signToken(t, testSecret, hs256(), map[string]interface{}{"sub": "customer-1", "exp": now.Add(-time.Second).Unix()}),
+			wantErr: true,
+		},
+		{
+			name:    "expires now",
+			token:   signToken(t, testSecret, hs256(), map[string]interface{}{"sub": "customer-1", "exp": now.Unix()}),
+			wantErr: true,
+		},
+		{
+			name:    "no subject",
+			token:   signToken(t, testSecret, hs256(), map[string]interface{}{"role": "operator"}),
+			wantErr: true,
+		},
+		{
+			name:    "malformed",
+			token:   "not-a-jwt",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := a.verify(tt.token, now)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("verify() = %+v, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("verify: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("verify() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// tamper swaps the claims of token for another subject, keeping its signature.
+func tamper(t *testing.T, token string) string {
+	t.Helper()
+	forged := strings.Split(signToken(t, testSecret, hs256(), map[string]interface{}{"sub": "customer-2"}), ".")
+	parts := strings.Split(token, ".")
+	return parts[0] + "." + forged[1] + "." + parts[2]
+}
+
+func TestMiddleware(t *testing.T) {
+	valid := signToken(t, testSecret, hs256(), map[string]interface{}{"sub": "customer-1", "exp": time.Now().Add(time.Hour).Unix()})
+	operator := signToken(t, testSecret, hs256(), map[string]interface{}{"sub": "operator-1", "role": "operator"})
+	expired := signToken(t, testSecret, hs256(), map[string]interface{}{"sub": "customer-1", "exp": time.Now().Add(-time.Minute).Unix()})
+
+	tests := []struct {
+		name         string
+		secret       string
+		token        string
+		wantStatus   int
+		wantCustomer string
+		wantRole     string
+	}{
+		{name: "anonymous", secret: testSecret, wantStatus: http.StatusOK},
+		{name: "customer", secret: testSecret, token: valid, wantStatus: http.StatusOK, wantCustomer: "customer-1"},
+		{name: "operator", secret: testSecret, token: operator, wantStatus: http.StatusOK, wantCustomer: "operator-1", wantRole: "operator"},
+		{name: "bad signature", secret: testSecret, token: tamper(t, valid), wantStatus: http.StatusUnauthorized},
+		{name: "expired", secret: testSecret, token: expired, wantStatus: http.StatusUnauthorized},
+		{name: "no secret", token: valid, wantStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var called bool
+			var gotCustomer, gotRole string
+			h := newAuthenticator(tt.secret).middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				called = true
+				gotCustomer, gotRole = r.Header.Get(customerIDHeader), r.Header.Get(roleHeader)
+			}))
+
+			r := httptest.NewRequest(http.MethodGet, "/api/orders", nil)
+			// Identity headers sent by the client must never reach the services.
+			r.Header.Set(customerIDHeader, "spoofed-customer")
+			r.Header.Set(roleHeader, "operator")
+			if tt.token != "" {
+				r.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if tt.wantStatus == http.StatusUnauthorized {
+				if called {
+					t.Error("next handler called for an invalid token")
+				}
+				if w.Header().Get("WWW-Authenticate") == "" {
+					t.Error("401 without WWW-Authenticate")
+				}
+				return
+			}
+			if gotCustomer != tt.wantCustomer || gotRole != tt.wantRole {
+				t.Errorf("forwarded customer %q role %q, want %q and %q", gotCustomer, gotRole, tt.wantCustomer, tt.wantRole)
+			}
+		})
+	}
+}
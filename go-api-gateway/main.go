@@ -27,8 +27,12 @@ func main() {
 	setupProxy(mux, "/api/cart", "http://cart-service:8080")
 	setupProxy(mux, "/api/cart/", "http://cart-service:8080")
 
-	// Apply CORS middleware
-	handler := enableCORS(mux)
+	// Resolve the customer from the bearer token, then apply CORS middleware
+	authSecret := os.Getenv("AUTH_JWT_SECRET")
+	if authSecret == "" {
+		slog.Warn("AUTH_JWT_SECRET is not set, all requests are anonymous")
+	}
+	handler := enableCORS(newAuthenticator(authSecret).middleware(mux))
 
 	slog.Info("API Gateway listening on port " + port)
 	if err := http.ListenAndServe(":"+port, handler); err != nil {
@@ -61,7 +65,7 @@ func enableCORS(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
 		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, Idempotency-Key")
 
 		if r.Method == http.MethodOptions {
 			w.WriteHeader(http.StatusOK)